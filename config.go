@@ -1,19 +1,83 @@
 package main
 
-import "time"
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
 
 type flags struct {
-	Kubeconfig               string
-	ResyncPeriodS            string
-	ResyncPeriod             time.Duration
-	StatusAddr               string
-	AllowAll                 bool
-	LogLevel                 string
-	LogFormat                string
-	ReplicateSecrets         bool
-	ReplicateConfigMaps      bool
-	ReplicateRoles           bool
-	ReplicateRoleBindings    bool
-	ReplicateServiceAccounts bool
-	SyncByContent            bool
+	Version                               bool
+	Kubeconfig                            string
+	ResyncPeriodS                         string
+	ResyncPeriod                          time.Duration
+	ResyncPeriodsS                        string
+	ResyncPeriods                         map[string]time.Duration
+	ResyncJitter                          float64
+	StatusAddr                            string
+	AllowAll                              bool
+	LogLevel                              string
+	LogFormat                             string
+	ReplicateSecrets                      bool
+	ReplicateConfigMaps                   bool
+	ReplicateRoles                        bool
+	ReplicateClusterRoles                 bool
+	ReplicateRoleBindings                 bool
+	ReplicateServiceAccounts              bool
+	ReplicateNetworkAttachmentDefinitions bool
+	ReplicateMiddlewares                  bool
+	SyncByContent                         bool
+	AdoptLegacyReplicas                   bool
+	RetainOnTombstone                     bool
+	PullAccessReviewServiceAccount        string
+	StampContentHash                      bool
+	CompatibilityModesS                   string
+	CompatibilityModes                    []string
+	ControllerIdentity                    string
+	RetryBackoffS                         string
+	RetryBackoff                          time.Duration
+	MaxRetryBackoffS                      string
+	MaxRetryBackoff                       time.Duration
+	WatchLabelSelector                    string
+	WatchSelector                         labels.Selector
+	ListPageSize                          int64
+	MetadataOnlyTargets                   bool
+	Workers                               int
+	WorkersPerKindS                       string
+	WorkersPerKind                        map[string]int
+	NamespaceWorkers                      int
+	MaxCreationsPerSecond                 float64
+	CheckpointFile                        string
+	CheckpointConfigMap                   string
+	CheckpointIntervalS                   string
+	CheckpointInterval                    time.Duration
+	MappingConfigMap                      string
+	MappingIntervalS                      string
+	MappingInterval                       time.Duration
+	DebouncePeriodS                       string
+	DebouncePeriod                        time.Duration
+	LogDeduplicationPeriodS               string
+	LogDeduplicationPeriod                time.Duration
+	StalenessThresholdS                   string
+	StalenessThreshold                    time.Duration
+	TenantLabel                           string
+	CollisionStrategy                     string
+	PropagateAnnotationsS                 string
+	PropagateAnnotations                  []string
+	MaxObjectSizesS                       string
+	MaxObjectSizes                        map[string]int64
+	AuditLogFile                          string
+	EventBurst                            int
+	NamespaceEventThreshold               int
+	EventIntervalS                        string
+	EventInterval                         time.Duration
+	OTLPEndpoint                          string
+	TraceSampleRatio                      float64
+	ReplicaMetrics                        bool
+	PushgatewayURL                        string
+	PushgatewayJob                        string
+	PushgatewayIntervalS                  string
+	PushgatewayInterval                   time.Duration
+	PushgatewayLabelsS                    string
+	PushgatewayLabels                     map[string]string
 }
@@ -8,7 +8,9 @@ import (
 )
 
 type response struct {
-	NotReady []string `json:"notReady"`
+	NotReady       []string                  `json:"notReady"`
+	Replicators    []common.ReplicatorStatus `json:"replicators"`
+	NamespaceQueue int                       `json:"namespaceQueue"`
 }
 
 // Handler implements a HTTP response handler that reports on the current
@@ -31,15 +33,33 @@ func (h *Handler) notReadyComponents() []string {
 	return notReady
 }
 
+// replicatorStatuses reports the details of all replicators that can report them
+func (h *Handler) replicatorStatuses() []common.ReplicatorStatus {
+	statuses := make([]common.ReplicatorStatus, 0, len(h.Replicators))
+
+	for _, replicator := range h.Replicators {
+		if reporter, ok := replicator.(common.StatusReporter); ok {
+			status := reporter.Status()
+			status.Synced = replicator.Synced()
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
 //noinspection GoUnusedParameter
 func (h *Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	if req.URL.Path == "/healthz" {
 		res.WriteHeader(http.StatusOK)
 	} else {
 		r := response{
-			NotReady: h.notReadyComponents(),
+			NotReady:       h.notReadyComponents(),
+			Replicators:    h.replicatorStatuses(),
+			NamespaceQueue: common.NamespaceQueueLength(),
 		}
 
+		res.Header().Set("Content-Type", "application/json")
 		if len(r.NotReady) > 0 {
 			res.WriteHeader(http.StatusServiceUnavailable)
 		} else {
@@ -1,6 +1,7 @@
 package liveness
 
 import (
+	"encoding/json"
 	"github.com/mittwald/kubernetes-replicator/replicate/common"
 	v1 "k8s.io/api/core/v1"
 	"net/http"
@@ -26,6 +27,15 @@ func (r *MockReplicator) NamespaceAdded(ns *v1.Namespace) {
 	// Do nothing
 }
 
+type MockStatusReplicator struct {
+	MockReplicator
+	status common.ReplicatorStatus
+}
+
+func (r *MockStatusReplicator) Status() common.ReplicatorStatus {
+	return r.status
+}
+
 func buildReqRes(t *testing.T) (*http.Request, *httptest.ResponseRecorder) {
 	req, err := http.NewRequest("GET", "/status", nil)
 	res := httptest.NewRecorder()
@@ -63,3 +73,27 @@ func TestReturns503IfOneReplicatorIsNotSynced(t *testing.T) {
 
 	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
 }
+
+func TestReportsDetailsOfReplicators(t *testing.T) {
+	req, res := buildReqRes(t)
+
+	handler := Handler{
+		Replicators: []common.Replicator{
+			&MockReplicator{synced: true},
+			&MockStatusReplicator{
+				MockReplicator: MockReplicator{synced: false},
+				status:         common.ReplicatorStatus{Kind: "Secret", PendingRetries: 3, PendingDebounces: 1},
+			},
+		},
+	}
+
+	handler.ServeHTTP(res, req)
+
+	var r response
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&r))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, []string{"*liveness.MockStatusReplicator"}, r.NotReady)
+	assert.Equal(t, []common.ReplicatorStatus{
+		{Kind: "Secret", Synced: false, PendingRetries: 3, PendingDebounces: 1},
+	}, r.Replicators)
+}
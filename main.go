@@ -2,21 +2,36 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/mittwald/kubernetes-replicator/replicate/clusterrole"
 	"github.com/mittwald/kubernetes-replicator/replicate/common"
 	"github.com/mittwald/kubernetes-replicator/replicate/configmap"
+	"github.com/mittwald/kubernetes-replicator/replicate/middleware"
+	"github.com/mittwald/kubernetes-replicator/replicate/networkattachmentdefinition"
 	"github.com/mittwald/kubernetes-replicator/replicate/role"
 	"github.com/mittwald/kubernetes-replicator/replicate/rolebinding"
 	"github.com/mittwald/kubernetes-replicator/replicate/secret"
 	"github.com/mittwald/kubernetes-replicator/replicate/serviceaccount"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mittwald/kubernetes-replicator/liveness"
+	"github.com/mittwald/kubernetes-replicator/pushgateway"
+	"github.com/mittwald/kubernetes-replicator/replications"
+	"github.com/mittwald/kubernetes-replicator/stats"
+	"github.com/mittwald/kubernetes-replicator/version"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -27,6 +42,8 @@ func init() {
 	var err error
 	flag.StringVar(&f.Kubeconfig, "kubeconfig", "", "path to Kubernetes config file")
 	flag.StringVar(&f.ResyncPeriodS, "resync-period", "30m", "resynchronization period")
+	flag.StringVar(&f.ResyncPeriodsS, "resync-periods", "", "comma-separated list of resynchronization periods per kind that override --resync-period, e.g. 'Secret=10m,ConfigMap=1h'")
+	flag.Float64Var(&f.ResyncJitter, "resync-jitter", 0.1, "maximum fraction by which the resynchronization period of each kind is randomly extended, so that kinds are not resynchronized at the same time")
 	flag.StringVar(&f.StatusAddr, "status-addr", ":9102", "listen address for status and monitoring server")
 	flag.StringVar(&f.LogLevel, "log-level", "info", "Log level (trace, debug, info, warn, error)")
 	flag.StringVar(&f.LogFormat, "log-format", "plain", "Log format (plain, json)")
@@ -34,9 +51,51 @@ func init() {
 	flag.BoolVar(&f.ReplicateSecrets, "replicate-secrets", true, "Enable replication of secrets")
 	flag.BoolVar(&f.ReplicateConfigMaps, "replicate-configmaps", true, "Enable replication of config maps")
 	flag.BoolVar(&f.ReplicateRoles, "replicate-roles", true, "Enable replication of roles")
+	flag.BoolVar(&f.ReplicateClusterRoles, "replicate-cluster-roles", false, "Enable projection of cluster roles into namespaced roles")
 	flag.BoolVar(&f.ReplicateRoleBindings, "replicate-role-bindings", true, "Enable replication of role bindings")
 	flag.BoolVar(&f.ReplicateServiceAccounts, "replicate-service-accounts", true, "Enable replication of service accounts")
+	flag.BoolVar(&f.ReplicateNetworkAttachmentDefinitions, "replicate-network-attachment-definitions", false, "Enable replication of Multus network attachment definitions")
+	flag.BoolVar(&f.ReplicateMiddlewares, "replicate-middlewares", false, "Enable replication of Traefik middlewares")
 	flag.BoolVar(&f.SyncByContent, "sync-by-content", false, "Always compare the contents of source and target resources and force them to be the same")
+	flag.BoolVar(&f.AdoptLegacyReplicas, "adopt-legacy-replicas", false, "Adopt replicas created by the legacy replication engine into the current bookkeeping instead of leaving them untouched")
+	flag.BoolVar(&f.RetainOnTombstone, "retain-on-tombstone", false, "Keep the replicas of sources whose deletion was not observed directly, but only inferred after the watch on the API server was interrupted")
+	flag.StringVar(&f.PullAccessReviewServiceAccount, "pull-access-review-service-account", "", "name of a service account in the target namespace of pull replications that must be allowed to get the source via RBAC (checked with a SubjectAccessReview); disabled if empty")
+	flag.BoolVar(&f.StampContentHash, "stamp-content-hash", false, "Annotate replicated secrets and config maps with a hash of their data, so that workloads can be rolled out when it changes")
+	flag.StringVar(&f.CompatibilityModesS, "compatibility-annotations", "", "comma-separated list of other replication tools whose annotations are honored as well (kubed, reflector)")
+	flag.StringVar(&f.ControllerIdentity, "controller-identity", "kubernetes-replicator", "identity of this replicator instance that is recorded in the replicated-by-controller annotation of pushed replicas")
+	flag.StringVar(&f.RetryBackoffS, "retry-backoff", "1s", "delay before a failed replication is retried; doubles with every further attempt (0 to only retry on resync)")
+	flag.StringVar(&f.MaxRetryBackoffS, "max-retry-backoff", "5m", "maximum delay between retries of a failed replication")
+	flag.StringVar(&f.DebouncePeriodS, "debounce-period", "0s", "time an updated source has to remain unchanged before it is replicated, so that bursts of updates are replicated only once (0 to replicate every update immediately)")
+	flag.StringVar(&f.LogDeduplicationPeriodS, "log-deduplication-period", "10m", "time during which a repeated failure of the same replication is logged only once; repetitions are summarized afterwards (0 to log every failure)")
+	flag.StringVar(&f.StalenessThresholdS, "staleness-threshold", "10m", "time after which a target that has not been updated to the version of its source is reported as stale by the stale_targets metric (0 to disable)")
+	flag.IntVar(&f.Workers, "workers", 1, "number of namespaces each replicator replicates a source into concurrently")
+	flag.StringVar(&f.WorkersPerKindS, "workers-per-kind", "", "comma-separated list of numbers of workers per kind that override --workers, e.g. 'Secret=8,ConfigMap=2'")
+	flag.IntVar(&f.NamespaceWorkers, "namespace-workers", 4, "number of namespace events (e.g. newly created namespaces) that are processed concurrently")
+	flag.StringVar(&f.CheckpointFile, "checkpoint-file", "", "file in which a snapshot of the replicas is saved periodically, so that a restarted replicator can skip up-to-date replicas")
+	flag.StringVar(&f.CheckpointConfigMap, "checkpoint-configmap", "", "config map ('<namespace>/<name>') in which a snapshot of the replicas is saved periodically, instead of a file")
+	flag.StringVar(&f.CheckpointIntervalS, "checkpoint-interval", "1m", "interval in which the snapshot of the replicas is saved")
+	flag.StringVar(&f.MappingConfigMap, "mapping-configmap", "", "config map ('<namespace>/<name>') that is kept up-to-date with what is replicated where, so that it can be inspected without access to the replicator; disabled if empty")
+	flag.StringVar(&f.MappingIntervalS, "mapping-interval", "1m", "interval in which the mapping config map is updated")
+	flag.Float64Var(&f.MaxCreationsPerSecond, "max-creations-per-second", 0, "maximum number of targets created per second by all replicators; further creations are queued (0 for no limit)")
+	flag.BoolVar(&f.MetadataOnlyTargets, "metadata-only-targets", false, "Cache only the metadata of secrets and config maps replicated in push mode, fetching them from the API when they are updated")
+	flag.Int64Var(&f.ListPageSize, "list-page-size", 500, "maximum number of objects that are requested per list call when the informers are (re)started (0 to list all objects in a single call)")
+	flag.StringVar(&f.WatchLabelSelector, "watch-label-selector", "", "label selector that restricts the watched secrets, config maps and other resources, e.g. 'replicator.v1.mittwald.de/enabled=true'; sources, replicas and pull targets must match it (all objects are watched if empty)")
+	flag.StringVar(&f.TenantLabel, "tenant-label", "", "namespace label that identifies the tenant a namespace belongs to; required for the replicate-to-same-tenant annotation")
+	flag.StringVar(&f.CollisionStrategy, "collision-strategy", common.CollisionStrategyError, "how to handle sources whose replicas would have the same name in a target namespace (error, first-wins, suffix-by-source)")
+	flag.StringVar(&f.PropagateAnnotationsS, "propagate-annotations", "", "comma-separated list of annotation keys or prefixes (ending with '/') that are copied from source to replicated resources, e.g. 'reloader.stakater.com/,wave.pusher.com/'")
+	flag.StringVar(&f.MaxObjectSizesS, "max-object-sizes", "", "comma-separated list of maximum sizes of replicated objects per kind, e.g. 'Secret=256Ki,ConfigMap=512Ki'")
+	flag.IntVar(&f.EventBurst, "event-burst", 25, "number of events that may be recorded at once for a source or replica; further events are dropped")
+	flag.IntVar(&f.NamespaceEventThreshold, "namespace-event-threshold", 0, "number of failed creations of a replica in a row after which warning events are recorded in its target namespace (0 to disable)")
+	flag.StringVar(&f.EventIntervalS, "event-interval", "5m", "time after which another event may be recorded for a source or replica once --event-burst is exhausted")
+	flag.StringVar(&f.AuditLogFile, "audit-log-file", "", "file to which a JSON record of every creation, update and deletion of a replica is appended; disabled if empty")
+	flag.StringVar(&f.OTLPEndpoint, "otlp-endpoint", "", "URL of an OpenTelemetry collector to which traces are exported via OTLP/HTTP, e.g. 'http://otel-collector:4318'; disabled if empty")
+	flag.Float64Var(&f.TraceSampleRatio, "trace-sample-ratio", 1, "fraction of traces that are exported to the OpenTelemetry collector")
+	flag.BoolVar(&f.ReplicaMetrics, "replica-metrics", false, "export the number of replicas per target namespace and source; this yields a time series per replica, which can be a lot in large clusters")
+	flag.StringVar(&f.PushgatewayURL, "pushgateway-url", "", "URL of a Prometheus Pushgateway to push metrics to; disabled if empty")
+	flag.StringVar(&f.PushgatewayJob, "pushgateway-job", "kubernetes-replicator", "job name under which metrics are pushed to the Pushgateway")
+	flag.StringVar(&f.PushgatewayIntervalS, "pushgateway-interval", "1m", "interval in which metrics are pushed to the Pushgateway")
+	flag.StringVar(&f.PushgatewayLabelsS, "pushgateway-labels", "", "comma-separated list of grouping labels for the Pushgateway, e.g. 'cluster=prod,region=eu'")
+	flag.BoolVar(&f.Version, "version", false, "print the version of the replicator and exit")
 	flag.Parse()
 
 	switch strings.ToUpper(strings.TrimSpace(f.LogLevel)) {
@@ -55,15 +114,117 @@ func init() {
 	default:
 		log.SetLevel(log.InfoLevel)
 	}
+	info := version.Current()
+	if f.Version {
+		fmt.Printf("kubernetes-replicator %s (commit %s, built %s with %s)\n", info.Version, info.Commit, info.Date, info.GoVersion)
+		os.Exit(0)
+	}
+
 	if strings.ToUpper(strings.TrimSpace(f.LogFormat)) == "JSON" {
 		log.SetFormatter(&log.JSONFormatter{})
 	}
 
+	log.Infof("kubernetes-replicator %s (commit %s)", info.Version, info.Commit)
+	version.Register(info)
+
 	f.ResyncPeriod, err = time.ParseDuration(f.ResyncPeriodS)
 	if err != nil {
 		panic(err)
 	}
 
+	f.ResyncPeriods, err = common.ParseResyncPeriods(f.ResyncPeriodsS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.WorkersPerKind, err = common.ParseWorkersPerKind(f.WorkersPerKindS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.RetryBackoff, err = time.ParseDuration(f.RetryBackoffS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.MaxRetryBackoff, err = time.ParseDuration(f.MaxRetryBackoffS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.WatchSelector, err = labels.Parse(f.WatchLabelSelector)
+	if err != nil {
+		panic(err)
+	}
+
+	f.DebouncePeriod, err = time.ParseDuration(f.DebouncePeriodS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.LogDeduplicationPeriod, err = time.ParseDuration(f.LogDeduplicationPeriodS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.StalenessThreshold, err = time.ParseDuration(f.StalenessThresholdS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.MaxObjectSizes, err = common.ParseSizeLimits(f.MaxObjectSizesS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.PushgatewayInterval, err = time.ParseDuration(f.PushgatewayIntervalS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.CheckpointInterval, err = time.ParseDuration(f.CheckpointIntervalS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.EventInterval, err = time.ParseDuration(f.EventIntervalS)
+	if err != nil {
+		panic(err)
+	}
+
+	f.MappingInterval, err = time.ParseDuration(f.MappingIntervalS)
+	if err != nil {
+		panic(err)
+	}
+
+	if f.CheckpointFile != "" && f.CheckpointConfigMap != "" {
+		panic(fmt.Errorf("--checkpoint-file and --checkpoint-configmap are mutually exclusive"))
+	}
+
+	f.PushgatewayLabels, err = pushgateway.ParseLabels(f.PushgatewayLabelsS)
+	if err != nil {
+		panic(err)
+	}
+
+	if f.TraceSampleRatio < 0 || f.TraceSampleRatio > 1 {
+		panic(fmt.Errorf("invalid trace sample ratio %v; must be between 0 and 1", f.TraceSampleRatio))
+	}
+
+	if !slices.Contains(common.CollisionStrategies, f.CollisionStrategy) {
+		panic(fmt.Errorf("invalid collision strategy %q; must be one of %v", f.CollisionStrategy, common.CollisionStrategies))
+	}
+
+	f.CompatibilityModes, err = common.ParseCompatibilityModes(f.CompatibilityModesS)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, annotation := range strings.Split(f.PropagateAnnotationsS, ",") {
+		if annotation = strings.TrimSpace(annotation); annotation != "" {
+			f.PropagateAnnotations = append(f.PropagateAnnotations, annotation)
+		}
+	}
+
 	log.Debugf("using flag values %#v", f)
 }
 
@@ -72,6 +233,7 @@ func main() {
 	var config *rest.Config
 	var err error
 	var client kubernetes.Interface
+	var dynamicClient dynamic.Interface
 	var enabledReplicators []common.Replicator
 
 	if f.Kubeconfig == "" {
@@ -87,45 +249,166 @@ func main() {
 	}
 
 	client = kubernetes.NewForConfigOrDie(config)
+	dynamicClient = dynamic.NewForConfigOrDie(config)
+	metadataClient := metadata.NewForConfigOrDie(config)
+
+	var checkpointStore common.CheckpointStore
+	if f.CheckpointFile != "" {
+		checkpointStore = &common.FileCheckpointStore{Path: f.CheckpointFile}
+	} else if f.CheckpointConfigMap != "" {
+		checkpointStore, err = common.NewConfigMapCheckpointStore(client, f.CheckpointConfigMap)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var mapping *common.MappingConfigMap
+	if f.MappingConfigMap != "" {
+		mapping, err = common.NewMappingConfigMap(client, f.MappingConfigMap)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if f.OTLPEndpoint != "" {
+		if err := setupTracing(f.OTLPEndpoint, f.ControllerIdentity, f.TraceSampleRatio); err != nil {
+			panic(err)
+		}
+	}
+
+	var auditLog *common.AuditLog
+	if f.AuditLogFile != "" {
+		auditLog, err = common.NewFileAuditLog(f.AuditLogFile)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var checkpoint common.Checkpoint
+	if checkpointStore != nil {
+		checkpoint, err = checkpointStore.Load()
+		if err != nil {
+			log.WithError(err).Warn("could not load checkpoint; replicating all sources")
+		}
+	}
+
+	replicatorConfig := common.ReplicatorConfig{
+		Client:                         client,
+		ResyncPeriod:                   f.ResyncPeriod,
+		ResyncPeriods:                  f.ResyncPeriods,
+		ResyncJitter:                   f.ResyncJitter,
+		AllowAll:                       f.AllowAll,
+		SyncByContent:                  f.SyncByContent,
+		AdoptLegacyReplicas:            f.AdoptLegacyReplicas,
+		RetainOnTombstone:              f.RetainOnTombstone,
+		PullAccessReviewServiceAccount: f.PullAccessReviewServiceAccount,
+		StampContentHash:               f.StampContentHash,
+		CompatibilityModes:             f.CompatibilityModes,
+		ControllerIdentity:             f.ControllerIdentity,
+		RetryBackoff:                   f.RetryBackoff,
+		MaxRetryBackoff:                f.MaxRetryBackoff,
+		WatchSelector:                  f.WatchSelector,
+		ListPageSize:                   f.ListPageSize,
+		MetadataOnlyTargets:            f.MetadataOnlyTargets,
+		Workers:                        f.Workers,
+		WorkersPerKind:                 f.WorkersPerKind,
+		NamespaceWorkers:               f.NamespaceWorkers,
+		MaxCreationsPerSecond:          float32(f.MaxCreationsPerSecond),
+		DebouncePeriod:                 f.DebouncePeriod,
+		LogDeduplicationPeriod:         f.LogDeduplicationPeriod,
+		StalenessThreshold:             f.StalenessThreshold,
+		NamespaceEventThreshold:        f.NamespaceEventThreshold,
+		MetadataClient:                 metadataClient,
+		TenantLabel:                    f.TenantLabel,
+		CollisionStrategy:              f.CollisionStrategy,
+		MaxObjectSizes:                 f.MaxObjectSizes,
+		EventRecorder:                  common.NewEventRecorder(client, common.EventOptions{Burst: f.EventBurst, Interval: f.EventInterval}),
+		AuditLog:                       auditLog,
+		PropagatedAnnotations:          f.PropagateAnnotations,
+		DynamicClient:                  dynamicClient,
+		Checkpoint:                     checkpoint,
+	}
 
 	if f.ReplicateSecrets {
-		secretRepl := secret.NewReplicator(client, f.ResyncPeriod, f.AllowAll, f.SyncByContent)
+		secretRepl := secret.NewReplicator(replicatorConfig)
 		go secretRepl.Run()
 		enabledReplicators = append(enabledReplicators, secretRepl)
 	}
 
 	if f.ReplicateConfigMaps {
-		configMapRepl := configmap.NewReplicator(client, f.ResyncPeriod, f.AllowAll, f.SyncByContent)
+		configMapRepl := configmap.NewReplicator(replicatorConfig)
 		go configMapRepl.Run()
 		enabledReplicators = append(enabledReplicators, configMapRepl)
 	}
 
 	if f.ReplicateRoles {
-		roleRepl := role.NewReplicator(client, f.ResyncPeriod, f.AllowAll)
+		roleRepl := role.NewReplicator(replicatorConfig)
 		go roleRepl.Run()
 		enabledReplicators = append(enabledReplicators, roleRepl)
 	}
 
+	if f.ReplicateClusterRoles {
+		clusterRoleRepl := clusterrole.NewReplicator(replicatorConfig)
+		go clusterRoleRepl.Run()
+		enabledReplicators = append(enabledReplicators, clusterRoleRepl)
+	}
+
 	if f.ReplicateRoleBindings {
-		roleBindingRepl := rolebinding.NewReplicator(client, f.ResyncPeriod, f.AllowAll)
+		roleBindingRepl := rolebinding.NewReplicator(replicatorConfig)
 		go roleBindingRepl.Run()
 		enabledReplicators = append(enabledReplicators, roleBindingRepl)
 	}
 
 	if f.ReplicateServiceAccounts {
-		serviceAccountRepl := serviceaccount.NewReplicator(client, f.ResyncPeriod, f.AllowAll)
+		serviceAccountRepl := serviceaccount.NewReplicator(replicatorConfig)
 		go serviceAccountRepl.Run()
 		enabledReplicators = append(enabledReplicators, serviceAccountRepl)
 	}
 
+	if f.ReplicateNetworkAttachmentDefinitions {
+		networkAttachmentDefinitionRepl := networkattachmentdefinition.NewReplicator(dynamicClient, replicatorConfig)
+		go networkAttachmentDefinitionRepl.Run()
+		enabledReplicators = append(enabledReplicators, networkAttachmentDefinitionRepl)
+	}
+
+	if f.ReplicateMiddlewares {
+		middlewareResource, err := middleware.ServedResource(client.Discovery())
+		if err != nil {
+			panic(err)
+		}
+
+		log.Infof("replicating middlewares of %s", middlewareResource.GroupVersion())
+		middlewareRepl := middleware.NewReplicator(dynamicClient, middlewareResource, replicatorConfig)
+		go middlewareRepl.Run()
+		enabledReplicators = append(enabledReplicators, middlewareRepl)
+	}
+
 	h := liveness.Handler{
 		Replicators: enabledReplicators,
 	}
 
+	prometheus.MustRegister(&common.CacheCollector{Replicators: enabledReplicators, Replicas: f.ReplicaMetrics})
+
+	if checkpointStore != nil {
+		go common.RunCheckpoints(checkpointStore, enabledReplicators, f.CheckpointInterval)
+	}
+
+	if mapping != nil {
+		go common.RunMappingUpdates(mapping, enabledReplicators, f.MappingInterval)
+	}
+
+	if f.PushgatewayURL != "" {
+		go pushgateway.Run(f.PushgatewayURL, f.PushgatewayJob, f.PushgatewayLabels, f.PushgatewayInterval)
+	}
+
 	log.Infof("starting liveness monitor at %s", f.StatusAddr)
 
 	http.Handle("/healthz", &h)
 	http.Handle("/readyz", &h)
+	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/debug/stats", &stats.Handler{Replicators: enabledReplicators})
+	http.Handle("/version", &version.Handler{Info: version.Current()})
+	http.Handle("/api/v1/replications", &replications.Handler{Replicators: enabledReplicators})
 	err = http.ListenAndServe(f.StatusAddr, nil)
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,54 @@
+package pushgateway
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	log "github.com/sirupsen/logrus"
+)
+
+// ParseLabels parses grouping labels of the form "key1=value1,key2=value2"
+func ParseLabels(labels string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, entry := range strings.Split(labels, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid pushgateway label %q: expected '<name>=<value>'", entry)
+		}
+
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+// newPusher creates a pusher for the metrics of the gatherer, which are grouped by the job and the given labels
+func newPusher(url string, job string, labels map[string]string, gatherer prometheus.Gatherer) *push.Pusher {
+	pusher := push.New(url, job).Gatherer(gatherer)
+	for name, value := range labels {
+		pusher = pusher.Grouping(name, value)
+	}
+	return pusher
+}
+
+// Run periodically pushes all registered metrics to a Prometheus Pushgateway
+func Run(url string, job string, labels map[string]string, interval time.Duration) {
+	pusher := newPusher(url, job, labels, prometheus.DefaultGatherer)
+
+	log.Infof("pushing metrics to %s every %s", url, interval)
+
+	for range time.Tick(interval) {
+		if err := pusher.Push(); err != nil {
+			log.WithError(err).Warnf("could not push metrics to %s", url)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package pushgateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels("cluster=prod, region = eu,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"cluster": "prod", "region": "eu"}, labels)
+
+	labels, err = ParseLabels("")
+	assert.NoError(t, err)
+	assert.Empty(t, labels)
+
+	_, err = ParseLabels("cluster")
+	assert.Error(t, err)
+
+	_, err = ParseLabels("=prod")
+	assert.Error(t, err)
+}
+
+func TestPushesMetricsWithGroupingLabels(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		data, _ := io.ReadAll(req.Body)
+		path, body = req.URL.Path, string(data)
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "kubernetes_replicator_test_total", Help: "test"})
+	registry.MustRegister(counter)
+	counter.Inc()
+
+	pusher := newPusher(server.URL, "kubernetes-replicator", map[string]string{"cluster": "prod"}, registry)
+	assert.NoError(t, pusher.Push())
+
+	assert.Equal(t, "/metrics/job/kubernetes-replicator/cluster/prod", path)
+	assert.Contains(t, body, "kubernetes_replicator_test_total")
+}
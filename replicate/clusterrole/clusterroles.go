@@ -0,0 +1,194 @@
+package clusterrole
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Replicator projects the rules of cluster roles into namespaced roles. Only push-based replication
+// (replicate-to and replicate-to-matching) is supported.
+type Replicator struct {
+	*common.GenericReplicator
+	RoleController cache.Controller
+}
+
+// NewReplicator creates a new cluster role replicator
+func NewReplicator(config common.ReplicatorConfig) common.Replicator {
+	client := config.Client
+	config.Kind = "ClusterRole"
+	config.ObjType = &rbacv1.ClusterRole{}
+	// projected roles grant permissions, so they must not outlive the annotations that requested them
+	config.DeleteUnselectedReplicas = true
+	config.ListFunc = func(lo metav1.ListOptions) (runtime.Object, error) {
+		return client.RbacV1().ClusterRoles().List(context.TODO(), lo)
+	}
+	config.WatchFunc = func(lo metav1.ListOptions) (watch.Interface, error) {
+		return client.RbacV1().ClusterRoles().Watch(context.TODO(), lo)
+	}
+
+	repl := Replicator{
+		GenericReplicator: common.NewGenericReplicator(config),
+	}
+	repl.TargetStore, repl.RoleController = cache.NewInformerWithOptions(cache.InformerOptions{
+		ListerWatcher: common.InstrumentListWatch("ClusterRole/roles", &cache.ListWatch{
+			ListFunc: common.PagedListFunc(func(lo metav1.ListOptions) (runtime.Object, error) {
+				return client.RbacV1().Roles("").List(context.TODO(), lo)
+			}, config.ListPageSize),
+			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+				return client.RbacV1().Roles("").Watch(context.TODO(), lo)
+			},
+		}),
+		ObjectType:   &rbacv1.Role{},
+		ResyncPeriod: repl.ResyncPeriod,
+		Handler:      cache.ResourceEventHandlerFuncs{},
+		Indexers:     common.Indexers,
+		Transform:    common.StripManagedFields,
+	})
+	repl.UpdateFuncs = common.UpdateFuncs{
+		ReplicateDataFrom:        repl.ReplicateDataFrom,
+		ReplicateObjectTo:        repl.ReplicateObjectTo,
+		PatchDeleteDependent:     repl.PatchDeleteDependent,
+		DeleteReplicatedResource: repl.DeleteReplicatedResource,
+	}
+
+	return &repl
+}
+
+func (r *Replicator) Run() {
+	go r.RoleController.Run(wait.NeverStop)
+	r.GenericReplicator.Run()
+}
+
+func (r *Replicator) Synced() bool {
+	return r.RoleController.HasSynced() && r.GenericReplicator.Synced()
+}
+
+// ReplicateDataFrom is not supported for cluster roles
+func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interface{}) error {
+	return errors.Errorf("pull-based replication is not supported for %s %s", r.Kind, common.MustGetKey(targetObj))
+}
+
+// ReplicateObjectTo creates a role with the rules of the cluster role in the target namespace
+func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespace) error {
+	source := sourceObj.(*rbacv1.ClusterRole)
+	targetLocation := fmt.Sprintf("%s/%s", target.Name, r.ResolveTargetName(source, target.Name))
+
+	logger := log.
+		WithField("kind", r.Kind).
+		WithField("source", common.MustGetKey(source)).
+		WithField("target", targetLocation)
+
+	targetResource, exists, err := r.TargetStore.GetByKey(targetLocation)
+	if err != nil {
+		return errors.Wrapf(err, "Could not get %s from cache!", targetLocation)
+	}
+	logger.Infof("Checking if %s exists? %v", targetLocation, exists)
+
+	var targetCopy *rbacv1.Role
+	if exists {
+		targetObject := targetResource.(*rbacv1.Role)
+		targetVersion, ok := targetObject.Annotations[common.ReplicatedFromVersionAnnotation]
+		sourceVersion := source.ResourceVersion
+
+		if ok && targetVersion == sourceVersion {
+			logger.Debugf("Role %s is already up-to-date", common.MustGetKey(targetObject))
+			return nil
+		}
+
+		targetCopy = targetObject.DeepCopy()
+		common.StripTranslatedAnnotations(targetCopy)
+	} else {
+		targetCopy = new(rbacv1.Role)
+	}
+
+	if ownerReferences, ok := r.TargetOwnerReferences(source, target.Name); ok {
+		targetCopy.OwnerReferences = ownerReferences
+	}
+
+	if targetCopy.Annotations == nil {
+		targetCopy.Annotations = make(map[string]string)
+	}
+
+	labelsCopy := make(map[string]string)
+
+	stripLabels, ok := source.Annotations[common.StripLabels]
+	if !ok && stripLabels != "true" {
+		if source.Labels != nil {
+			for key, value := range source.Labels {
+				labelsCopy[key] = value
+			}
+		}
+	}
+
+	targetCopy.Name = r.ResolveTargetName(source, target.Name)
+	common.StripLabelsWithPrefixes(source, labelsCopy)
+	common.AddLabels(source, labelsCopy)
+	common.SetTrackingLabels(source, labelsCopy)
+	targetCopy.Labels = labelsCopy
+	targetCopy.Rules = source.Rules
+	if targetCopy.Rules == nil {
+		targetCopy.Rules = make([]rbacv1.PolicyRule, 0)
+	}
+	r.PropagateAnnotations(source.Annotations, targetCopy.Annotations)
+	contentHash := r.ContentHash(source, targetCopy.Rules)
+	if exists && common.HasContentHash(targetCopy, contentHash) {
+		logger.Debugf("content of %s is unchanged; not updating it", targetLocation)
+		return nil
+	}
+	targetCopy.Annotations[common.ReplicatedContentHashAnnotation] = contentHash
+	targetCopy.Annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	r.SetProvenanceAnnotations(source, targetCopy.Annotations)
+	targetCopy.Annotations[common.ReplicatedFromVersionAnnotation] = source.ResourceVersion
+
+	var obj interface{}
+	if exists {
+		logger.Debugf("Updating existing role %s/%s", target.Name, targetCopy.Name)
+		obj, err = r.Client.RbacV1().Roles(target.Name).Update(context.TODO(), targetCopy, metav1.UpdateOptions{})
+	} else {
+		logger.Debugf("Creating a new role %s/%s", target.Name, targetCopy.Name)
+		obj, err = r.Client.RbacV1().Roles(target.Name).Create(context.TODO(), targetCopy, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Failed to update role %s/%s", target.Name, targetCopy.Name)
+	}
+
+	if err := r.TargetStore.Update(obj); err != nil {
+		return errors.Wrapf(err, "Failed to update cache for %s/%s", target.Name, targetCopy)
+	}
+
+	return nil
+}
+
+// PatchDeleteDependent is not supported for cluster roles
+func (r *Replicator) PatchDeleteDependent(sourceKey string, target interface{}) (interface{}, error) {
+	return nil, errors.Errorf("pull-based replication is not supported for %s %s", r.Kind, common.MustGetKey(target))
+}
+
+// DeleteReplicatedResource deletes a role that was projected from a cluster role
+func (r *Replicator) DeleteReplicatedResource(targetResource interface{}) error {
+	targetLocation := common.MustGetKey(targetResource)
+	logger := log.WithFields(log.Fields{
+		"kind":   r.Kind,
+		"target": targetLocation,
+	})
+
+	object := targetResource.(*rbacv1.Role)
+	logger.Debugf("Deleting %s", targetLocation)
+	if err := r.Client.RbacV1().Roles(object.Namespace).Delete(context.TODO(), object.Name, metav1.DeleteOptions{}); err != nil {
+		return errors.Wrapf(err, "Failed deleting %s: %v", targetLocation, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"context"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AcceptedSources returns the keys of the sources listed in the AcceptFrom annotation of a namespace
+func AcceptedSources(namespace *v1.Namespace) []string {
+	value, ok := namespace.Annotations[AcceptFrom]
+	if !ok {
+		return nil
+	}
+
+	var sources []string
+	for _, source := range strings.Split(value, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// acceptsSource checks if the AcceptFrom annotation of a namespace lists the source with the given key
+func acceptsSource(namespace *v1.Namespace, sourceKey string) bool {
+	for _, source := range AcceptedSources(namespace) {
+		if source == sourceKey {
+			return true
+		}
+	}
+	return false
+}
+
+// isAcceptancePermitted checks if the source allows replication into a namespace that accepts it. Like pull
+// replication, accepting a source requires the source's ReplicationAllowed annotations to permit the namespace.
+func (r *GenericReplicator) isAcceptancePermitted(ctx context.Context, obj interface{}, namespace *v1.Namespace) bool {
+	source := MustGetObject(obj)
+	target := &metav1.ObjectMeta{Namespace: namespace.Name, Name: r.ResolveTargetName(source, namespace.Name)}
+
+	if ok, err := r.IsReplicationPermitted(target, &metav1.ObjectMeta{
+		Name:        source.GetName(),
+		Namespace:   source.GetNamespace(),
+		Annotations: source.GetAnnotations(),
+	}); !ok {
+		r.logger(ctx).WithField("source", MustGetKey(obj)).WithField("target", namespace.Name).WithError(err).
+			Warn("namespace accepts source that does not allow replication into it")
+		return false
+	}
+	return true
+}
+
+// replicateToAcceptingNamespaces replicates a source into all namespaces that accept it with their AcceptFrom
+// annotation
+func (r *GenericReplicator) replicateToAcceptingNamespaces(ctx context.Context, obj interface{}) {
+	sourceKey := MustGetKey(obj)
+	logger := r.logger(ctx).WithField("source", sourceKey)
+
+	var accepting []v1.Namespace
+	for _, ns := range namespaceWatcher.NamespaceStore.List() {
+		if namespace := ns.(*v1.Namespace); acceptsSource(namespace, sourceKey) && r.isAcceptancePermitted(ctx, obj, namespace) {
+			accepting = append(accepting, *namespace)
+		}
+	}
+
+	if len(accepting) == 0 {
+		return
+	}
+
+	if replicated, err := r.replicateResourceToNamespaces(ctx, obj, accepting); err != nil {
+		logger.WithError(err).Errorf("replicated %s to %d out of %d accepting namespaces", sourceKey, len(replicated), len(accepting))
+	}
+}
+
+// replicateAcceptedSources replicates all sources of this replicator's kind that are listed in the AcceptFrom
+// annotation of the namespace into it
+func (r *GenericReplicator) replicateAcceptedSources(ctx context.Context, ns *v1.Namespace) {
+	logger := r.logger(ctx).WithField("target", ns.Name)
+
+	for _, sourceKey := range AcceptedSources(ns) {
+		obj, exists, err := r.Store.GetByKey(sourceKey)
+		if err != nil {
+			logger.WithError(err).Error("error fetching object from store")
+			continue
+		} else if !exists || !r.isAcceptancePermitted(ctx, obj, ns) {
+			continue
+		}
+
+		if _, err := r.replicateResourceToNamespaces(ctx, obj, []v1.Namespace{*ns}); err != nil {
+			logger.WithField("resource", sourceKey).WithError(err).Error("error while replicating accepted object to namespace")
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestReplicateAcceptedSourcesRequiresPermission(t *testing.T) {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "my-app",
+		Annotations: map[string]string{AcceptFrom: "kube-system/token, infra/tls"},
+	}}
+	namespaceWatcher.NamespaceStore = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, namespaceWatcher.NamespaceStore.Add(namespace))
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "kube-system"}}))
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "infra", Annotations: map[string]string{
+		ReplicationAllowed:           "true",
+		ReplicationAllowedNamespaces: "my-.*",
+	}}}))
+
+	var replicated []string
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Secret", Client: fake.NewSimpleClientset()},
+		Store:            store,
+		TargetStore:      store,
+		UpdateFuncs: UpdateFuncs{
+			ReplicateObjectTo: func(source interface{}, target *v1.Namespace) error {
+				replicated = append(replicated, MustGetKey(source)+"->"+target.Name)
+				return nil
+			},
+		},
+	}
+
+	r.replicateAcceptedSources(context.Background(), namespace)
+	assert.Equal(t, []string{"infra/tls->my-app"}, replicated)
+
+	replicated = nil
+	for _, key := range []string{"kube-system/token", "infra/tls"} {
+		obj, _, _ := store.GetByKey(key)
+		r.replicateToAcceptingNamespaces(context.Background(), obj)
+	}
+	assert.Equal(t, []string{"infra/tls->my-app"}, replicated)
+}
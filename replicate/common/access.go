@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// reviewPullAccess checks if the service account configured by PullAccessReviewServiceAccount in the namespace
+// of the target may get the source. This keeps tenants from pulling sources they could not read via RBAC anyway.
+// Replication is permitted if no service account is configured.
+func (r *GenericReplicator) reviewPullAccess(object *metav1.ObjectMeta, sourceObject *metav1.ObjectMeta) (bool, error) {
+	if r.PullAccessReviewServiceAccount == "" {
+		return true, nil
+	}
+
+	resource, err := r.sourceResource()
+	if err != nil {
+		return false, errors.Wrapf(err, "could not review access to source %s/%s", sourceObject.Namespace, sourceObject.Name)
+	}
+
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", object.Namespace, r.PullAccessReviewServiceAccount)
+	review := authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user,
+			Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + object.Namespace},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: sourceObject.Namespace,
+				Verb:      "get",
+				Group:     resource.Group,
+				Version:   resource.Version,
+				Resource:  resource.Resource,
+				Name:      sourceObject.Name,
+			},
+		},
+	}
+
+	result, err := r.Client.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), &review, metav1.CreateOptions{})
+	if err != nil {
+		return false, errors.Wrapf(err, "could not review access of %s to source %s/%s", user, sourceObject.Namespace, sourceObject.Name)
+	}
+
+	if !result.Status.Allowed {
+		return false, deniedf(DeniedReasonAccessReview, "%s may not get source %s/%s. %s will not be replicated",
+			user, sourceObject.Namespace, sourceObject.Name, object.Name)
+	}
+
+	log.Tracef("%s may get source %s/%s -- allowing replication", user, sourceObject.Namespace, sourceObject.Name)
+	return true, nil
+}
+
+// sourceResource returns the resource of the kind of sources handled by the replicator
+func (r *GenericReplicator) sourceResource() (schema.GroupVersionResource, error) {
+	gvk := r.ObjType.GetObjectKind().GroupVersionKind()
+	if gvk.Empty() {
+		kinds, _, err := scheme.Scheme.ObjectKinds(r.ObjType)
+		if err != nil {
+			return schema.GroupVersionResource{}, errors.Wrapf(err, "could not determine kind of %s", r.Kind)
+		}
+		gvk = kinds[0]
+	}
+
+	return r.namespacedResource(gvk.GroupVersion().String(), gvk.Kind)
+}
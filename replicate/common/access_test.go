@@ -0,0 +1,66 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestReviewPullAccess(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "secrets", Kind: "Secret", Namespaced: true}},
+		},
+	}
+
+	var reviewed []authorizationv1.SubjectAccessReviewSpec
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		reviewed = append(reviewed, review.Spec)
+		review.Status.Allowed = review.Spec.User == "system:serviceaccount:allowed:default"
+		return true, review, nil
+	})
+
+	repl := GenericReplicator{ReplicatorConfig: ReplicatorConfig{
+		Kind:     "Secret",
+		Client:   client,
+		AllowAll: true,
+		ObjType:  &v1.Secret{},
+	}}
+
+	source := &metav1.ObjectMeta{Namespace: "source", Name: "credentials"}
+
+	ok, err := repl.IsReplicationPermitted(&metav1.ObjectMeta{Namespace: "denied", Name: "credentials"}, source)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Empty(t, reviewed)
+
+	repl.PullAccessReviewServiceAccount = "default"
+
+	ok, err = repl.IsReplicationPermitted(&metav1.ObjectMeta{Namespace: "allowed", Name: "credentials"}, source)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	ok, err = repl.IsReplicationPermitted(&metav1.ObjectMeta{Namespace: "denied", Name: "credentials"}, source)
+	assert.False(t, ok)
+	assert.Error(t, err)
+
+	assert.Len(t, reviewed, 2)
+	assert.Equal(t, &authorizationv1.ResourceAttributes{
+		Namespace: "source",
+		Verb:      "get",
+		Version:   "v1",
+		Resource:  "secrets",
+		Name:      "credentials",
+	}, reviewed[1].ResourceAttributes)
+	assert.Equal(t, []string{"system:serviceaccounts", "system:serviceaccounts:denied"}, reviewed[1].Groups)
+}
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// matchesAnnotationFilter checks if an annotation key matches one of the given filters. Filters that
+// end with a "/" are treated as prefixes, all other filters need to match the key exactly.
+func matchesAnnotationFilter(key string, filters []string) bool {
+	for _, filter := range filters {
+		if strings.HasSuffix(filter, "/") && strings.HasPrefix(key, filter) {
+			return true
+		}
+		if key == filter {
+			return true
+		}
+	}
+	return false
+}
+
+// keptAnnotations returns the annotation filters listed in the keep-annotations annotation of the source
+func keptAnnotations(source map[string]string) []string {
+	var filters []string
+	for _, filter := range strings.Split(source[KeepAnnotations], ",") {
+		if filter = strings.TrimSpace(filter); filter != "" {
+			filters = append(filters, filter)
+		}
+	}
+	return filters
+}
+
+// isPropagatedAnnotation checks if an annotation key is covered by the given filters. The replicator's own
+// annotations are never propagated, since they describe the source and not its replicas.
+func isPropagatedAnnotation(key string, filters []string) bool {
+	return !strings.HasPrefix(key, AnnotationPrefix) && matchesAnnotationFilter(key, filters)
+}
+
+// ParseAddedAnnotations parses the annotations of the ReplicateAddAnnotations annotation, which are given as a
+// JSON object, so that their values may contain any character
+func ParseAddedAnnotations(value string) (map[string]string, error) {
+	var added map[string]string
+	if err := json.Unmarshal([]byte(value), &added); err != nil {
+		return nil, errors.Wrapf(err, "invalid annotations %q: expected a JSON object of strings", value)
+	}
+
+	for key := range added {
+		if strings.HasPrefix(key, AnnotationPrefix) {
+			return nil, errors.Errorf("annotation %q is reserved for the replicator", key)
+		}
+		for _, msg := range validation.IsQualifiedName(key) {
+			return nil, errors.Errorf("invalid annotation key %q: %s", key, msg)
+		}
+	}
+
+	return added, nil
+}
+
+// addedAnnotations returns the annotations that the source adds to its replicas. Invalid values are reported
+// by ValidateAnnotations and treated as if no annotations were added.
+func addedAnnotations(source map[string]string) map[string]string {
+	value, ok := source[ReplicateAddAnnotations]
+	if !ok {
+		return nil
+	}
+
+	added, err := ParseAddedAnnotations(value)
+	if err != nil {
+		return nil
+	}
+	return added
+}
+
+// PropagateAnnotations copies all annotations of the source that are covered by the configured
+// PropagateAnnotations filters or by the source's keep-annotations annotation onto the target, and sets the
+// annotations listed in the source's replicate-add-annotations annotation. Covered or added annotations that
+// are no longer present on the source are removed from the target. The value of the source's replicate-trigger
+// annotation is recorded on the target. Returns true if the target annotations were changed.
+func (r *GenericReplicator) PropagateAnnotations(source map[string]string, target map[string]string) bool {
+	filters := append(keptAnnotations(source), r.PropagatedAnnotations...)
+	added := addedAnnotations(source)
+	changed := false
+
+	for key := range target {
+		if _, ok := source[key]; !ok && isPropagatedAnnotation(key, filters) {
+			delete(target, key)
+			changed = true
+		}
+	}
+
+	for _, key := range strings.Split(target[AddedAnnotationsAnnotation], ",") {
+		if _, ok := added[key]; !ok && key != "" && !isPropagatedAnnotation(key, filters) {
+			delete(target, key)
+			changed = true
+		}
+	}
+
+	for key, value := range source {
+		if _, ok := added[key]; ok || !isPropagatedAnnotation(key, filters) {
+			continue
+		}
+		if oldValue, ok := target[key]; !ok || oldValue != value {
+			target[key] = value
+			changed = true
+		}
+	}
+
+	for key, value := range added {
+		if oldValue, ok := target[key]; !ok || oldValue != value {
+			target[key] = value
+			changed = true
+		}
+	}
+
+	if trigger, ok := source[ReplicateTrigger]; ok && target[ReplicatedTriggerAnnotation] != trigger {
+		target[ReplicatedTriggerAnnotation] = trigger
+		changed = true
+	} else if _, replicated := target[ReplicatedTriggerAnnotation]; !ok && replicated {
+		delete(target, ReplicatedTriggerAnnotation)
+		changed = true
+	}
+
+	addedKeys := strings.Join(GetKeysFromStringMap(added), ",")
+	if oldKeys, ok := target[AddedAnnotationsAnnotation]; ok && addedKeys == "" {
+		delete(target, AddedAnnotationsAnnotation)
+		changed = true
+	} else if addedKeys != "" && oldKeys != addedKeys {
+		target[AddedAnnotationsAnnotation] = addedKeys
+		changed = true
+	}
+
+	return changed
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPropagateAnnotations(t *testing.T) {
+	repl := GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{
+			PropagatedAnnotations: []string{"reloader.stakater.com/", "wave.pusher.com/update-on-config-change"},
+		},
+	}
+
+	source := map[string]string{
+		"reloader.stakater.com/match":             "true",
+		"wave.pusher.com/update-on-config-change": "true",
+		"wave.pusher.com/other":                   "true",
+		"unrelated":                               "foo",
+	}
+	target := map[string]string{
+		"reloader.stakater.com/search": "true",
+		"own-annotation":               "bar",
+	}
+
+	assert.True(t, repl.PropagateAnnotations(source, target))
+	assert.Equal(t, map[string]string{
+		"reloader.stakater.com/match":             "true",
+		"wave.pusher.com/update-on-config-change": "true",
+		"own-annotation":                          "bar",
+	}, target)
+
+	assert.False(t, repl.PropagateAnnotations(source, target))
+}
+
+func TestPropagateAnnotationsDisabled(t *testing.T) {
+	repl := GenericReplicator{}
+	target := map[string]string{}
+
+	assert.False(t, repl.PropagateAnnotations(map[string]string{"reloader.stakater.com/match": "true"}, target))
+	assert.Empty(t, target)
+}
+
+func TestPropagateAnnotationsKeptBySource(t *testing.T) {
+	repl := GenericReplicator{}
+
+	source := map[string]string{
+		KeepAnnotations:        "prometheus.io/,mycorp.com/team,replicator.v1.mittwald.de/",
+		"prometheus.io/scrape": "true",
+		"mycorp.com/team":      "platform",
+		"mycorp.com/cost":      "42",
+		ReplicateTo:            "team-.*",
+		StripLabels:            "true",
+	}
+	target := map[string]string{
+		"prometheus.io/port":   "9090",
+		ReplicatedAtAnnotation: "2024-01-01T00:00:00Z",
+	}
+
+	assert.True(t, repl.PropagateAnnotations(source, target))
+	assert.Equal(t, map[string]string{
+		"prometheus.io/scrape": "true",
+		"mycorp.com/team":      "platform",
+		ReplicatedAtAnnotation: "2024-01-01T00:00:00Z",
+	}, target)
+}
+
+func TestPropagateAnnotationsAdded(t *testing.T) {
+	repl := GenericReplicator{}
+
+	source := map[string]string{
+		ReplicateAddAnnotations: `{"argocd.argoproj.io/compare-options": "IgnoreExtraneous,ServerSideDiff=true", "vault.hashicorp.com/agent-inject": "true"}`,
+	}
+	target := map[string]string{
+		"vault.hashicorp.com/agent-inject": "false",
+		"own-annotation":                   "bar",
+	}
+
+	assert.True(t, repl.PropagateAnnotations(source, target))
+	assert.Equal(t, map[string]string{
+		"argocd.argoproj.io/compare-options": "IgnoreExtraneous,ServerSideDiff=true",
+		"vault.hashicorp.com/agent-inject":   "true",
+		"own-annotation":                     "bar",
+		AddedAnnotationsAnnotation:           "argocd.argoproj.io/compare-options,vault.hashicorp.com/agent-inject",
+	}, target)
+	assert.False(t, repl.PropagateAnnotations(source, target))
+
+	source[ReplicateAddAnnotations] = `{"vault.hashicorp.com/agent-inject": "true"}`
+	assert.True(t, repl.PropagateAnnotations(source, target))
+	assert.Equal(t, map[string]string{
+		"vault.hashicorp.com/agent-inject": "true",
+		"own-annotation":                   "bar",
+		AddedAnnotationsAnnotation:         "vault.hashicorp.com/agent-inject",
+	}, target)
+
+	delete(source, ReplicateAddAnnotations)
+	assert.True(t, repl.PropagateAnnotations(source, target))
+	assert.Equal(t, map[string]string{"own-annotation": "bar"}, target)
+}
+
+func TestParseAddedAnnotations(t *testing.T) {
+	_, err := ParseAddedAnnotations(`{"replicator.v1.mittwald.de/replicate-to": "foo"}`)
+	assert.Error(t, err)
+
+	_, err = ParseAddedAnnotations(`a=b`)
+	assert.Error(t, err)
+
+	_, err = ParseAddedAnnotations(`{"invalid key": "foo"}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ContentVersion returns a checksum of the replicated content of an object, i.e. everything except its metadata
+// and status. Unlike the resource version, it does not change when only annotations are changed, which allows
+// approving a version with the ApprovedVersion annotation.
+func ContentVersion(obj interface{}) (string, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", errors.Wrap(err, "could not convert object")
+	}
+
+	// the content of unstructured objects is not copied, so it must not be modified
+	replicated := make(map[string]interface{}, len(content))
+	for key, value := range content {
+		switch key {
+		case "apiVersion", "kind", "metadata", "status":
+		default:
+			replicated[key] = value
+		}
+	}
+
+	data, err := json.Marshal(replicated)
+	if err != nil {
+		return "", errors.Wrap(err, "could not serialize object")
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
+
+// isApproved checks if the current content of the source may be replicated. Sources with the RequiresApproval
+// annotation are only replicated once their ApprovedVersion annotation matches their content version.
+func (r *GenericReplicator) isApproved(source interface{}) bool {
+	annotations := MustGetObject(source).GetAnnotations()
+	if annotations[RequiresApproval] != "true" {
+		return true
+	}
+
+	logger := log.WithField("kind", r.Kind).WithField("source", MustGetKey(source))
+
+	version, err := ContentVersion(source)
+	if err != nil {
+		logger.WithError(err).Error("could not determine content version")
+		return false
+	}
+
+	if annotations[ApprovedVersion] == version {
+		return true
+	}
+
+	logger.Infof("replication is held until version %s is approved with the %s annotation", version, ApprovedVersion)
+	r.recordEvent(source, v1.EventTypeNormal, "ApprovalRequired",
+		"Replication is held until version %s is approved with the %s annotation", version, ApprovedVersion)
+	return false
+}
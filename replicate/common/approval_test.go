@@ -0,0 +1,32 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApproval(t *testing.T) {
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}}
+
+	source := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	assert.True(t, r.isApproved(source))
+
+	source.Annotations = map[string]string{RequiresApproval: "true"}
+	assert.False(t, r.isApproved(source))
+
+	version, err := ContentVersion(source)
+	assert.NoError(t, err)
+
+	source.Annotations[ApprovedVersion] = version
+	source.ResourceVersion = "2"
+	assert.True(t, r.isApproved(source))
+
+	source.Data["password"] = []byte("rotated")
+	assert.False(t, r.isApproved(source))
+}
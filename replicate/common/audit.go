@@ -0,0 +1,100 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Operations on targets that are recorded in the audit log
+const (
+	AuditOperationCreate = "create"
+	AuditOperationUpdate = "update"
+	AuditOperationDelete = "delete"
+)
+
+// Reasons for operations on targets that are recorded in the audit log
+const (
+	AuditReasonPush          = "push"
+	AuditReasonPull          = "pull"
+	AuditReasonMerge         = "merge"
+	AuditReasonSourceDeleted = "source-deleted"
+)
+
+// AuditRecord describes a single operation of the replicator on a target
+type AuditRecord struct {
+	Time          time.Time `json:"time"`
+	Controller    string    `json:"controller"`
+	Operation     string    `json:"operation"`
+	Kind          string    `json:"kind"`
+	Target        string    `json:"target"`
+	Source        string    `json:"source"`
+	SourceVersion string    `json:"sourceVersion,omitempty"`
+	Reason        string    `json:"reason"`
+}
+
+// AuditLog writes one JSON document per line for each operation on a target. A nil AuditLog discards all records.
+type AuditLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewAuditLog creates an audit log that writes its records to the given writer
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{encoder: json.NewEncoder(w)}
+}
+
+// NewFileAuditLog creates an audit log that appends its records to the file at the given path
+func NewFileAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open audit log %s", path)
+	}
+
+	return NewAuditLog(file), nil
+}
+
+// Record writes the given record to the audit log
+func (a *AuditLog) Record(record AuditRecord) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.encoder.Encode(record); err != nil {
+		log.WithError(err).Errorf("could not write audit record for %s %s", record.Kind, record.Target)
+	}
+}
+
+// Audit records an operation on the target at the given location in the audit log, if one is configured. source is
+// the source object, or the key of the sources (e.g. of merged targets).
+func (r *GenericReplicator) Audit(operation string, source interface{}, targetLocation string, reason string) {
+	if r.AuditLog == nil {
+		return
+	}
+
+	record := AuditRecord{
+		Time:       time.Now().UTC(),
+		Controller: r.ControllerIdentity,
+		Operation:  operation,
+		Kind:       r.Kind,
+		Target:     targetLocation,
+		Reason:     reason,
+	}
+
+	if sourceKey, ok := source.(string); ok {
+		record.Source = sourceKey
+	} else {
+		record.Source = MustGetKey(source)
+		record.SourceVersion = MustGetObject(source).GetResourceVersion()
+	}
+
+	r.AuditLog.Record(record)
+}
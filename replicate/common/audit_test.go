@@ -0,0 +1,104 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestAudit(t *testing.T) {
+	var buf bytes.Buffer
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{
+		Kind:               "Secret",
+		ControllerIdentity: "replicator-a",
+		AuditLog:           NewAuditLog(&buf),
+	}}
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "source", ResourceVersion: "42"}}
+
+	r.Audit(AuditOperationCreate, source, "target/shared", AuditReasonPush)
+	r.Audit(AuditOperationUpdate, "source/a,source/b", "target/merged", AuditReasonMerge)
+
+	decoder := json.NewDecoder(&buf)
+
+	var record AuditRecord
+	require.NoError(t, decoder.Decode(&record))
+	assert.False(t, record.Time.IsZero())
+	assert.Equal(t, AuditRecord{
+		Time:          record.Time,
+		Controller:    "replicator-a",
+		Operation:     AuditOperationCreate,
+		Kind:          "Secret",
+		Target:        "target/shared",
+		Source:        "source/shared",
+		SourceVersion: "42",
+		Reason:        AuditReasonPush,
+	}, record)
+
+	record = AuditRecord{}
+	require.NoError(t, decoder.Decode(&record))
+	assert.Equal(t, "source/a,source/b", record.Source)
+	assert.Empty(t, record.SourceVersion)
+	assert.Equal(t, AuditReasonMerge, record.Reason)
+
+	assert.False(t, decoder.More())
+}
+
+func TestAuditWithoutLog(t *testing.T) {
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}}
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "source"}}
+
+	assert.NotPanics(t, func() {
+		r.Audit(AuditOperationDelete, source, "target/shared", AuditReasonSourceDeleted)
+	})
+}
+
+func TestAuditUpdatedDependents(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "source", ResourceVersion: "2"}}
+	outdated := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "outdated", Namespace: "target", Annotations: map[string]string{
+		ReplicateFromAnnotation:         "source/shared",
+		ReplicatedFromVersionAnnotation: "1",
+	}}}
+	current := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "current", Namespace: "target", Annotations: map[string]string{
+		ReplicateFromAnnotation:         "source/shared",
+		ReplicatedFromVersionAnnotation: "2",
+	}}}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	require.NoError(t, store.Add(source))
+	require.NoError(t, store.Add(outdated))
+	require.NoError(t, store.Add(current))
+
+	var buf bytes.Buffer
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Secret", AuditLog: NewAuditLog(&buf)},
+		Store:            store,
+		TargetStore:      store,
+		UpdateFuncs: UpdateFuncs{
+			ReplicateDataFrom: func(source interface{}, target interface{}) error {
+				return nil
+			},
+		},
+	}
+
+	replications := testutil.ToFloat64(metricReplications.WithLabelValues("Secret", "success"))
+	require.NoError(t, r.updateDependents(context.Background(), source, []string{"target/outdated", "target/current"}))
+	assert.Equal(t, replications+2, testutil.ToFloat64(metricReplications.WithLabelValues("Secret", "success")))
+
+	// dependents that were already replicated from the current version are not written, and not audited
+	decoder := json.NewDecoder(&buf)
+	var record AuditRecord
+	require.NoError(t, decoder.Decode(&record))
+	assert.Equal(t, AuditOperationUpdate, record.Operation)
+	assert.Equal(t, "target/outdated", record.Target)
+	assert.Equal(t, "source/shared", record.Source)
+	assert.Equal(t, AuditReasonPull, record.Reason)
+	assert.False(t, decoder.More())
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ComparesCertificates checks if replicas of the source are only updated when their certificate changes, as
+// requested by the CompareCertificates annotation. This only applies to secrets of type "kubernetes.io/tls".
+func ComparesCertificates(source metav1.Object, secretType v1.SecretType) bool {
+	return secretType == v1.SecretTypeTLS && source.GetAnnotations()[CompareCertificates] == "true"
+}
+
+// SameCertificateData checks if two versions of the data of a TLS secret contain the same certificate, i.e. a
+// leaf certificate with the same issuer, serial number and expiry, and are otherwise identical. The private key
+// is not compared, since it belongs to the certificate; it may only differ in its encoding.
+func SameCertificateData(old map[string][]byte, new map[string][]byte) bool {
+	if len(old) != len(new) {
+		return false
+	}
+
+	for key, value := range new {
+		oldValue, ok := old[key]
+		if !ok {
+			return false
+		}
+		if key != v1.TLSCertKey && key != v1.TLSPrivateKeyKey && !bytes.Equal(oldValue, value) {
+			return false
+		}
+	}
+
+	oldCert, err := parseLeafCertificate(old[v1.TLSCertKey])
+	if err != nil {
+		return false
+	}
+	newCert, err := parseLeafCertificate(new[v1.TLSCertKey])
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(oldCert.RawIssuer, newCert.RawIssuer) &&
+		oldCert.SerialNumber.Cmp(newCert.SerialNumber) == 0 &&
+		oldCert.NotAfter.Equal(newCert.NotAfter)
+}
+
+// parseLeafCertificate parses the first certificate of a PEM encoded certificate chain
+func parseLeafCertificate(data []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testCertificate(t *testing.T, serial int64, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestSameCertificateData(t *testing.T) {
+	notAfter := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+	cert := testCertificate(t, 1, notAfter)
+
+	old := map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: []byte("key"), "ca.crt": []byte("ca")}
+
+	// the same certificate with another chain and a re-encoded key
+	chained := append(append([]byte{}, cert...), testCertificate(t, 2, notAfter)...)
+	assert.True(t, SameCertificateData(old, map[string][]byte{v1.TLSCertKey: chained, v1.TLSPrivateKeyKey: []byte("other"), "ca.crt": []byte("ca")}))
+
+	assert.False(t, SameCertificateData(old, map[string][]byte{v1.TLSCertKey: testCertificate(t, 3, notAfter), v1.TLSPrivateKeyKey: []byte("key"), "ca.crt": []byte("ca")}))
+	assert.False(t, SameCertificateData(old, map[string][]byte{v1.TLSCertKey: testCertificate(t, 1, notAfter.Add(time.Hour)), v1.TLSPrivateKeyKey: []byte("key"), "ca.crt": []byte("ca")}))
+	assert.False(t, SameCertificateData(old, map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: []byte("key"), "ca.crt": []byte("other")}))
+	assert.False(t, SameCertificateData(old, map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: []byte("key")}))
+	assert.False(t, SameCertificateData(old, map[string][]byte{v1.TLSCertKey: []byte("garbage"), v1.TLSPrivateKeyKey: []byte("key"), "ca.crt": []byte("ca")}))
+}
+
+func TestComparesCertificates(t *testing.T) {
+	source := &metav1.ObjectMeta{Annotations: map[string]string{CompareCertificates: "true"}}
+	assert.True(t, ComparesCertificates(source, v1.SecretTypeTLS))
+	assert.False(t, ComparesCertificates(source, v1.SecretTypeOpaque))
+	assert.False(t, ComparesCertificates(&metav1.ObjectMeta{}, v1.SecretTypeTLS))
+}
@@ -0,0 +1,224 @@
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// checkpointKey is the key of the checkpoint in the data of a checkpoint config map
+const checkpointKey = "checkpoint.json.gz"
+
+// SourceCheckpoint records the namespaces into which a version of a source has been replicated
+type SourceCheckpoint struct {
+	Version    string   `json:"version"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// KindCheckpoint maps the keys of the sources of a replicator to their checkpoints
+type KindCheckpoint map[string]SourceCheckpoint
+
+// Checkpoint is a snapshot of the replicas created in push mode, by kind. It allows a restarted replicator to skip
+// replicas that are up-to-date before its informers have synced.
+type Checkpoint map[string]KindCheckpoint
+
+// Checkpointer is implemented by replicators that can take a snapshot of their replicas
+type Checkpointer interface {
+	Snapshot() (string, KindCheckpoint)
+}
+
+// CheckpointStore persists checkpoints
+type CheckpointStore interface {
+	Load() (Checkpoint, error)
+	Save(checkpoint Checkpoint) error
+}
+
+// covers checks if the given version of a source has been replicated into the namespace according to the checkpoint
+func (c KindCheckpoint) covers(sourceKey string, version string, namespace string) bool {
+	checkpoint, ok := c[sourceKey]
+	return ok && version != "" && checkpoint.Version == version && slices.Contains(checkpoint.Namespaces, namespace)
+}
+
+// Snapshot takes a snapshot of the cached replicas that are up-to-date with their source. It returns the kind
+// of the replicator along with the snapshot.
+func (r *GenericReplicator) Snapshot() (string, KindCheckpoint) {
+	checkpoint := make(KindCheckpoint)
+	for _, obj := range r.Store.List() {
+		source := MustGetObject(obj)
+		sourceKey := MustGetKey(source)
+
+		var namespaces []string
+		for _, replica := range r.replicasOf(sourceKey) {
+			if replica := MustGetObject(replica); replica.GetAnnotations()[ReplicatedFromVersionAnnotation] == source.GetResourceVersion() {
+				namespaces = append(namespaces, replica.GetNamespace())
+			}
+		}
+
+		if len(namespaces) > 0 {
+			slices.Sort(namespaces)
+			checkpoint[sourceKey] = SourceCheckpoint{Version: source.GetResourceVersion(), Namespaces: namespaces}
+		}
+	}
+	return r.Kind, checkpoint
+}
+
+// RunCheckpoints saves a checkpoint of the given replicators periodically, once all of them have synced
+func RunCheckpoints(store CheckpointStore, replicators []Replicator, interval time.Duration) {
+	for range time.Tick(interval) {
+		checkpoint := make(Checkpoint)
+		synced := true
+		for _, replicator := range replicators {
+			checkpointer, ok := replicator.(Checkpointer)
+			if !ok {
+				continue
+			}
+			if !replicator.Synced() {
+				synced = false
+				break
+			}
+			kind, kindCheckpoint := checkpointer.Snapshot()
+			checkpoint[kind] = kindCheckpoint
+		}
+
+		if !synced {
+			log.Debug("not saving checkpoint: replicators have not synced yet")
+			continue
+		}
+		if err := store.Save(checkpoint); err != nil {
+			log.WithError(err).Warn("could not save checkpoint")
+		}
+	}
+}
+
+// encodeCheckpoint encodes a checkpoint as gzip-compressed JSON
+func encodeCheckpoint(checkpoint Checkpoint) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(checkpoint); err != nil {
+		return nil, errors.Wrap(err, "could not encode checkpoint")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not compress checkpoint")
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeCheckpoint decodes a checkpoint encoded by encodeCheckpoint
+func decodeCheckpoint(data []byte) (Checkpoint, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decompress checkpoint")
+	}
+	defer reader.Close()
+
+	var checkpoint Checkpoint
+	if err := json.NewDecoder(reader).Decode(&checkpoint); err != nil {
+		return nil, errors.Wrap(err, "could not decode checkpoint")
+	}
+	return checkpoint, nil
+}
+
+// FileCheckpointStore persists checkpoints in a local file
+type FileCheckpointStore struct {
+	Path string
+}
+
+// Load reads the checkpoint from the file. It returns an empty checkpoint if the file does not exist.
+func (s *FileCheckpointStore) Load() (Checkpoint, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return Checkpoint{}, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "could not read checkpoint from %s", s.Path)
+	}
+	return decodeCheckpoint(data)
+}
+
+// Save writes the checkpoint to a temporary file, which then replaces the file
+func (s *FileCheckpointStore) Save(checkpoint Checkpoint) error {
+	data, err := encodeCheckpoint(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "could not write checkpoint to %s", s.Path)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "could not write checkpoint to %s", s.Path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "could not write checkpoint to %s", s.Path)
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), s.Path), "could not write checkpoint to %s", s.Path)
+}
+
+// ConfigMapCheckpointStore persists checkpoints in a config map
+type ConfigMapCheckpointStore struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+// NewConfigMapCheckpointStore creates a store for the config map at the given location ("<namespace>/<name>")
+func NewConfigMapCheckpointStore(client kubernetes.Interface, location string) (*ConfigMapCheckpointStore, error) {
+	namespace, name, ok := strings.Cut(location, "/")
+	if !ok || namespace == "" || name == "" {
+		return nil, errors.Errorf("invalid checkpoint config map %q; expected '<namespace>/<name>'", location)
+	}
+	return &ConfigMapCheckpointStore{Client: client, Namespace: namespace, Name: name}, nil
+}
+
+// Load reads the checkpoint from the config map. It returns an empty checkpoint if the config map does not exist.
+func (s *ConfigMapCheckpointStore) Load() (Checkpoint, error) {
+	configMap, err := s.Client.CoreV1().ConfigMaps(s.Namespace).Get(context.TODO(), s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return Checkpoint{}, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "could not get checkpoint config map %s/%s", s.Namespace, s.Name)
+	}
+
+	data, ok := configMap.BinaryData[checkpointKey]
+	if !ok {
+		return Checkpoint{}, nil
+	}
+	return decodeCheckpoint(data)
+}
+
+// Save writes the checkpoint to the config map, creating it if necessary
+func (s *ConfigMapCheckpointStore) Save(checkpoint Checkpoint) error {
+	data, err := encodeCheckpoint(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	configMaps := s.Client.CoreV1().ConfigMaps(s.Namespace)
+	configMap, err := configMaps.Get(context.TODO(), s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(context.TODO(), &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.Name, Namespace: s.Namespace},
+			BinaryData: map[string][]byte{checkpointKey: data},
+		}, metav1.CreateOptions{})
+	} else if err == nil {
+		configMap.BinaryData = map[string][]byte{checkpointKey: data}
+		_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	}
+	return errors.Wrapf(err, "could not save checkpoint to config map %s/%s", s.Namespace, s.Name)
+}
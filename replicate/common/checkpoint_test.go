@@ -0,0 +1,95 @@
+package common
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// unsyncedController stands in for the controller of a replicator whose informers have not synced yet
+type unsyncedController struct {
+	cache.Controller
+}
+
+func (unsyncedController) HasSynced() bool {
+	return false
+}
+
+func TestCheckpointStores(t *testing.T) {
+	checkpoint := Checkpoint{"Secret": {"default/source": {Version: "2", Namespaces: []string{"a", "b"}}}}
+
+	configMapStore, err := NewConfigMapCheckpointStore(fake.NewSimpleClientset(), "kube-system/replicator-checkpoint")
+	require.NoError(t, err)
+
+	_, err = NewConfigMapCheckpointStore(nil, "replicator-checkpoint")
+	assert.Error(t, err)
+
+	for name, store := range map[string]CheckpointStore{
+		"file":      &FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint")},
+		"configmap": configMapStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			loaded, err := store.Load()
+			require.NoError(t, err)
+			assert.Empty(t, loaded)
+
+			require.NoError(t, store.Save(checkpoint))
+			require.NoError(t, store.Save(checkpoint))
+
+			loaded, err = store.Load()
+			require.NoError(t, err)
+			assert.Equal(t, checkpoint, loaded)
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, Indexers)
+	require.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default", ResourceVersion: "2"}}))
+	for namespace, version := range map[string]string{"current": "2", "outdated": "1"} {
+		require.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      "source",
+			Namespace: namespace,
+			Annotations: map[string]string{
+				ReplicatedSourceAnnotation:      "default/source",
+				ReplicatedFromVersionAnnotation: version,
+			},
+		}}))
+	}
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: store}
+
+	kind, checkpoint := r.Snapshot()
+	assert.Equal(t, "Secret", kind)
+	assert.Equal(t, KindCheckpoint{"default/source": {Version: "2", Namespaces: []string{"current"}}}, checkpoint)
+}
+
+func TestIsUpToDateByCheckpoint(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default", ResourceVersion: "2"}}
+
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{
+			Kind:       "Secret",
+			Checkpoint: Checkpoint{"Secret": {"default/source": {Version: "2", Namespaces: []string{"a"}}}},
+		},
+		Controller:  unsyncedController{},
+		TargetStore: cache.NewStore(cache.MetaNamespaceKeyFunc),
+	}
+
+	assert.True(t, r.isUpToDate(source, "a"))
+	assert.False(t, r.isUpToDate(source, "b"))
+
+	changed := source.DeepCopy()
+	changed.ResourceVersion = "3"
+	assert.False(t, r.isUpToDate(changed, "a"))
+
+	// once the informers have synced, only the cache counts
+	r.Controller = nil
+	assert.False(t, r.isUpToDate(source, "a"))
+}
@@ -0,0 +1,117 @@
+package common
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	descCachedObjects = prometheus.NewDesc(metricsNamespace+"_cached_objects",
+		"Number of objects in the caches of a replicator", []string{"kind", "cache"}, nil)
+	descTrackedSources = prometheus.NewDesc(metricsNamespace+"_tracked_sources",
+		"Number of sources that are replicated in push mode, by the annotation selecting their target namespaces",
+		[]string{"kind", "annotation"}, nil)
+	descDependencies = prometheus.NewDesc(metricsNamespace+"_dependencies",
+		"Number of sources that are pulled by at least one target", []string{"kind"}, nil)
+	descDependents = prometheus.NewDesc(metricsNamespace+"_dependents",
+		"Number of targets that pull from a source", []string{"kind"}, nil)
+	descPendingDebounces = prometheus.NewDesc(metricsNamespace+"_pending_debounces",
+		"Number of sources whose updates are held back until they settle", []string{"kind"}, nil)
+	descCachedNamespaces = prometheus.NewDesc(metricsNamespace+"_cached_namespaces",
+		"Number of namespaces in the namespace cache shared by all replicators", nil, nil)
+	descCachedConfigMaps = prometheus.NewDesc(metricsNamespace+"_cached_referenced_configmaps",
+		"Number of config maps in the cache of config maps referenced by sources", nil, nil)
+	descOrphanedReplicas = prometheus.NewDesc(metricsNamespace+"_orphaned_replicas",
+		"Number of replicas whose source does not exist, by the mode in which they were replicated", []string{"kind", "mode"}, nil)
+	descStaleTargets = prometheus.NewDesc(metricsNamespace+"_stale_targets",
+		"Number of targets of a source that have lagged behind its version for longer than the staleness threshold",
+		[]string{"kind", "source"}, nil)
+	descSynced = prometheus.NewDesc(metricsNamespace+"_synced",
+		"Whether the informers of a replicator have synced their caches (1) or not (0)", []string{"kind"}, nil)
+	descReplicas = prometheus.NewDesc(metricsNamespace+"_replicas",
+		"Number of replicas of a source in a target namespace, including targets that pull or merge from the source",
+		[]string{"kind", "namespace", "source"}, nil)
+)
+
+// CacheCollector reports the sizes of the caches and bookkeeping of the replicators when metrics are scraped, so that
+// runaway growth can be alerted on
+type CacheCollector struct {
+	Replicators []Replicator
+
+	// Replicas enables the number of replicas per target namespace and source, which yields a time series for
+	// every namespace a source is replicated to
+	Replicas bool
+}
+
+// Describe implements prometheus.Collector
+func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- descCachedObjects
+	ch <- descTrackedSources
+	ch <- descDependencies
+	ch <- descDependents
+	ch <- descPendingDebounces
+	ch <- descCachedNamespaces
+	ch <- descCachedConfigMaps
+	ch <- descOrphanedReplicas
+	ch <- descStaleTargets
+	ch <- descSynced
+	if c.Replicas {
+		ch <- descReplicas
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *CacheCollector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, value int, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value), labels...)
+	}
+
+	for _, replicator := range c.Replicators {
+		if reporter, ok := replicator.(StatsReporter); ok {
+			stats := reporter.Stats()
+			gauge(descCachedObjects, stats.CachedObjects, stats.Kind, "sources")
+			gauge(descCachedObjects, stats.CachedTargets, stats.Kind, "targets")
+			gauge(descTrackedSources, stats.ReplicateTo, stats.Kind, ReplicateTo)
+			gauge(descTrackedSources, stats.ReplicateToMatching, stats.Kind, ReplicateToMatching)
+			gauge(descTrackedSources, stats.ReplicateToSameTenant, stats.Kind, ReplicateToSameTenant)
+			gauge(descTrackedSources, stats.ReplicateToFromConfigMap, stats.Kind, ReplicateToFromConfigMap)
+			gauge(descDependencies, stats.Dependencies, stats.Kind)
+			gauge(descDependents, stats.Dependents, stats.Kind)
+		}
+		if reporter, ok := replicator.(StatusReporter); ok {
+			status := reporter.Status()
+			gauge(descPendingDebounces, status.PendingDebounces, status.Kind)
+			if replicator.Synced() {
+				gauge(descSynced, 1, status.Kind)
+			} else {
+				gauge(descSynced, 0, status.Kind)
+			}
+		}
+		if counter, ok := replicator.(OrphanCounter); ok {
+			if kind, counts, ok := counter.OrphanCounts(); ok {
+				for mode, count := range counts {
+					gauge(descOrphanedReplicas, count, kind, mode)
+				}
+			}
+		}
+		if reporter, ok := replicator.(StalenessReporter); ok {
+			if kind, stale, ok := reporter.StaleTargets(); ok {
+				for source, count := range stale {
+					gauge(descStaleTargets, count, kind, source)
+				}
+			}
+		}
+		if counter, ok := replicator.(ReplicaCounter); ok && c.Replicas {
+			for _, count := range counter.ReplicaCounts() {
+				gauge(descReplicas, count.Count, count.Kind, count.Namespace, count.Source)
+			}
+		}
+	}
+
+	shared := SharedCacheStats()
+	if namespaces, ok := shared["namespaces"]; ok {
+		gauge(descCachedNamespaces, namespaces)
+	}
+	if configMaps, ok := shared["referencedConfigMaps"]; ok {
+		gauge(descCachedConfigMaps, configMaps)
+	}
+}
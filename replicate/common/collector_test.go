@@ -0,0 +1,100 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCacheCollector(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"}}))
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "target",
+		Namespace:   "team-a",
+		Annotations: map[string]string{ReplicateFromAnnotation: "default/source"},
+	}}))
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: cache.NewStore(cache.MetaNamespaceKeyFunc)}
+	r.ReplicateToList.Store("default/source", struct{}{})
+
+	collector := &CacheCollector{Replicators: []Replicator{r}}
+
+	expected := `
+# HELP kubernetes_replicator_cached_objects Number of objects in the caches of a replicator
+# TYPE kubernetes_replicator_cached_objects gauge
+kubernetes_replicator_cached_objects{cache="sources",kind="Secret"} 2
+kubernetes_replicator_cached_objects{cache="targets",kind="Secret"} 0
+# HELP kubernetes_replicator_dependencies Number of sources that are pulled by at least one target
+# TYPE kubernetes_replicator_dependencies gauge
+kubernetes_replicator_dependencies{kind="Secret"} 1
+# HELP kubernetes_replicator_dependents Number of targets that pull from a source
+# TYPE kubernetes_replicator_dependents gauge
+kubernetes_replicator_dependents{kind="Secret"} 1
+# HELP kubernetes_replicator_tracked_sources Number of sources that are replicated in push mode, by the annotation selecting their target namespaces
+# TYPE kubernetes_replicator_tracked_sources gauge
+kubernetes_replicator_tracked_sources{annotation="replicator.v1.mittwald.de/replicate-to",kind="Secret"} 1
+kubernetes_replicator_tracked_sources{annotation="replicator.v1.mittwald.de/replicate-to-from-configmap",kind="Secret"} 0
+kubernetes_replicator_tracked_sources{annotation="replicator.v1.mittwald.de/replicate-to-matching",kind="Secret"} 0
+kubernetes_replicator_tracked_sources{annotation="replicator.v1.mittwald.de/replicate-to-same-tenant",kind="Secret"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kubernetes_replicator_cached_objects", "kubernetes_replicator_dependencies", "kubernetes_replicator_dependents",
+		"kubernetes_replicator_tracked_sources"))
+}
+
+func TestCacheCollectorReplicas(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"}}))
+	for _, namespace := range []string{"team-a", "team-b"} {
+		assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "source",
+			Namespace:   namespace,
+			Annotations: map[string]string{ReplicatedSourceAnnotation: "default/source"},
+		}}))
+	}
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "pulled",
+		Namespace:   "team-a",
+		Annotations: map[string]string{ReplicateFromAnnotation: "default/source"},
+	}}))
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: store}
+
+	expected := `
+# HELP kubernetes_replicator_replicas Number of replicas of a source in a target namespace, including targets that pull or merge from the source
+# TYPE kubernetes_replicator_replicas gauge
+kubernetes_replicator_replicas{kind="Secret",namespace="team-a",source="default/source"} 2
+kubernetes_replicator_replicas{kind="Secret",namespace="team-b",source="default/source"} 1
+`
+	collector := &CacheCollector{Replicators: []Replicator{r}, Replicas: true}
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "kubernetes_replicator_replicas"))
+
+	collector = &CacheCollector{Replicators: []Replicator{r}}
+	assert.Zero(t, testutil.CollectAndCount(collector, "kubernetes_replicator_replicas"))
+}
+
+func TestCacheCollectorSynced(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	synced := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: store}
+	unsynced := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "ConfigMap"},
+		Store:            store,
+		TargetStore:      store,
+		Controller:       unsyncedController{},
+	}
+	collector := &CacheCollector{Replicators: []Replicator{synced, unsynced}}
+
+	expected := `
+# HELP kubernetes_replicator_synced Whether the informers of a replicator have synced their caches (1) or not (0)
+# TYPE kubernetes_replicator_synced gauge
+kubernetes_replicator_synced{kind="ConfigMap"} 0
+kubernetes_replicator_synced{kind="Secret"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "kubernetes_replicator_synced"))
+}
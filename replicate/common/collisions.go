@@ -0,0 +1,111 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Strategies for handling sources whose replicas would have the same name in a target namespace
+const (
+	// CollisionStrategyError keeps the existing replica and reports the collision as an error and event
+	CollisionStrategyError = "error"
+
+	// CollisionStrategyFirstWins keeps the existing replica and ignores the colliding source
+	CollisionStrategyFirstWins = "first-wins"
+
+	// CollisionStrategySuffixBySource replicates the colliding source under a name suffixed with its namespace
+	CollisionStrategySuffixBySource = "suffix-by-source"
+)
+
+// CollisionStrategies lists all valid collision strategies
+var CollisionStrategies = []string{CollisionStrategyError, CollisionStrategyFirstWins, CollisionStrategySuffixBySource}
+
+// GetReplicationPriority returns the priority of the source in case of collisions, which defaults to 0
+func GetReplicationPriority(source metav1.Object) int {
+	priority, err := strconv.Atoi(source.GetAnnotations()[ReplicationPriority])
+	if err != nil {
+		return 0
+	}
+	return priority
+}
+
+// collidingSource checks if the target at the given location is a replica of another source than the given one,
+// and returns the key of that source
+func (r *GenericReplicator) collidingSource(sourceKey string, targetLocation string) (string, bool) {
+	target, exists, err := r.cachedTarget(targetLocation)
+	if err != nil || !exists {
+		return "", false
+	}
+
+	otherSource, ok := MustGetObject(target).GetAnnotations()[ReplicatedSourceAnnotation]
+	if !ok || otherSource == sourceKey {
+		return "", false
+	}
+
+	return otherSource, true
+}
+
+// ResolveTargetName returns the name of the replica of the source in the given namespace. It differs from
+// TargetName only if the "suffix-by-source" collision strategy is used and the name is taken by another source.
+func (r *GenericReplicator) ResolveTargetName(source metav1.Object, namespace string) string {
+	name := TargetName(source, namespace)
+	if r.CollisionStrategy != CollisionStrategySuffixBySource {
+		return name
+	}
+
+	if _, collides := r.collidingSource(MustGetKey(source), fmt.Sprintf("%s/%s", namespace, name)); collides {
+		return fmt.Sprintf("%s-%s", name, source.GetNamespace())
+	}
+
+	return name
+}
+
+// mayReplaceCollidingTarget checks if the source may be replicated into the given namespace without overwriting
+// a replica of another source. Replicas of sources with a lower ReplicationPriority are overwritten; if both
+// sources have the same priority, the collision strategy decides.
+func (r *GenericReplicator) mayReplaceCollidingTarget(source interface{}, namespace v1.Namespace) bool {
+	sourceKey := MustGetKey(source)
+	targetLocation := fmt.Sprintf("%s/%s", namespace.Name, r.ResolveTargetName(MustGetObject(source), namespace.Name))
+	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey).WithField("target", targetLocation)
+
+	otherSource, collides := r.collidingSource(sourceKey, targetLocation)
+	if !collides {
+		return true
+	}
+
+	priority := GetReplicationPriority(MustGetObject(source))
+	otherPriority := 0
+	otherObj, otherExists, err := r.Store.GetByKey(otherSource)
+	if err == nil && otherExists {
+		otherPriority = GetReplicationPriority(MustGetObject(otherObj))
+	}
+
+	if priority > otherPriority {
+		logger.Infof("%s is replicated from %s with lower priority %d; replacing it", targetLocation, otherSource, otherPriority)
+		if otherExists {
+			r.recordEvent(otherObj, v1.EventTypeNormal, "ReplicationOverridden",
+				"%s %s is now replicated from %s, which has a higher priority", r.Kind, targetLocation, sourceKey)
+		}
+		return true
+	} else if priority < otherPriority {
+		logger.Infof("%s is replicated from %s with higher priority %d; skipping", targetLocation, otherSource, otherPriority)
+		r.recordEvent(source, v1.EventTypeNormal, "ReplicationOverridden",
+			"%s %s is replicated from %s, which has a higher priority", r.Kind, targetLocation, otherSource)
+		return false
+	}
+
+	switch r.CollisionStrategy {
+	case CollisionStrategyFirstWins:
+		logger.Debugf("%s is already replicated from %s; skipping", targetLocation, otherSource)
+	default:
+		logger.Errorf("%s is already replicated from %s; not replicating %s into it", targetLocation, otherSource, sourceKey)
+		r.recordEvent(source, v1.EventTypeWarning, "ReplicationCollision",
+			"%s %s is already replicated from %s", r.Kind, targetLocation, otherSource)
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCollisionStrategies(t *testing.T) {
+	existing := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "shared",
+		Namespace:   "target",
+		Annotations: map[string]string{ReplicatedSourceAnnotation: "first/shared"},
+	}}
+	first := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "first"}}
+	second := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "second"}}
+	target := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "target"}}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(existing))
+
+	for _, strategy := range []string{CollisionStrategyError, CollisionStrategyFirstWins} {
+		r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret", CollisionStrategy: strategy}, Store: store, TargetStore: store}
+
+		assert.True(t, r.mayReplaceCollidingTarget(first, target), strategy)
+		assert.False(t, r.mayReplaceCollidingTarget(second, target), strategy)
+		assert.Equal(t, "shared", r.ResolveTargetName(second, "target"), strategy)
+	}
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret", CollisionStrategy: CollisionStrategySuffixBySource}, Store: store, TargetStore: store}
+
+	assert.True(t, r.mayReplaceCollidingTarget(second, target))
+	assert.Equal(t, "shared", r.ResolveTargetName(first, "target"))
+	assert.Equal(t, "shared-second", r.ResolveTargetName(second, "target"))
+}
+
+func TestCollisionPriority(t *testing.T) {
+	existing := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "shared",
+		Namespace:   "target",
+		Annotations: map[string]string{ReplicatedSourceAnnotation: "first/shared"},
+	}}
+	first := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "shared",
+		Namespace:   "first",
+		Annotations: map[string]string{ReplicationPriority: "10"},
+	}}
+	second := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "second"}}
+	target := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "target"}}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(existing))
+	assert.NoError(t, store.Add(first))
+	assert.NoError(t, store.Add(second))
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret", CollisionStrategy: CollisionStrategyError}, Store: store, TargetStore: store}
+
+	assert.False(t, r.mayReplaceCollidingTarget(second, target))
+
+	second.Annotations = map[string]string{ReplicationPriority: "20"}
+	assert.True(t, r.mayReplaceCollidingTarget(second, target))
+
+	second.Annotations[ReplicationPriority] = "10"
+	assert.False(t, r.mayReplaceCollidingTarget(second, target))
+
+	// replicas of sources that no longer exist have the default priority
+	assert.NoError(t, store.Delete(first))
+	assert.True(t, r.mayReplaceCollidingTarget(second, target))
+}
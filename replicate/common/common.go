@@ -28,6 +28,43 @@ func PreviouslyPresentKeys(object *metav1.ObjectMeta) (map[string]struct{}, bool
 	return out, true
 }
 
+// TargetName returns the name that the replica of the source has in the given target namespace. This is the name of
+// the source itself, unless it is overridden with the ReplicateToName annotation, decorated with the prefix and
+// suffix from the ReplicateToPrefix and ReplicateToSuffix annotations. Names that cannot be rendered are returned
+// unrendered; ValidateAnnotations reports them.
+func TargetName(source metav1.Object, namespace string) string {
+	name, _ := renderTargetName(source, namespace)
+	return name
+}
+
+// renderTargetName builds the target name from the annotations of the source and renders it as a Go template
+// with the target namespace as TemplateData.Namespace
+func renderTargetName(source metav1.Object, namespace string) (string, error) {
+	annotations := source.GetAnnotations()
+
+	name := strings.TrimSpace(annotations[ReplicateToName])
+	if name == "" {
+		name = source.GetName()
+	}
+
+	name = GenerateTargetName(name, annotations[ReplicateToPrefix], annotations[ReplicateToSuffix])
+	if !strings.Contains(name, "{{") {
+		return name, nil
+	}
+
+	renderer := ValueRenderer{enabled: true, data: TemplateData{Namespace: namespace}}
+	rendered, err := renderer.Render(name)
+	if err != nil {
+		return name, err
+	}
+	return rendered, nil
+}
+
+// GenerateTargetName builds a target name from the given name, prefix and suffix
+func GenerateTargetName(name string, prefix string, suffix string) string {
+	return strings.TrimSpace(prefix) + name + strings.TrimSpace(suffix)
+}
+
 func BuildStrictRegex(regex string) string {
 	reg := strings.TrimSpace(regex)
 	if !strings.HasPrefix(reg, "^") {
@@ -42,3 +79,20 @@ func BuildStrictRegex(regex string) string {
 func JSONPatchPathEscape(annotation string) string {
 	return strings.ReplaceAll(annotation, "/", "~1")
 }
+
+// WithNamespaceOwnerReference adds the given namespace as owner to a list of owner references, so that the
+// replica is garbage collected together with its namespace
+func WithNamespaceOwnerReference(ownerReferences []metav1.OwnerReference, namespace *v1.Namespace) []metav1.OwnerReference {
+	for _, ref := range ownerReferences {
+		if ref.UID == namespace.UID {
+			return ownerReferences
+		}
+	}
+
+	return append(ownerReferences, metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Name:       namespace.Name,
+		UID:        namespace.UID,
+	})
+}
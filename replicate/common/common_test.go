@@ -0,0 +1,52 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTargetName(t *testing.T) {
+	source := &metav1.ObjectMeta{Name: "source"}
+	assert.Equal(t, "source", TargetName(source, "target"))
+
+	source.Annotations = map[string]string{ReplicateToName: " renamed "}
+	assert.Equal(t, "renamed", TargetName(source, "target"))
+
+	source.Annotations[ReplicateToName] = ""
+	assert.Equal(t, "source", TargetName(source, "target"))
+
+	source.Annotations[ReplicateToPrefix] = "team-a-"
+	source.Annotations[ReplicateToSuffix] = "-copy"
+	assert.Equal(t, "team-a-source-copy", TargetName(source, "target"))
+
+	source.Annotations[ReplicateToName] = "renamed"
+	assert.Equal(t, "team-a-renamed-copy", TargetName(source, "target"))
+}
+
+func TestTargetNameTemplate(t *testing.T) {
+	source := &metav1.ObjectMeta{
+		Name: "source",
+		Annotations: map[string]string{
+			ReplicateToName: "{{ .Namespace }}-registry-creds",
+		},
+	}
+	assert.Equal(t, "team-a-registry-creds", TargetName(source, "team-a"))
+	assert.Equal(t, "team-b-registry-creds", TargetName(source, "team-b"))
+
+	source.Annotations[ReplicateToName] = "{{ .Unknown }}-registry-creds"
+	_, err := renderTargetName(source, "team-a")
+	assert.Error(t, err)
+}
+
+func TestWithNamespaceOwnerReference(t *testing.T) {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "target", UID: "1234"}}
+
+	refs := WithNamespaceOwnerReference(nil, namespace)
+	assert.Equal(t, []metav1.OwnerReference{{APIVersion: "v1", Kind: "Namespace", Name: "target", UID: "1234"}}, refs)
+
+	refs = WithNamespaceOwnerReference(refs, namespace)
+	assert.Len(t, refs, 1)
+}
@@ -0,0 +1,142 @@
+package common
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Other replication tools whose annotations can be honored in addition to the replicator's own annotations
+const (
+	// CompatibilityKubed honors the "kubed.appscode.com/sync" annotation of kubed's config syncer
+	CompatibilityKubed = "kubed"
+
+	// CompatibilityReflector honors the "reflector.v1.k8s.emberstack.com/" annotations of emberstack's reflector
+	CompatibilityReflector = "reflector"
+)
+
+// CompatibilityModes lists all tools whose annotations can be honored
+var CompatibilityModes = []string{CompatibilityKubed, CompatibilityReflector}
+
+// Annotations of other replication tools
+const (
+	kubedSync = "kubed.appscode.com/sync"
+
+	reflectorAllowed           = "reflector.v1.k8s.emberstack.com/reflection-allowed"
+	reflectorAllowedNamespaces = "reflector.v1.k8s.emberstack.com/reflection-allowed-namespaces"
+	reflectorAutoEnabled       = "reflector.v1.k8s.emberstack.com/reflection-auto-enabled"
+	reflectorAutoNamespaces    = "reflector.v1.k8s.emberstack.com/reflection-auto-namespaces"
+	reflectorReflects          = "reflector.v1.k8s.emberstack.com/reflects"
+)
+
+// ParseCompatibilityModes parses a comma-separated list of compatibility modes
+func ParseCompatibilityModes(value string) ([]string, error) {
+	var modes []string
+	for _, mode := range strings.Split(value, ",") {
+		if mode = strings.TrimSpace(mode); mode == "" {
+			continue
+		}
+		if !slices.Contains(CompatibilityModes, mode) {
+			return nil, errors.Errorf("invalid compatibility mode %q; must be one of %v", mode, CompatibilityModes)
+		}
+		modes = append(modes, mode)
+	}
+	return modes, nil
+}
+
+// TranslateForeignAnnotations adds the replicator annotations that are equivalent to the annotations of the
+// given other replication tools to the object. Annotations of the replicator that are already set take
+// precedence. The added annotations are listed in the TranslatedAnnotations annotation, so that they can be
+// removed by StripTranslatedAnnotations before the object is written. It returns whether any annotation was added.
+func TranslateForeignAnnotations(object metav1.Object, modes []string) bool {
+	annotations := object.GetAnnotations()
+	if len(annotations) == 0 {
+		return false
+	}
+
+	translated := make(map[string]string)
+	for _, mode := range modes {
+		switch mode {
+		case CompatibilityKubed:
+			translateKubedAnnotations(annotations, translated)
+		case CompatibilityReflector:
+			translateReflectorAnnotations(annotations, translated)
+		}
+	}
+
+	var added []string
+	for key, value := range translated {
+		if _, ok := annotations[key]; !ok {
+			annotations[key] = value
+			added = append(added, key)
+		}
+	}
+
+	if len(added) == 0 {
+		return false
+	}
+
+	sort.Strings(added)
+	annotations[TranslatedAnnotations] = strings.Join(added, ",")
+	object.SetAnnotations(annotations)
+	return true
+}
+
+// StripTranslatedAnnotations removes the annotations that were added to a cached object by
+// TranslateForeignAnnotations. It must be called before a cached object is written back as a whole, since the
+// translated annotations would otherwise be stored in the API server.
+func StripTranslatedAnnotations(object metav1.Object) {
+	annotations := object.GetAnnotations()
+	translated, ok := annotations[TranslatedAnnotations]
+	if !ok {
+		return
+	}
+
+	for _, key := range strings.Split(translated, ",") {
+		delete(annotations, key)
+	}
+	delete(annotations, TranslatedAnnotations)
+	object.SetAnnotations(annotations)
+}
+
+// translateKubedAnnotations translates kubed's sync annotation, which contains a namespace label selector that
+// may be empty to sync into all namespaces
+func translateKubedAnnotations(annotations map[string]string, translated map[string]string) {
+	if selector, ok := annotations[kubedSync]; ok {
+		translated[ReplicateToMatching] = selector
+	}
+}
+
+// translateReflectorAnnotations translates reflector's annotations. Reflector allows all namespaces if no
+// namespaces are given, and automatically creates mirrors in all allowed namespaces unless auto namespaces are
+// given.
+func translateReflectorAnnotations(annotations map[string]string, translated map[string]string) {
+	if source, ok := annotations[reflectorReflects]; ok {
+		translated[ReplicateFromAnnotation] = source
+		return
+	}
+
+	if annotations[reflectorAllowed] != "true" {
+		return
+	}
+
+	allowedNamespaces := annotations[reflectorAllowedNamespaces]
+	if strings.TrimSpace(allowedNamespaces) == "" {
+		allowedNamespaces = ".*"
+	}
+	translated[ReplicationAllowed] = "true"
+	translated[ReplicationAllowedNamespaces] = allowedNamespaces
+
+	if annotations[reflectorAutoEnabled] != "true" {
+		return
+	}
+
+	autoNamespaces := annotations[reflectorAutoNamespaces]
+	if strings.TrimSpace(autoNamespaces) == "" {
+		autoNamespaces = allowedNamespaces
+	}
+	translated[ReplicateTo] = autoNamespaces
+}
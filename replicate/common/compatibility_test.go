@@ -0,0 +1,68 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseCompatibilityModes(t *testing.T) {
+	modes, err := ParseCompatibilityModes(" kubed, reflector")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{CompatibilityKubed, CompatibilityReflector}, modes)
+
+	modes, err = ParseCompatibilityModes("")
+	assert.NoError(t, err)
+	assert.Empty(t, modes)
+
+	_, err = ParseCompatibilityModes("kubed,kyverno")
+	assert.Error(t, err)
+}
+
+func TestTranslateForeignAnnotations(t *testing.T) {
+	kubed := &metav1.ObjectMeta{Annotations: map[string]string{kubedSync: "app=kubed"}}
+	assert.False(t, TranslateForeignAnnotations(kubed, []string{CompatibilityReflector}))
+	assert.True(t, TranslateForeignAnnotations(kubed, []string{CompatibilityKubed}))
+	assert.Equal(t, "app=kubed", kubed.Annotations[ReplicateToMatching])
+
+	reflector := &metav1.ObjectMeta{Annotations: map[string]string{
+		reflectorAllowed:     "true",
+		reflectorAutoEnabled: "true",
+	}}
+	assert.True(t, TranslateForeignAnnotations(reflector, []string{CompatibilityReflector}))
+	assert.Equal(t, "true", reflector.Annotations[ReplicationAllowed])
+	assert.Equal(t, ".*", reflector.Annotations[ReplicationAllowedNamespaces])
+	assert.Equal(t, ".*", reflector.Annotations[ReplicateTo])
+
+	restricted := &metav1.ObjectMeta{Annotations: map[string]string{
+		reflectorAllowed:           "true",
+		reflectorAllowedNamespaces: "team-.*",
+		reflectorAutoEnabled:       "true",
+		reflectorAutoNamespaces:    "team-a",
+		ReplicateTo:                "team-b",
+	}}
+	assert.True(t, TranslateForeignAnnotations(restricted, []string{CompatibilityReflector}))
+	assert.Equal(t, "team-.*", restricted.Annotations[ReplicationAllowedNamespaces])
+	assert.Equal(t, "team-b", restricted.Annotations[ReplicateTo], "replicator annotations take precedence")
+
+	mirror := &metav1.ObjectMeta{Annotations: map[string]string{reflectorReflects: "infra/registry-creds"}}
+	assert.True(t, TranslateForeignAnnotations(mirror, []string{CompatibilityReflector}))
+	assert.Equal(t, "infra/registry-creds", mirror.Annotations[ReplicateFromAnnotation])
+	assert.False(t, TranslateForeignAnnotations(mirror, []string{CompatibilityReflector}))
+}
+
+func TestStripTranslatedAnnotations(t *testing.T) {
+	object := &metav1.ObjectMeta{Annotations: map[string]string{
+		reflectorAllowed: "true",
+		ReplicateTo:      "team-b",
+	}}
+	assert.True(t, TranslateForeignAnnotations(object, []string{CompatibilityReflector}))
+	assert.Equal(t, ReplicationAllowed+","+ReplicationAllowedNamespaces, object.Annotations[TranslatedAnnotations])
+
+	StripTranslatedAnnotations(object)
+	assert.Equal(t, map[string]string{
+		reflectorAllowed: "true",
+		ReplicateTo:      "team-b",
+	}, object.Annotations, "only translated annotations are removed")
+}
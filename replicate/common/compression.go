@@ -0,0 +1,89 @@
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CompressedKeySuffix is appended to the keys of values that are stored compressed in the binaryData of a
+// config map
+const CompressedKeySuffix = ".gz"
+
+// ParseCompressionThreshold parses the value of the CompressValuesLargerThan annotation, e.g. "64Ki"
+func ParseCompressionThreshold(value string) (int64, error) {
+	quantity, err := resource.ParseQuantity(strings.TrimSpace(value))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid size %q", value)
+	}
+	return quantity.Value(), nil
+}
+
+// CompressionThreshold returns the size from which on values of the source are stored compressed in its replicas,
+// as configured by the CompressValuesLargerThan annotation. It returns false if values are not compressed.
+func CompressionThreshold(source metav1.Object) (int64, bool) {
+	value, ok := source.GetAnnotations()[CompressValuesLargerThan]
+	if !ok {
+		return 0, false
+	}
+
+	threshold, err := ParseCompressionThreshold(value)
+	if err != nil {
+		return 0, false
+	}
+	return threshold, true
+}
+
+// ShouldCompress checks if a value of the source is stored compressed in a replica. Values are never compressed
+// for targets that decompress them again.
+func ShouldCompress(source metav1.Object, target metav1.Object, value string) bool {
+	if target != nil && target.GetAnnotations()[DecompressValues] == "true" {
+		return false
+	}
+
+	threshold, ok := CompressionThreshold(source)
+	return ok && int64(len(value)) > threshold
+}
+
+// ShouldDecompress checks if a binary value of the source is stored decompressed in the target, which requires
+// the target to have the DecompressValues annotation and the key to end with CompressedKeySuffix
+func ShouldDecompress(target metav1.Object, key string) bool {
+	return target.GetAnnotations()[DecompressValues] == "true" && strings.HasSuffix(key, CompressedKeySuffix)
+}
+
+// CompressValue compresses a value with gzip. The result only depends on the value, so that unchanged values
+// result in unchanged replicas.
+func CompressValue(value string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(value)); err != nil {
+		return nil, errors.Wrap(err, "could not compress value")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not compress value")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecompressValue decompresses a value compressed by CompressValue
+func DecompressValue(value []byte) (string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return "", errors.Wrap(err, "could not decompress value")
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return "", errors.Wrap(err, "could not decompress value")
+	}
+
+	return string(decompressed), nil
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompression(t *testing.T) {
+	value := strings.Repeat("certificate bundle\n", 100)
+
+	compressed, err := CompressValue(value)
+	assert.NoError(t, err)
+	assert.Less(t, len(compressed), len(value))
+
+	again, err := CompressValue(value)
+	assert.NoError(t, err)
+	assert.Equal(t, compressed, again)
+
+	decompressed, err := DecompressValue(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, value, decompressed)
+
+	_, err = DecompressValue([]byte("not compressed"))
+	assert.Error(t, err)
+}
+
+func TestShouldCompress(t *testing.T) {
+	source := &metav1.ObjectMeta{Annotations: map[string]string{CompressValuesLargerThan: "1Ki"}}
+	target := &metav1.ObjectMeta{}
+
+	assert.False(t, ShouldCompress(source, nil, strings.Repeat("x", 1024)))
+	assert.True(t, ShouldCompress(source, nil, strings.Repeat("x", 1025)))
+	assert.True(t, ShouldCompress(source, target, strings.Repeat("x", 1025)))
+	assert.False(t, ShouldCompress(&metav1.ObjectMeta{}, nil, strings.Repeat("x", 1025)))
+
+	target.Annotations = map[string]string{DecompressValues: "true"}
+	assert.False(t, ShouldCompress(source, target, strings.Repeat("x", 1025)))
+	assert.True(t, ShouldDecompress(target, "bundle.pem.gz"))
+	assert.False(t, ShouldDecompress(target, "bundle.pem"))
+}
@@ -0,0 +1,177 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+var configMapWatcher ConfigMapWatcher
+
+type ConfigMapChangedFunc func(obj *v1.ConfigMap)
+
+// ConfigMapWatcher watches config maps that are referenced in annotations of replicated objects. The watch is
+// only started once the first reference is resolved, so that clusters not using such references do not pay for
+// caching all config maps.
+type ConfigMapWatcher struct {
+	doOnce   sync.Once
+	client   kubernetes.Interface
+	pageSize int64
+
+	ConfigMapStore      cache.Store
+	ConfigMapController cache.Controller
+
+	ChangedFuncs []ConfigMapChangedFunc
+}
+
+// OnConfigMapChanged will add another method to a list of functions to be called when a config map is created or updated
+func (cw *ConfigMapWatcher) OnConfigMapChanged(client kubernetes.Interface, pageSize int64, changedFunc ConfigMapChangedFunc) {
+	cw.client = client
+	cw.pageSize = pageSize
+	cw.ChangedFuncs = append(cw.ChangedFuncs, changedFunc)
+}
+
+// create will start watching config maps if this has not happened yet
+func (cw *ConfigMapWatcher) create(resyncPeriod time.Duration) {
+	cw.doOnce.Do(func() {
+		configMapChanged := func(obj interface{}) {
+			configMap := obj.(*v1.ConfigMap)
+			for _, changedFunc := range cw.ChangedFuncs {
+				go changedFunc(configMap)
+			}
+		}
+
+		cw.ConfigMapStore, cw.ConfigMapController = cache.NewInformerWithOptions(cache.InformerOptions{
+			ListerWatcher: InstrumentListWatch("ConfigMap/references", &cache.ListWatch{
+				ListFunc: PagedListFunc(func(lo metav1.ListOptions) (runtime.Object, error) {
+					return cw.client.CoreV1().ConfigMaps("").List(context.TODO(), lo)
+				}, cw.pageSize),
+				WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+					return cw.client.CoreV1().ConfigMaps("").Watch(context.TODO(), lo)
+				},
+			}),
+			ObjectType:   &v1.ConfigMap{},
+			ResyncPeriod: resyncPeriod,
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc:    configMapChanged,
+				UpdateFunc: func(old interface{}, new interface{}) { configMapChanged(new) },
+			},
+			Transform: StripBulkyMetadata,
+		})
+
+		log.WithField("kind", "ConfigMap").Infof("running referenced ConfigMap controller")
+		go cw.ConfigMapController.Run(wait.NeverStop)
+	})
+}
+
+// Lookup returns the value of a key in a config map. If the config map is not cached yet, it is read from the API.
+func (cw *ConfigMapWatcher) Lookup(namespace string, name string, key string, resyncPeriod time.Duration) (string, error) {
+	cw.create(resyncPeriod)
+
+	var configMap *v1.ConfigMap
+	obj, exists, err := cw.ConfigMapStore.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not get config map %s/%s from cache", namespace, name)
+	} else if exists {
+		configMap = obj.(*v1.ConfigMap)
+	} else {
+		configMap, err = cw.client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return "", errors.Errorf("config map %s/%s does not exist", namespace, name)
+		} else if err != nil {
+			return "", errors.Wrapf(err, "could not get config map %s/%s", namespace, name)
+		}
+	}
+
+	value, ok := configMap.Data[key]
+	if !ok {
+		return "", errors.Errorf("config map %s/%s has no key %s", namespace, name, key)
+	}
+
+	return value, nil
+}
+
+// ParseConfigMapReference parses a reference of the form "<namespace>/<name>#<key>". The namespace defaults to
+// the given namespace if omitted.
+func ParseConfigMapReference(reference string, defaultNamespace string) (namespace string, name string, key string, err error) {
+	location, key, ok := strings.Cut(strings.TrimSpace(reference), "#")
+	if !ok || key == "" {
+		return "", "", "", errors.Errorf("invalid config map reference %q: expected '<namespace>/<name>#<key>'", reference)
+	}
+
+	namespace, name, ok = strings.Cut(location, "/")
+	if !ok {
+		namespace, name = defaultNamespace, location
+	}
+	if namespace == "" || name == "" {
+		return "", "", "", errors.Errorf("invalid config map reference %q: expected '<namespace>/<name>#<key>'", reference)
+	}
+
+	return namespace, name, key, nil
+}
+
+// replicateToPatterns returns the namespace patterns the object is pushed to. They are read from the
+// ReplicateTo annotation and the config map referenced by the ReplicateToFromConfigMap annotation.
+func (r *GenericReplicator) replicateToPatterns(object metav1.Object) (string, bool, error) {
+	annotations := object.GetAnnotations()
+
+	patterns, found := annotations[ReplicateTo]
+
+	reference, ok := annotations[ReplicateToFromConfigMap]
+	if !ok {
+		return patterns, found, nil
+	}
+
+	namespace, name, key, err := ParseConfigMapReference(reference, object.GetNamespace())
+	if err != nil {
+		return patterns, found, err
+	}
+
+	referenced, err := configMapWatcher.Lookup(namespace, name, key, r.ResyncPeriod)
+	if err != nil {
+		return patterns, found, err
+	}
+
+	referenced = strings.Join(strings.Fields(strings.ReplaceAll(referenced, ",", " ")), ",")
+	if referenced == "" {
+		return patterns, found, nil
+	}
+	if found && patterns != "" {
+		return patterns + "," + referenced, true, nil
+	}
+
+	return referenced, true, nil
+}
+
+// ConfigMapChanged replicates all objects again whose namespace patterns are read from the given config map
+func (r *GenericReplicator) ConfigMapChanged(configMap *v1.ConfigMap) {
+	configMapKey := MustGetKey(configMap)
+
+	r.ReplicateToFromConfigMapList.Range(func(sourceKey string, reference string) bool {
+		if reference != configMapKey {
+			return true
+		}
+
+		obj, exists, err := r.Store.GetByKey(sourceKey)
+		if err != nil || !exists {
+			return true
+		}
+
+		log.WithField("kind", r.Kind).WithField("source", sourceKey).
+			Debugf("config map %s changed, replicating again", configMapKey)
+		r.ResourceAdded(obj)
+		return true
+	})
+}
@@ -0,0 +1,23 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfigMapReference(t *testing.T) {
+	namespace, name, key, err := ParseConfigMapReference("replicator/targets#secrets", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"replicator", "targets", "secrets"}, []string{namespace, name, key})
+
+	namespace, name, key, err = ParseConfigMapReference("targets#secrets", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default", "targets", "secrets"}, []string{namespace, name, key})
+
+	_, _, _, err = ParseConfigMapReference("replicator/targets", "default")
+	assert.Error(t, err)
+
+	_, _, _, err = ParseConfigMapReference("replicator/#secrets", "default")
+	assert.Error(t, err)
+}
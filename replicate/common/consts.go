@@ -2,14 +2,80 @@ package common
 
 // Annotations that are used to control this Controller's behaviour
 const (
-	ReplicateFromAnnotation         = "replicator.v1.mittwald.de/replicate-from"
-	ReplicatedAtAnnotation          = "replicator.v1.mittwald.de/replicated-at"
-	ReplicatedFromVersionAnnotation = "replicator.v1.mittwald.de/replicated-from-version"
-	ReplicatedKeysAnnotation        = "replicator.v1.mittwald.de/replicated-keys"
-	ReplicationAllowed              = "replicator.v1.mittwald.de/replication-allowed"
-	ReplicationAllowedNamespaces    = "replicator.v1.mittwald.de/replication-allowed-namespaces"
-	ReplicateTo                     = "replicator.v1.mittwald.de/replicate-to"
-	ReplicateToMatching             = "replicator.v1.mittwald.de/replicate-to-matching"
-	KeepOwnerReferences             = "replicator.v1.mittwald.de/keep-owner-references"
-	StripLabels                     = "replicator.v1.mittwald.de/strip-labels"
+	ReplicateFromAnnotation           = "replicator.v1.mittwald.de/replicate-from"
+	ReplicatedAtAnnotation            = "replicator.v1.mittwald.de/replicated-at"
+	ReplicatedFromVersionAnnotation   = "replicator.v1.mittwald.de/replicated-from-version"
+	ReplicatedKeysAnnotation          = "replicator.v1.mittwald.de/replicated-keys"
+	ReplicatedSourceAnnotation        = "replicator.v1.mittwald.de/replicated-source"
+	ReplicatedByControllerAnnotation  = "replicator.v1.mittwald.de/replicated-by-controller"
+	AddedAnnotationsAnnotation        = "replicator.v1.mittwald.de/added-annotations"
+	ReplicatedTriggerAnnotation       = "replicator.v1.mittwald.de/replicated-trigger"
+	ReplicatedContentHashAnnotation   = "replicator.v1.mittwald.de/replicated-content-hash"
+	MergedSourcesAnnotation           = "replicator.v1.mittwald.de/merged-sources"
+	ContentHashAnnotation             = "replicator.v1.mittwald.de/content-hash"
+	ReplicationAllowed                = "replicator.v1.mittwald.de/replication-allowed"
+	ReplicationAllowedNamespaces      = "replicator.v1.mittwald.de/replication-allowed-namespaces"
+	ReplicationAllowedNamespaceLabels = "replicator.v1.mittwald.de/replication-allowed-namespace-labels"
+	ReplicateTo                       = "replicator.v1.mittwald.de/replicate-to"
+	ReplicateToMatching               = "replicator.v1.mittwald.de/replicate-to-matching"
+	ReplicateToFromConfigMap          = "replicator.v1.mittwald.de/replicate-to-from-configmap"
+	ReplicateToSameTenant             = "replicator.v1.mittwald.de/replicate-to-same-tenant"
+	ReplicateToIntersect              = "replicator.v1.mittwald.de/replicate-to-intersect"
+	ReplicateToNamespacesWith         = "replicator.v1.mittwald.de/replicate-to-namespaces-with"
+	ReplicateToName                   = "replicator.v1.mittwald.de/replicate-to-name"
+	ReplicateToPrefix                 = "replicator.v1.mittwald.de/replicate-to-prefix"
+	ReplicateToSuffix                 = "replicator.v1.mittwald.de/replicate-to-suffix"
+	ReplicateKeys                     = "replicator.v1.mittwald.de/replicate-keys"
+	ReplicateKeysExclude              = "replicator.v1.mittwald.de/replicate-keys-exclude"
+	ReplicateKeyMap                   = "replicator.v1.mittwald.de/replicate-key-map"
+	TemplateValues                    = "replicator.v1.mittwald.de/template-values"
+	ReplicationStrategy               = "replicator.v1.mittwald.de/replication-strategy"
+	ReplicationPriority               = "replicator.v1.mittwald.de/replication-priority"
+	CompressValuesLargerThan          = "replicator.v1.mittwald.de/compress-values-larger-than"
+	DecompressValues                  = "replicator.v1.mittwald.de/decompress-values"
+	RequiresApproval                  = "replicator.v1.mittwald.de/requires-approval"
+	ApprovedVersion                   = "replicator.v1.mittwald.de/approved-version"
+	ReplicateOnce                     = "replicator.v1.mittwald.de/replicate-once"
+	ServiceAccountTokenMode           = "replicator.v1.mittwald.de/service-account-token-mode"
+	KeepAnnotations                   = "replicator.v1.mittwald.de/keep-annotations"
+	KeepOwnerReferences               = "replicator.v1.mittwald.de/keep-owner-references"
+	OwnByNamespace                    = "replicator.v1.mittwald.de/own-by-namespace"
+	StripLabels                       = "replicator.v1.mittwald.de/strip-labels"
+	StripLabelPrefixes                = "replicator.v1.mittwald.de/strip-label-prefixes"
+	ReplicateAddLabels                = "replicator.v1.mittwald.de/replicate-add-labels"
+	ReplicateAddAnnotations           = "replicator.v1.mittwald.de/replicate-add-annotations"
+	ReplicateAfter                    = "replicator.v1.mittwald.de/replicate-after"
+	ReplicateTrigger                  = "replicator.v1.mittwald.de/replicate-trigger"
+	DeletionPolicy                    = "replicator.v1.mittwald.de/replication-deletion-policy"
+	KeepOnNamespaceLabelChange        = "replicator.v1.mittwald.de/keep-on-namespace-label-change"
+	Retain                            = "replicator.v1.mittwald.de/retain"
+	PatchServiceAccounts              = "replicator.v1.mittwald.de/patch-service-accounts"
+	AcceptFrom                        = "replicator.v1.mittwald.de/accept-from"
+	ResyncPeriod                      = "replicator.v1.mittwald.de/resync-period"
+	MergeFrom                         = "replicator.v1.mittwald.de/merge-from"
+	CompareCertificates               = "replicator.v1.mittwald.de/compare-certificates"
+	MaxReplicas                       = "replicator.v1.mittwald.de/max-replicas"
+	FilterValues                      = "replicator.v1.mittwald.de/filter-values"
+	TranslatedAnnotations             = "replicator.v1.mittwald.de/translated-annotations"
+)
+
+// Labels that are set on replicas in push mode to identify their source
+const (
+	ReplicatedByNamespaceLabel = "replicator.v1.mittwald.de/replicated-by-namespace"
+	ReplicatedByNameLabel      = "replicator.v1.mittwald.de/replicated-by-name"
+)
+
+// AnnotationPrefix is the common prefix of all annotations evaluated or written by the replicator
+const AnnotationPrefix = "replicator.v1.mittwald.de/"
+
+// Annotations written by the legacy replication engine. These are only evaluated when adopting legacy replicas.
+const (
+	LegacyReplicatedByAnnotation = "replicator.v1.mittwald.de/replicated-by"
+)
+
+// Values of the ServiceAccountTokenMode annotation
+const (
+	ServiceAccountTokenModeCopy       = "copy"
+	ServiceAccountTokenModeSkip       = "skip"
+	ServiceAccountTokenModeRegenerate = "regenerate"
 )
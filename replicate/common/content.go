@@ -0,0 +1,84 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ContentHash computes a hash of everything a replica of the source depends on besides its labels: the source
+// itself, the given payload (like the data of a secret or the rules of a role), the replicator's annotations of the source and
+// the annotations that are propagated to the replica. Changes of any other metadata of the source, like the
+// tracking annotations of a GitOps tool, do not change the hash.
+func (r *GenericReplicator) ContentHash(source metav1.Object, payload ...interface{}) string {
+	sourceAnnotations := source.GetAnnotations()
+	filters := append(keptAnnotations(sourceAnnotations), r.PropagatedAnnotations...)
+
+	annotations := make(map[string]string)
+	for key, value := range sourceAnnotations {
+		if strings.HasPrefix(key, AnnotationPrefix) || isPropagatedAnnotation(key, filters) {
+			annotations[key] = value
+		}
+	}
+
+	// json.Marshal sorts map keys, so equal contents always result in equal hashes
+	encoded, err := json.Marshal([]interface{}{MustGetKey(source), payload, annotations})
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:])
+}
+
+// HasContentHash checks if a replica has been written from a source with the given content hash, so that
+// updating it again would not change anything but its bookkeeping annotations
+func HasContentHash(target metav1.Object, hash string) bool {
+	return hash != "" && target.GetAnnotations()[ReplicatedContentHashAnnotation] == hash
+}
+
+// DataHash computes a hash of the data of a replica, e.g. of the data and binary data of a config map
+func DataHash(data ...interface{}) string {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:])
+}
+
+// SetDataHash sets the ContentHashAnnotation of a replica to the hash of its data if StampContentHash is enabled,
+// and removes it otherwise. It returns whether the annotations changed.
+func (r *GenericReplicator) SetDataHash(annotations map[string]string, data ...interface{}) bool {
+	oldHash, ok := annotations[ContentHashAnnotation]
+	if !r.StampContentHash {
+		delete(annotations, ContentHashAnnotation)
+		return ok
+	}
+
+	hash := DataHash(data...)
+	annotations[ContentHashAnnotation] = hash
+	return !ok || oldHash != hash
+}
+
+// IsUnchangedSinceWrite checks if the target was last written by the replicator from the desired state with the
+// given hash, and its data has not changed since. Writing such a target again would be pointless even if its data
+// differs from the desired state, since that is the result of mutating it on every write (e.g. by an admission
+// webhook); doing so would cause an endless update loop.
+func (r *GenericReplicator) IsUnchangedSinceWrite(target metav1.Object, desiredHash string, data ...interface{}) bool {
+	if !HasContentHash(target, desiredHash) {
+		return false
+	}
+
+	written, ok := r.writtenHashes.Load(MustGetKey(target))
+	return ok && written == DataHash(data...)
+}
+
+// RememberWrite records the data of a target as returned by the API server after it has been written
+func (r *GenericReplicator) RememberWrite(target metav1.Object, data ...interface{}) {
+	r.writtenHashes.Store(MustGetKey(target), DataHash(data...))
+}
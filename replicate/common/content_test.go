@@ -0,0 +1,74 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestContentHash(t *testing.T) {
+	r := GenericReplicator{ReplicatorConfig: ReplicatorConfig{PropagatedAnnotations: []string{"reloader.stakater.com/"}}}
+	source := &metav1.ObjectMeta{
+		Labels: map[string]string{"app": "web"},
+		Annotations: map[string]string{
+			ReplicateTo:                      "target",
+			"argocd.argoproj.io/tracking-id": "web:/Secret:default/web",
+		},
+	}
+	data := map[string][]byte{"password": []byte("secret")}
+	hash := r.ContentHash(source, data)
+
+	source.Labels["app.kubernetes.io/instance"] = "web"
+	source.Annotations["argocd.argoproj.io/tracking-id"] = "web:/Secret:other/web"
+	assert.Equal(t, hash, r.ContentHash(source, data))
+
+	assert.NotEqual(t, hash, r.ContentHash(source, map[string][]byte{"password": []byte("changed")}))
+
+	source.Annotations[ReplicateTo] = "target,other"
+	assert.NotEqual(t, hash, r.ContentHash(source, data))
+	hash = r.ContentHash(source, data)
+
+	source.Annotations["reloader.stakater.com/match"] = "true"
+	assert.NotEqual(t, hash, r.ContentHash(source, data))
+	hash = r.ContentHash(source, data)
+
+	target := &metav1.ObjectMeta{Annotations: map[string]string{ReplicatedContentHashAnnotation: hash}}
+	assert.True(t, HasContentHash(target, hash))
+	assert.False(t, HasContentHash(&metav1.ObjectMeta{}, hash))
+}
+
+func TestSetDataHash(t *testing.T) {
+	r := GenericReplicator{}
+	annotations := map[string]string{ContentHashAnnotation: "outdated"}
+	data := map[string]string{"level": "debug"}
+
+	assert.True(t, r.SetDataHash(annotations, data))
+	assert.NotContains(t, annotations, ContentHashAnnotation)
+	assert.False(t, r.SetDataHash(annotations, data))
+
+	r.StampContentHash = true
+	assert.True(t, r.SetDataHash(annotations, data))
+	assert.Equal(t, DataHash(data), annotations[ContentHashAnnotation])
+	assert.False(t, r.SetDataHash(annotations, map[string]string{"level": "debug"}))
+
+	assert.True(t, r.SetDataHash(annotations, map[string]string{"level": "info"}))
+	assert.NotEqual(t, DataHash(data), annotations[ContentHashAnnotation])
+}
+
+func TestIsUnchangedSinceWrite(t *testing.T) {
+	r := GenericReplicator{}
+	target := &metav1.ObjectMeta{
+		Name:        "target",
+		Namespace:   "default",
+		Annotations: map[string]string{ReplicatedContentHashAnnotation: "desired"},
+	}
+	mutated := map[string]string{"key": "mutated by webhook"}
+
+	assert.False(t, r.IsUnchangedSinceWrite(target, "desired", mutated), "targets that were not written yet have to be written")
+
+	r.RememberWrite(target, mutated)
+	assert.True(t, r.IsUnchangedSinceWrite(target, "desired", mutated))
+	assert.False(t, r.IsUnchangedSinceWrite(target, "changed", mutated), "changes of the desired state have to be written")
+	assert.False(t, r.IsUnchangedSinceWrite(target, "desired", map[string]string{"key": "edited"}), "changes of the target have to be repaired")
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+var (
+	// creationLimiter is shared by all replicators, since they all create targets through the same API server.
+	// It is nil if creations are not rate limited.
+	creationLimiter     flowcontrol.RateLimiter
+	creationLimiterOnce sync.Once
+)
+
+// sharedCreationLimiter returns the creationLimiter, which is created from the configuration of the first
+// replicator
+func sharedCreationLimiter(config ReplicatorConfig) flowcontrol.RateLimiter {
+	creationLimiterOnce.Do(func() {
+		if config.MaxCreationsPerSecond > 0 {
+			creationLimiter = flowcontrol.NewTokenBucketRateLimiter(config.MaxCreationsPerSecond, max(1, int(config.MaxCreationsPerSecond)))
+		}
+	})
+	return creationLimiter
+}
+
+// waitForCreation blocks until the source may be replicated into the given namespace, if that would create a
+// new target and creations are rate limited. Creations that exceed the rate limit are queued in the order in
+// which they arrive.
+func (r *GenericReplicator) waitForCreation(source interface{}, namespace string) {
+	if r.creationLimiter == nil {
+		return
+	}
+
+	targetLocation := fmt.Sprintf("%s/%s", namespace, r.ResolveTargetName(MustGetObject(source), namespace))
+	if _, exists, err := r.cachedTarget(targetLocation); err != nil || exists {
+		return
+	}
+
+	if r.creationLimiter.TryAccept() {
+		return
+	}
+
+	log.WithField("kind", r.Kind).WithField("source", MustGetKey(source)).WithField("target", targetLocation).
+		Debugf("creation of %s exceeds the rate limit; waiting", targetLocation)
+
+	metricQueuedCreations.Inc()
+	defer metricQueuedCreations.Dec()
+	r.creationLimiter.Accept()
+}
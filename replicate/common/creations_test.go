@@ -0,0 +1,40 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestWaitForCreation(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "existing"}}))
+
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Secret"},
+		TargetStore:      store,
+		creationLimiter:  flowcontrol.NewTokenBucketRateLimiter(0.001, 1),
+	}
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "source"}}
+
+	// the first creation is within the burst, and updates are never limited
+	r.waitForCreation(source, "first")
+	r.waitForCreation(source, "existing")
+
+	done := make(chan struct{})
+	go func() {
+		r.waitForCreation(source, "second")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("creation exceeding the rate limit was not queued")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
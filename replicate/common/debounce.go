@@ -0,0 +1,39 @@
+package common
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ResourceUpdated handles updates of watched objects. If a DebouncePeriod is configured, bursts of updates of the
+// same object are coalesced into a single replication that happens once the object has not been updated for the
+// duration of the period; the latest version of the object is replicated.
+func (r *GenericReplicator) ResourceUpdated(old interface{}, new interface{}) {
+	if MustGetObject(old).GetResourceVersion() != MustGetObject(new).GetResourceVersion() {
+		r.observeChange(new)
+	}
+
+	if r.DebouncePeriod <= 0 {
+		r.ResourceAdded(new)
+		return
+	}
+
+	key := MustGetKey(new)
+	if timer, ok := r.debounceTimers.Load(key); ok && timer.Stop() {
+		log.WithField("kind", r.Kind).WithField("resource", key).Debugf("coalescing updates of %s %s", r.Kind, key)
+	}
+
+	r.debounceTimers.Store(key, time.AfterFunc(r.DebouncePeriod, func() {
+		r.debounceTimers.Delete(key)
+		r.resync(key)
+	}))
+}
+
+// cancelDebounce stops a pending replication of the object with the given key
+func (r *GenericReplicator) cancelDebounce(key string) {
+	if timer, ok := r.debounceTimers.Load(key); ok {
+		timer.Stop()
+		r.debounceTimers.Delete(key)
+	}
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestResourceUpdatedDebounce(t *testing.T) {
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Secret", DebouncePeriod: 50 * time.Millisecond},
+		Store:            cache.NewStore(cache.MetaNamespaceKeyFunc),
+	}
+
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cert", Namespace: "default"}}
+	for i := 0; i < 3; i++ {
+		r.ResourceUpdated(secret, secret)
+	}
+
+	timers := 0
+	r.debounceTimers.Range(func(string, *time.Timer) bool {
+		timers++
+		return true
+	})
+	assert.Equal(t, 1, timers)
+
+	assert.Eventually(t, func() bool {
+		_, pending := r.debounceTimers.Load("default/cert")
+		return !pending
+	}, time.Second, 10*time.Millisecond)
+
+	r.ResourceUpdated(secret, secret)
+	r.cancelDebounce("default/cert")
+	_, pending := r.debounceTimers.Load("default/cert")
+	assert.False(t, pending)
+}
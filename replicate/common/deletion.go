@@ -0,0 +1,53 @@
+package common
+
+import (
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Values of the DeletionPolicy annotation
+const (
+	// DeletionPolicyDelete deletes the replicas of a source (or clears the replicated data of its pull
+	// targets) when the source is deleted
+	DeletionPolicyDelete = "delete"
+
+	// DeletionPolicyRetain leaves the replicas of a source untouched when the source is deleted
+	DeletionPolicyRetain = "retain"
+)
+
+// DeletionPolicies lists all valid values of the DeletionPolicy annotation
+var DeletionPolicies = []string{DeletionPolicyDelete, DeletionPolicyRetain}
+
+// GetDeletionPolicy returns the deletion policy of the source, which defaults to "delete"
+func GetDeletionPolicy(source metav1.Object) string {
+	policy, ok := source.GetAnnotations()[DeletionPolicy]
+	if !ok || !slices.Contains(DeletionPolicies, policy) {
+		return DeletionPolicyDelete
+	}
+	return policy
+}
+
+// isRetained checks if a replica is protected from being deleted or cleared by the replicator with the Retain
+// annotation
+func isRetained(target interface{}) bool {
+	return MustGetObject(target).GetAnnotations()[Retain] == "true"
+}
+
+// keepsReplicasOnLabelChange checks if the replicas of a source have to be kept in namespaces whose labels no
+// longer match the source's selectors, as requested by the KeepOnNamespaceLabelChange annotation
+func keepsReplicasOnLabelChange(source interface{}) bool {
+	return MustGetObject(source).GetAnnotations()[KeepOnNamespaceLabelChange] == "true"
+}
+
+// retainsReplicasOf checks if the replicas of a deleted source have to be retained, either because of the
+// source's deletion policy, or because the deletion has only been inferred from a tombstone and the replicator is
+// configured to not trust these
+func (r *GenericReplicator) retainsReplicasOf(source interface{}) bool {
+	if _, tombstone := source.(cache.DeletedFinalStateUnknown); tombstone && r.RetainOnTombstone {
+		return true
+	}
+
+	return GetDeletionPolicy(MustGetObject(source)) == DeletionPolicyRetain
+}
@@ -0,0 +1,34 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestRetainsReplicasOf(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "source"}}
+	tombstone := cache.DeletedFinalStateUnknown{Key: "source/source", Obj: source}
+
+	repl := GenericReplicator{}
+	assert.False(t, repl.retainsReplicasOf(source))
+	assert.False(t, repl.retainsReplicasOf(tombstone))
+
+	repl.RetainOnTombstone = true
+	assert.False(t, repl.retainsReplicasOf(source))
+	assert.True(t, repl.retainsReplicasOf(tombstone))
+
+	source.Annotations = map[string]string{DeletionPolicy: DeletionPolicyRetain}
+	assert.True(t, repl.retainsReplicasOf(source))
+}
+
+func TestIsRetained(t *testing.T) {
+	target := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "target", Namespace: "target"}}
+	assert.False(t, isRetained(target))
+
+	target.Annotations = map[string]string{Retain: "true"}
+	assert.True(t, isRetained(target))
+}
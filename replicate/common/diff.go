@@ -0,0 +1,76 @@
+package common
+
+import (
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxLoggedValueLength is the length after which values logged by LogKeyDiff are truncated
+const maxLoggedValueLength = 64
+
+// KeyDiff lists the keys that are added to, removed from or changed in the data of a target by an update
+type KeyDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// DiffKeys compares the data of a target before and after an update. The keys of the result are sorted.
+func DiffKeys[V ~string | ~[]byte](old map[string]V, new map[string]V) KeyDiff {
+	var diff KeyDiff
+
+	for key, value := range new {
+		if oldValue, ok := old[key]; !ok {
+			diff.Added = append(diff.Added, key)
+		} else if string(oldValue) != string(value) {
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// LogKeyDiff logs which keys of the data of a target are added, removed or changed by an update at debug level, so
+// that it can be understood why a target is rewritten. Values are logged (truncated) unless redact is set, which it
+// should be for secrets.
+func LogKeyDiff[V ~string | ~[]byte](logger *log.Entry, old map[string]V, new map[string]V, redact bool) {
+	if !logger.Logger.IsLevelEnabled(log.DebugLevel) {
+		return
+	}
+
+	diff := DiffKeys(old, new)
+	for _, key := range diff.Added {
+		if redact {
+			logger.Debugf("adding key %s", key)
+		} else {
+			logger.Debugf("adding key %s: %q", key, truncateValue(string(new[key])))
+		}
+	}
+	for _, key := range diff.Removed {
+		logger.Debugf("removing key %s", key)
+	}
+	for _, key := range diff.Changed {
+		if redact {
+			logger.Debugf("changing key %s (value redacted)", key)
+		} else {
+			logger.Debugf("changing key %s: %q -> %q", key, truncateValue(string(old[key])), truncateValue(string(new[key])))
+		}
+	}
+}
+
+// truncateValue shortens a value for logging
+func truncateValue(value string) string {
+	if len(value) <= maxLoggedValueLength {
+		return value
+	}
+	return value[:maxLoggedValueLength] + "..."
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffKeys(t *testing.T) {
+	old := map[string][]byte{"kept": []byte("a"), "changed": []byte("b"), "removed": []byte("c")}
+	new := map[string][]byte{"kept": []byte("a"), "changed": []byte("B"), "added2": []byte("d"), "added1": []byte("e")}
+
+	diff := DiffKeys(old, new)
+	assert.Equal(t, []string{"added1", "added2"}, diff.Added)
+	assert.Equal(t, []string{"removed"}, diff.Removed)
+	assert.Equal(t, []string{"changed"}, diff.Changed)
+}
+
+func testDiffLogger(level log.Level) (*log.Entry, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&buf)
+	logger.SetLevel(level)
+	return log.NewEntry(logger), &buf
+}
+
+func TestLogKeyDiff(t *testing.T) {
+	old := map[string]string{"changed": "old-value", "removed": "x"}
+	new := map[string]string{"changed": "new-value", "added": strings.Repeat("v", 100)}
+
+	logger, buf := testDiffLogger(log.DebugLevel)
+	LogKeyDiff(logger, old, new, false)
+	output := buf.String()
+	assert.Contains(t, output, "adding key added: \\\""+strings.Repeat("v", maxLoggedValueLength)+"...\\\"")
+	assert.Contains(t, output, "removing key removed")
+	assert.Contains(t, output, "changing key changed: \\\"old-value\\\" -> \\\"new-value\\\"")
+
+	logger, buf = testDiffLogger(log.DebugLevel)
+	LogKeyDiff(logger, old, new, true)
+	output = buf.String()
+	assert.Contains(t, output, "adding key added\"")
+	assert.Contains(t, output, "changing key changed (value redacted)")
+	assert.NotContains(t, output, "old-value")
+	assert.NotContains(t, output, "new-value")
+
+	logger, buf = testDiffLogger(log.InfoLevel)
+	LogKeyDiff(logger, old, new, false)
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,158 @@
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+)
+
+// eventComponent is the component name under which events are recorded
+const eventComponent = "kubernetes-replicator"
+
+// eventCacheSize is the number of objects for which the event recorder keeps track of recent events. It is larger
+// than client-go's default, since a single source may have thousands of replicas.
+const eventCacheSize = 16384
+
+// EventOptions limits the rate at which events are recorded for an object, so that bursts of replications (e.g. on
+// resync) don't create thousands of events. Zero values fall back to the defaults of client-go.
+type EventOptions struct {
+	// Burst is the number of events that may be recorded for an object at once
+	Burst int
+
+	// Interval is the time after which another event may be recorded for an object once Burst is exhausted
+	Interval time.Duration
+}
+
+// correlatorOptions translates the options to those of the event correlator, which drops events exceeding the
+// rate limits and aggregates similar events of the same object into one
+func (o EventOptions) correlatorOptions() record.CorrelatorOptions {
+	options := record.CorrelatorOptions{
+		LRUCacheSize: eventCacheSize,
+		BurstSize:    o.Burst,
+	}
+	if o.Interval > 0 {
+		options.QPS = float32(1 / o.Interval.Seconds())
+	}
+	return options
+}
+
+// NewEventRecorder creates an event recorder that records events in the API server
+func NewEventRecorder(client kubernetes.Interface, options EventOptions) record.EventRecorder {
+	broadcaster := record.NewBroadcaster(record.WithCorrelatorOptions(options.correlatorOptions()))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: eventComponent})
+}
+
+// recordEvent records an event for the given object, if an event recorder is configured
+func (r *GenericReplicator) recordEvent(obj interface{}, eventType string, reason string, messageFmt string, args ...interface{}) {
+	if r.EventRecorder == nil {
+		return
+	}
+
+	object, ok := obj.(runtime.Object)
+	if !ok {
+		log.WithField("kind", r.Kind).Warnf("cannot record event for %T", obj)
+		return
+	}
+
+	r.EventRecorder.Eventf(object, eventType, reason, messageFmt, args...)
+}
+
+// Reasons for which a source does not permit its replication, see ReplicationDeniedError
+const (
+	DeniedReasonNotAllowed            = "not-allowed"
+	DeniedReasonNamespaceNotPermitted = "namespace-not-permitted"
+	DeniedReasonBadAnnotation         = "bad-annotation"
+	DeniedReasonAccessReview          = "access-review"
+)
+
+// ReplicationDeniedError is returned when a source does not permit its replication into a target
+type ReplicationDeniedError struct {
+	Reason  string
+	message string
+}
+
+func (e *ReplicationDeniedError) Error() string {
+	return e.message
+}
+
+// deniedf creates a ReplicationDeniedError with the given reason
+func deniedf(reason string, format string, args ...interface{}) error {
+	return &ReplicationDeniedError{Reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// isReplicationDenied checks if the given (possibly wrapped) error denies a replication
+func isReplicationDenied(err error) bool {
+	var denied *ReplicationDeniedError
+	return errors.As(err, &denied)
+}
+
+// targetReference refers to the target at the given location, which has the type of the given source. Unlike the
+// target itself, the reference can be used to record events on targets that are not cached (yet).
+func (r *GenericReplicator) targetReference(source interface{}, targetLocation string) runtime.Object {
+	object, ok := source.(runtime.Object)
+	if !ok {
+		return nil
+	}
+
+	ref, err := reference.GetReference(scheme.Scheme, object)
+	if err != nil {
+		log.WithField("kind", r.Kind).WithError(err).Warnf("cannot refer to target %s", targetLocation)
+		return nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(targetLocation)
+	if err != nil {
+		log.WithField("kind", r.Kind).WithError(err).Warnf("cannot refer to target %s", targetLocation)
+		return nil
+	}
+
+	ref = &v1.ObjectReference{
+		Kind:       ref.Kind,
+		APIVersion: ref.APIVersion,
+		Namespace:  namespace,
+		Name:       name,
+	}
+	if target, exists, err := r.cachedTarget(targetLocation); err == nil && exists {
+		ref.UID = types.UID(MustGetObject(target).GetUID())
+	}
+
+	return ref
+}
+
+// recordReplicationEvents records the outcome of a replication from the given source into the target at the given
+// location, both on the source and on the target. updated tells whether the target existed before.
+func (r *GenericReplicator) recordReplicationEvents(source interface{}, targetLocation string, updated bool, err error) {
+	sourceKey := MustGetKey(source)
+	target := r.targetReference(source, targetLocation)
+	if target == nil {
+		return
+	}
+
+	switch {
+	case isReplicationDenied(err):
+		r.recordEvent(source, v1.EventTypeWarning, "ReplicationDenied", "Replication to %s denied: %v", targetLocation, err)
+		r.recordEvent(target, v1.EventTypeWarning, "ReplicationDenied", "Replication from %s denied: %v", sourceKey, err)
+	case err != nil:
+		r.recordEvent(source, v1.EventTypeWarning, "ReplicationFailed", "Replication to %s failed: %v", targetLocation, err)
+		r.recordEvent(target, v1.EventTypeWarning, "ReplicationFailed", "Replication from %s failed: %v", sourceKey, err)
+	case updated:
+		r.recordEvent(source, v1.EventTypeNormal, "ReplicaUpdated", "Updated replica %s", targetLocation)
+		r.recordEvent(target, v1.EventTypeNormal, "Replicated", "Updated from %s", sourceKey)
+	default:
+		r.recordEvent(source, v1.EventTypeNormal, "ReplicaCreated", "Created replica %s", targetLocation)
+		r.recordEvent(target, v1.EventTypeNormal, "Replicated", "Created from %s", sourceKey)
+	}
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordReplicationEvents(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "source"}}
+
+	recorder := record.NewFakeRecorder(10)
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret", EventRecorder: recorder}, Store: store, TargetStore: store}
+
+	r.recordReplicationEvents(source, "target/shared", false, nil)
+	assert.Equal(t, "Normal ReplicaCreated Created replica target/shared", <-recorder.Events)
+	assert.Equal(t, "Normal Replicated Created from source/shared", <-recorder.Events)
+
+	r.recordReplicationEvents(source, "target/shared", true, nil)
+	assert.Equal(t, "Normal ReplicaUpdated Updated replica target/shared", <-recorder.Events)
+	assert.Equal(t, "Normal Replicated Updated from source/shared", <-recorder.Events)
+
+	r.recordReplicationEvents(source, "target/shared", true, errors.New("forbidden"))
+	assert.Equal(t, "Warning ReplicationFailed Replication to target/shared failed: forbidden", <-recorder.Events)
+	assert.Equal(t, "Warning ReplicationFailed Replication from source/shared failed: forbidden", <-recorder.Events)
+
+	r.recordReplicationEvents(source, "target/shared", true, deniedf(DeniedReasonNotAllowed, "not allowed"))
+	assert.Equal(t, "Warning ReplicationDenied Replication to target/shared denied: not allowed", <-recorder.Events)
+	assert.Equal(t, "Warning ReplicationDenied Replication from source/shared denied: not allowed", <-recorder.Events)
+}
+
+func TestIsReplicationDenied(t *testing.T) {
+	r := &GenericReplicator{}
+	source := &metav1.ObjectMeta{Name: "shared", Namespace: "source"}
+	target := &metav1.ObjectMeta{Name: "shared", Namespace: "target"}
+
+	ok, err := r.IsReplicationPermitted(target, source)
+	assert.False(t, ok)
+	assert.True(t, isReplicationDenied(err))
+	assert.True(t, isReplicationDenied(fmt.Errorf("replication of target is not permitted: %w", err)))
+	assert.False(t, isReplicationDenied(errors.New("forbidden")))
+}
+
+func TestEventOptions(t *testing.T) {
+	options := EventOptions{Burst: 10, Interval: 2 * time.Minute}.correlatorOptions()
+	assert.Equal(t, eventCacheSize, options.LRUCacheSize)
+	assert.Equal(t, 10, options.BurstSize)
+	assert.InDelta(t, 1./120., options.QPS, 1e-6)
+
+	options = EventOptions{}.correlatorOptions()
+	assert.Zero(t, options.BurstSize)
+	assert.Zero(t, options.QPS)
+}
+
+func TestEventRecorderLimitsEvents(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	recorder := NewEventRecorder(client, EventOptions{Burst: 3, Interval: time.Hour})
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "source", UID: "1234"}}
+
+	for i := 0; i < 10; i++ {
+		recorder.Eventf(source, v1.EventTypeWarning, "ReplicationFailed", "Replication to target-%d/shared failed", i)
+	}
+
+	writes := func() int {
+		count := 0
+		for _, action := range client.Actions() {
+			if action.Matches("create", "events") || action.Matches("patch", "events") {
+				count++
+			}
+		}
+		return count
+	}
+	assert.Eventually(t, func() bool { return writes() == 3 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 3, writes())
+}
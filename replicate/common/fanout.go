@@ -0,0 +1,74 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ParseMaxReplicas parses the value of the MaxReplicas annotation
+func ParseMaxReplicas(value string) (int, error) {
+	limit, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, errors.Errorf("expected a number, got %q", value)
+	}
+	if limit < 0 {
+		return 0, errors.Errorf("expected a non-negative number, got %d", limit)
+	}
+	return limit, nil
+}
+
+// GetMaxReplicas returns the maximum number of namespaces the source may be replicated to, as configured by the
+// MaxReplicas annotation. It returns false if the number is not limited.
+func GetMaxReplicas(source metav1.Object) (int, bool) {
+	value, ok := source.GetAnnotations()[MaxReplicas]
+	if !ok {
+		return 0, false
+	}
+
+	limit, err := ParseMaxReplicas(value)
+	if err != nil {
+		return 0, false
+	}
+	return limit, true
+}
+
+// withinFanOutLimit checks if replicating the source into the given namespaces keeps the number of namespaces
+// with replicas of the source within its MaxReplicas limit. Namespaces that already contain a replica are not
+// counted twice. If the limit would be exceeded, the source is not replicated into any of the namespaces.
+func (r *GenericReplicator) withinFanOutLimit(source interface{}, targets []v1.Namespace) bool {
+	limit, ok := GetMaxReplicas(MustGetObject(source))
+	if !ok {
+		return true
+	}
+
+	sourceKey := MustGetKey(source)
+	sourceNamespace := MustGetObject(source).GetNamespace()
+	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
+
+	namespaces := make(map[string]struct{})
+	for _, obj := range r.replicasOf(sourceKey) {
+		namespaces[MustGetObject(obj).GetNamespace()] = struct{}{}
+	}
+	for _, namespace := range targets {
+		if namespace.Name != sourceNamespace {
+			namespaces[namespace.Name] = struct{}{}
+		}
+	}
+
+	count := len(namespaces)
+	if count <= limit {
+		metricFanOutLimitExceeded.DeleteLabelValues(r.Kind, sourceKey)
+		return true
+	}
+
+	logger.Warnf("not replicating %s: it would be replicated to %d namespaces, exceeding its limit of %d", sourceKey, count, limit)
+	metricFanOutLimitExceeded.WithLabelValues(r.Kind, sourceKey).Set(float64(count))
+	r.recordEvent(source, v1.EventTypeWarning, "FanOutLimitExceeded",
+		"Not replicating %s: it would be replicated to %d namespaces, exceeding its limit of %d", r.Kind, count, limit)
+	return false
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestParseMaxReplicas(t *testing.T) {
+	limit, err := ParseMaxReplicas(" 20")
+	assert.NoError(t, err)
+	assert.Equal(t, 20, limit)
+
+	_, err = ParseMaxReplicas("many")
+	assert.Error(t, err)
+
+	_, err = ParseMaxReplicas("-1")
+	assert.Error(t, err)
+}
+
+func TestWithinFanOutLimit(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "source",
+		Namespace:   "existing",
+		Annotations: map[string]string{ReplicatedSourceAnnotation: "default/source"},
+	}}))
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: store}
+
+	namespaces := func(names ...string) []v1.Namespace {
+		result := make([]v1.Namespace, len(names))
+		for i, name := range names {
+			result[i] = v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		}
+		return result
+	}
+
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"}}
+	assert.True(t, r.withinFanOutLimit(source, namespaces("a", "b", "c")))
+
+	source.Annotations = map[string]string{MaxReplicas: "2"}
+	assert.True(t, r.withinFanOutLimit(source, namespaces("existing", "a", "default")))
+	assert.False(t, r.withinFanOutLimit(source, namespaces("a", "b")))
+}
@@ -0,0 +1,100 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// ParseValueFilters parses the value of the FilterValues annotation, which contains one "<key>=<JSONPath>" entry
+// per line, e.g. "config.json={.public}"
+func ParseValueFilters(value string) (map[string]*jsonpath.JSONPath, error) {
+	result := make(map[string]*jsonpath.JSONPath)
+
+	for _, entry := range strings.Split(value, "\n") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		key, expression, ok := strings.Cut(entry, "=")
+		key, expression = strings.TrimSpace(key), strings.TrimSpace(expression)
+		if !ok || key == "" || expression == "" {
+			return nil, errors.Errorf("invalid filter %q: expected '<key>=<JSONPath>'", entry)
+		}
+
+		filter := jsonpath.New(key)
+		if err := filter.Parse(expression); err != nil {
+			return nil, errors.Wrapf(err, "invalid JSONPath %q for key %s", expression, key)
+		}
+
+		result[key] = filter
+	}
+
+	return result, nil
+}
+
+// FilterValue applies the filter that the FilterValues annotation of the source defines for the given key to a
+// structured (JSON or YAML) value. The filtered value is encoded in the format of the original value. Values
+// without a filter are returned unchanged. Since filters usually hide sensitive parts of a document, values that
+// cannot be filtered result in an error rather than being replicated unfiltered.
+func FilterValue(source metav1.Object, key string, value string) (string, error) {
+	annotation, ok := source.GetAnnotations()[FilterValues]
+	if !ok {
+		return value, nil
+	}
+
+	filters, err := ParseValueFilters(annotation)
+	if err != nil {
+		return "", err
+	}
+
+	filter, ok := filters[key]
+	if !ok {
+		return value, nil
+	}
+
+	isJSON := json.Valid([]byte(value))
+
+	var document interface{}
+	if err := yaml.Unmarshal([]byte(value), &document); err != nil {
+		return "", errors.Wrapf(err, "value of key %s is neither JSON nor YAML", key)
+	}
+
+	results, err := filter.FindResults(document)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not filter key %s", key)
+	}
+
+	var matches []interface{}
+	for _, result := range results {
+		for _, match := range result {
+			matches = append(matches, match.Interface())
+		}
+	}
+
+	var filtered interface{} = matches
+	if len(matches) == 1 {
+		filtered = matches[0]
+	}
+
+	if isJSON {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(filtered); err != nil {
+			return "", errors.Wrapf(err, "could not encode filtered key %s", key)
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	}
+
+	encoded, err := yaml.Marshal(filtered)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not encode filtered key %s", key)
+	}
+	return string(encoded), nil
+}
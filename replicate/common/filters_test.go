@@ -0,0 +1,46 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseValueFilters(t *testing.T) {
+	filters, err := ParseValueFilters("config.json={.public}\n\n settings.yaml = {.features[*].name}\n")
+	require.NoError(t, err)
+	assert.Len(t, filters, 2)
+	assert.Contains(t, filters, "config.json")
+	assert.Contains(t, filters, "settings.yaml")
+
+	_, err = ParseValueFilters("config.json")
+	assert.Error(t, err)
+	_, err = ParseValueFilters("config.json={.public")
+	assert.Error(t, err)
+}
+
+func TestFilterValue(t *testing.T) {
+	source := &metav1.ObjectMeta{Annotations: map[string]string{
+		FilterValues: "config.json={.public}\nsettings.yaml={.features[*].name}",
+	}}
+
+	value, err := FilterValue(source, "config.json", `{"public":{"url":"https://example.com"},"secret":"s3cr3t"}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"url":"https://example.com"}`, value)
+
+	value, err = FilterValue(source, "settings.yaml", "features:\n- name: a\n  token: x\n- name: b\n  token: y\n")
+	require.NoError(t, err)
+	assert.Equal(t, "- a\n- b\n", value)
+
+	value, err = FilterValue(source, "other", "unfiltered")
+	require.NoError(t, err)
+	assert.Equal(t, "unfiltered", value)
+
+	_, err = FilterValue(source, "config.json", `{"secret":"s3cr3t"}`)
+	assert.Error(t, err)
+
+	_, err = FilterValue(source, "settings.yaml", "features: [")
+	assert.Error(t, err)
+}
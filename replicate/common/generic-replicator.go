@@ -4,11 +4,12 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/hashicorp/go-multierror"
@@ -17,9 +18,14 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 type ReplicatorConfig struct {
@@ -31,6 +37,128 @@ type ReplicatorConfig struct {
 	ListFunc      cache.ListFunc
 	WatchFunc     cache.WatchFunc
 	ObjType       runtime.Object
+
+	// GetFunc fetches a single object from the API; it is required to cache replicas as metadata only
+	GetFunc func(namespace string, name string) (runtime.Object, error)
+
+	// TargetResource is the resource of the replicas created in push mode; it is required to cache replicas
+	// as metadata only
+	TargetResource schema.GroupVersionResource
+
+	// StripLastAppliedConfiguration removes the annotation written by "kubectl apply" from cached objects. It
+	// must only be set for kinds whose objects are updated with patches, since it would be lost otherwise.
+	StripLastAppliedConfiguration bool
+
+	// MetadataOnlyTargets caches only the metadata of replicas created in push mode, using the MetadataClient.
+	// Kinds that don't set GetFunc and TargetResource cache complete replicas.
+	MetadataOnlyTargets bool
+	MetadataClient      metadata.Interface
+
+	// AdoptLegacyReplicas allows taking over replicas that were created by the legacy replication engine.
+	AdoptLegacyReplicas bool
+
+	// TenantLabel is the namespace label that identifies the tenant a namespace belongs to
+	TenantLabel string
+
+	// MaxObjectSizes maps lower-case kinds to the maximum size (in bytes) of sources that are replicated
+	MaxObjectSizes map[string]int64
+
+	// CollisionStrategy determines how sources are handled whose replicas would have the same name in a
+	// target namespace; one of CollisionStrategies
+	CollisionStrategy string
+
+	// EventRecorder is used to record events on sources and their replicas; events are not recorded if it is nil
+	EventRecorder record.EventRecorder
+
+	// AuditLog records every operation on targets; operations are not recorded if it is nil
+	AuditLog *AuditLog
+
+	// PropagatedAnnotations is a list of annotation keys and prefixes (ending with "/") that are copied
+	// from the source to its replicas, e.g. "reloader.stakater.com/".
+	PropagatedAnnotations []string
+
+	// DynamicClient is used to look up objects of arbitrary kinds, like the owners of replicas; owner references
+	// cannot be rewritten if it is nil
+	DynamicClient dynamic.Interface
+
+	// RetainOnTombstone keeps the replicas of sources whose deletion was not observed directly, but only
+	// inferred when the informer relisted the sources after its watch was interrupted
+	RetainOnTombstone bool
+
+	// DeleteUnselectedReplicas deletes the replicas of sources that are no longer replicated into any namespace,
+	// e.g. because their replicate-to annotation was removed. Otherwise, these replicas are only deleted along with
+	// their source.
+	DeleteUnselectedReplicas bool
+
+	// PullAccessReviewServiceAccount is the name of a service account in the target namespace of pull
+	// replications. If set, a source is only replicated into that namespace if the service account may get
+	// the source itself, as determined by a SubjectAccessReview.
+	PullAccessReviewServiceAccount string
+
+	// StampContentHash stamps replicas of secrets and config maps with a hash of their data, so that workloads
+	// can be rolled out when replicated data changes
+	StampContentHash bool
+
+	// CompatibilityModes lists other replication tools (see CompatibilityModes) whose annotations are honored
+	// like their equivalent replicator annotations
+	CompatibilityModes []string
+
+	// ControllerIdentity identifies this replicator instance in the provenance annotations of pushed replicas
+	ControllerIdentity string
+
+	// RetryBackoff is the delay before a failed replication is retried for the first time; it doubles with every
+	// further attempt up to MaxRetryBackoff. Failed replications are only retried on resync if it is zero.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+
+	// ListPageSize is the maximum number of objects that informers request per list call; objects are listed
+	// in a single call if it is zero
+	ListPageSize int64
+
+	// ResyncPeriods maps lower-case kinds to the resync period of their informers, overriding ResyncPeriod
+	ResyncPeriods map[string]time.Duration
+
+	// ResyncJitter is the maximum fraction by which the resync period of each informer is randomly extended
+	ResyncJitter float64
+
+	// DebouncePeriod is the time an updated object has to remain unchanged before it is replicated; updates are
+	// replicated immediately if it is zero
+	DebouncePeriod time.Duration
+
+	// LogDeduplicationPeriod is the time during which a repeated failure of the same replication is logged only
+	// once; every failure is logged if it is zero
+	LogDeduplicationPeriod time.Duration
+
+	// StalenessThreshold is the time after which a target that has not been updated to the version of its source is
+	// reported as stale; staleness is not tracked if it is zero
+	StalenessThreshold time.Duration
+
+	// NamespaceEventThreshold is the number of failed creations of a replica in a row after which warning events
+	// are recorded in its target namespace; no such events are recorded if it is zero
+	NamespaceEventThreshold int
+
+	// Workers is the number of namespaces a source is replicated into concurrently; namespaces are processed
+	// one after another if it is less than 2
+	Workers int
+
+	// WorkersPerKind maps lower-case kinds to their number of workers, overriding Workers
+	WorkersPerKind map[string]int
+
+	// NamespaceWorkers is the number of namespace events that are processed concurrently, shared by all
+	// replicators
+	NamespaceWorkers int
+
+	// MaxCreationsPerSecond limits the rate at which targets are created in push mode by all replicators;
+	// creations are not limited if it is zero
+	MaxCreationsPerSecond float32
+
+	// Checkpoint is the checkpoint saved by a previous run of the replicator, which is used to skip replicas that
+	// are up-to-date before the informers have synced
+	Checkpoint Checkpoint
+
+	// WatchSelector restricts the objects that are watched (and cached) to those matching it; all objects are
+	// watched if it is nil
+	WatchSelector labels.Selector
 }
 
 type UpdateFuncs struct {
@@ -38,6 +166,10 @@ type UpdateFuncs struct {
 	ReplicateObjectTo        func(source interface{}, target *v1.Namespace) error
 	PatchDeleteDependent     func(sourceKey string, target interface{}) (interface{}, error)
 	DeleteReplicatedResource func(target interface{}) error
+
+	// MergeDataFrom merges the data of several sources into one target; targets with a MergeFrom annotation
+	// are not supported if it is nil
+	MergeDataFrom func(sources []interface{}, target interface{}) error
 }
 
 type GenericReplicator struct {
@@ -45,9 +177,11 @@ type GenericReplicator struct {
 	Store      cache.Store
 	Controller cache.Controller
 
-	DependencyMap map[string]map[string]interface{}
-	DependentMap  map[string]string
-	UpdateFuncs   UpdateFuncs
+	// TargetStore contains the replicas created in push mode. It is identical to Store unless sources
+	// are replicated into objects of a different kind, or replicas are cached as metadata only.
+	TargetStore cache.Store
+
+	UpdateFuncs UpdateFuncs
 
 	// ReplicateToList is a set that caches the names of all secrets that have a
 	// "replicate-to" annotation.
@@ -56,124 +190,252 @@ type GenericReplicator struct {
 	// ReplicateToMatchingList is a set that caches the names of all secrets
 	// that have a "replicate-to-matching" annotation.
 	ReplicateToMatchingList GenericMap[string, labels.Selector]
+
+	// ReplicateToSameTenantList is a set that caches the names of all secrets
+	// that have a "replicate-to-same-tenant" annotation.
+	ReplicateToSameTenantList GenericMap[string, struct{}]
+
+	// ReplicateToFromConfigMapList caches the config map (as "<namespace>/<name>") that is
+	// referenced by the "replicate-to-from-configmap" annotation of a source.
+	ReplicateToFromConfigMapList GenericMap[string, string]
+
+	// namespacedResources caches the resources of the kinds resolved by namespacedResource
+	namespacedResources GenericMap[string, schema.GroupVersionResource]
+
+	// resyncTimers holds the timers of sources that are resynced with their own ResyncPeriod
+	resyncTimers GenericMap[string, *time.Timer]
+
+	// targetController maintains TargetStore if replicas are cached as metadata only
+	targetController cache.Controller
+
+	// synced is set once the informers have synced, see hasSynced
+	synced atomic.Bool
+
+	// debounceTimers holds the timers of objects whose updates are coalesced during the DebouncePeriod
+	debounceTimers GenericMap[string, *time.Timer]
+
+	// objectLocks serializes the replications of each object, see ResourceAdded
+	objectLocks keyLocks
+
+	// creationLimiter limits the rate at which targets are created; creations are not limited if it is nil
+	creationLimiter flowcontrol.RateLimiter
+
+	// writtenHashes holds the hash of the data of each target as returned by the API server after it was last
+	// written in pull mode, see IsUnchangedSinceWrite
+	writtenHashes GenericMap[string, string]
+
+	// retries holds the scheduled retries of failed replications, keyed by "<source>-><target>"
+	retries GenericMap[string, *pendingRetry]
+
+	// lastReplication is the time (in Unix nanoseconds) at which a replica was last written successfully
+	lastReplication atomic.Int64
+
+	// observedChanges holds the time at which the latest unreplicated change of each source was observed
+	observedChanges GenericMap[string, time.Time]
+
+	// laggingTargets holds the time since which each target has been found lagging behind its source, see
+	// StaleTargets
+	laggingTargets GenericMap[string, time.Time]
+
+	// failureLog deduplicates the warnings about failed replications, see logFailure
+	failureLog failureLog
+
+	// lastErrors holds the error of the latest failed replication into each target, keyed by the target's location
+	lastErrors GenericMap[string, replicationError]
+
+	// failedCreations counts the consecutive failed creations of each replica, see recordFailedCreation
+	failedCreations GenericMap[string, int]
 }
 
 // NewGenericReplicator creates a new generic replicator
 func NewGenericReplicator(config ReplicatorConfig) *GenericReplicator {
+	config.ResyncPeriod = kindResyncPeriod(config)
+	config.Workers = kindWorkers(config)
+
 	repl := GenericReplicator{
 		ReplicatorConfig:        config,
-		DependencyMap:           make(map[string]map[string]interface{}),
-		DependentMap:            make(map[string]string),
+		creationLimiter:         sharedCreationLimiter(config),
 		ReplicateToList:         GenericMap[string, struct{}]{},
 		ReplicateToMatchingList: GenericMap[string, labels.Selector]{},
+
+		ReplicateToSameTenantList:    GenericMap[string, struct{}]{},
+		ReplicateToFromConfigMapList: GenericMap[string, string]{},
 	}
 
-	store, controller := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc:  config.ListFunc,
-			WatchFunc: config.WatchFunc,
-		},
-		config.ObjType,
-		config.ResyncPeriod,
-		cache.ResourceEventHandlerFuncs{
-			AddFunc:    repl.ResourceAdded,
-			UpdateFunc: func(old interface{}, new interface{}) { repl.ResourceAdded(new) },
+	metadataOnlyTargets := supportsMetadataOnlyTargets(config)
+	if metadataOnlyTargets {
+		// replicas are cached by a separate informer
+		config.WatchSelector = withReplicaRequirement(config.WatchSelector, false)
+	}
+
+	store, controller := cache.NewInformerWithOptions(cache.InformerOptions{
+		ListerWatcher: newListWatch(config),
+		ObjectType:    config.ObjType,
+		ResyncPeriod:  config.ResyncPeriod,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    repl.resourceCreated,
+			UpdateFunc: repl.ResourceUpdated,
 			DeleteFunc: repl.ResourceDeleted,
 		},
-	)
+		Indexers:  Indexers,
+		Transform: newTransform(config),
+	})
 
-	namespaceWatcher.OnNamespaceAdded(config.Client, config.ResyncPeriod, repl.NamespaceAdded)
-	namespaceWatcher.OnNamespaceUpdated(config.Client, config.ResyncPeriod, repl.NamespaceUpdated)
+	namespaceWatcher.OnNamespaceAdded(config.Client, config.ResyncPeriod, config.ListPageSize, config.NamespaceWorkers, repl.NamespaceAdded)
+	namespaceWatcher.OnNamespaceUpdated(config.Client, config.ResyncPeriod, config.ListPageSize, config.NamespaceWorkers, repl.NamespaceUpdated)
+	configMapWatcher.OnConfigMapChanged(config.Client, config.ListPageSize, repl.ConfigMapChanged)
 
 	repl.Store = store
+	repl.TargetStore = store
 	repl.Controller = controller
 
+	if metadataOnlyTargets {
+		repl.TargetStore, repl.targetController = newMetadataTargetInformer(config)
+	}
+
 	return &repl
 }
 
 // IsReplicationPermitted checks if replication is allowed in annotations of the source object
 // Returns true if replication is allowed. If replication is not allowed returns false with
-// error message
+// error message. Denied replications are counted by their reason.
 func (r *GenericReplicator) IsReplicationPermitted(object *metav1.ObjectMeta, sourceObject *metav1.ObjectMeta) (bool, error) {
+	allowed, err := r.isReplicationPermitted(object, sourceObject)
+	var denied *ReplicationDeniedError
+	if errors.As(err, &denied) {
+		metricDeniedReplications.WithLabelValues(r.Kind, denied.Reason, object.Namespace).Inc()
+	}
+	return allowed, err
+}
+
+func (r *GenericReplicator) isReplicationPermitted(object *metav1.ObjectMeta, sourceObject *metav1.ObjectMeta) (bool, error) {
 	if r.AllowAll {
-		return true, nil
+		return r.reviewPullAccess(object, sourceObject)
 	}
 
 	// make sure source object allows replication
 	annotationAllowed, ok := sourceObject.Annotations[ReplicationAllowed]
 	if !ok {
-		return false, fmt.Errorf("source %s/%s does not allow replication. %s will not be replicated",
+		return false, deniedf(DeniedReasonNotAllowed, "source %s/%s does not allow replication. %s will not be replicated",
 			sourceObject.Namespace, sourceObject.Name, object.Name)
 	}
 	annotationAllowedBool, err := strconv.ParseBool(annotationAllowed)
 
 	// check if source object allows replication
-	if err != nil || !annotationAllowedBool {
-		return false, fmt.Errorf("source %s/%s does not allow replication. %s will not be replicated",
+	if err != nil {
+		return false, deniedf(DeniedReasonBadAnnotation, "source %s/%s has an invalid %s annotation %q. %s will not be replicated",
+			sourceObject.Namespace, sourceObject.Name, ReplicationAllowed, annotationAllowed, object.Name)
+	}
+	if !annotationAllowedBool {
+		return false, deniedf(DeniedReasonNotAllowed, "source %s/%s does not allow replication. %s will not be replicated",
 			sourceObject.Namespace, sourceObject.Name, object.Name)
 	}
 
 	// check if the target namespace is permitted
 	annotationAllowedNamespaces, ok := sourceObject.Annotations[ReplicationAllowedNamespaces]
-	if !ok {
-		return false, fmt.Errorf(
-			"source %s/%s does not allow replication (%s annotation missing). %s will not be replicated",
-			sourceObject.Namespace, sourceObject.Name, ReplicationAllowedNamespaces, object.Name)
+	annotationAllowedNamespaceLabels, okLabels := sourceObject.Annotations[ReplicationAllowedNamespaceLabels]
+	if !ok && !okLabels {
+		return false, deniedf(DeniedReasonNamespaceNotPermitted,
+			"source %s/%s does not allow replication (%s or %s annotation missing). %s will not be replicated",
+			sourceObject.Namespace, sourceObject.Name, ReplicationAllowedNamespaces, ReplicationAllowedNamespaceLabels, object.Name)
 	}
-	allowedNamespaces := strings.Split(annotationAllowedNamespaces, ",")
 	allowed := false
-	for _, ns := range allowedNamespaces {
-		ns := BuildStrictRegex(ns)
+	if ok {
+		matched, excluded := MatchAllowedNamespacePatterns(annotationAllowedNamespaces, object.Namespace)
+		if excluded {
+			return false, deniedf(DeniedReasonNamespaceNotPermitted,
+				"source %s/%s excludes namespace %s from replication. %s will not be replicated",
+				sourceObject.Namespace, sourceObject.Name, object.Namespace, object.Name)
+		}
+		if matched {
+			log.Tracef("Namespace '%s' matches '%s' -- allowing replication", object.Namespace, annotationAllowedNamespaces)
+			allowed = true
+		}
+	}
 
-		if matched, _ := regexp.MatchString(ns, object.Namespace); matched {
-			log.Tracef("Namespace '%s' matches '%s' -- allowing replication", object.Namespace, ns)
+	if !allowed && okLabels {
+		if _, err := labels.Parse(annotationAllowedNamespaceLabels); err != nil {
+			return false, deniedf(DeniedReasonBadAnnotation, "source %s/%s has an invalid %s annotation: %v. %s will not be replicated",
+				sourceObject.Namespace, sourceObject.Name, ReplicationAllowedNamespaceLabels, err, object.Name)
+		}
+		matched, err := r.namespaceMatchesSelector(object.Namespace, annotationAllowedNamespaceLabels)
+		if err != nil {
+			return false, errors.Wrapf(err, "source %s/%s: could not check labels of namespace %s",
+				sourceObject.Namespace, sourceObject.Name, object.Namespace)
+		}
+		if matched {
+			log.Tracef("Namespace '%s' matches '%s' -- allowing replication", object.Namespace, annotationAllowedNamespaceLabels)
 			allowed = true
-			break
 		}
 	}
 
-	err = nil
 	if !allowed {
-		err = fmt.Errorf(
+		return false, deniedf(DeniedReasonNamespaceNotPermitted,
 			"source %s/%s does not allow replication in namespace %s. %s will not be replicated",
 			sourceObject.Namespace, sourceObject.Name, object.Namespace, object.Name)
 	}
-	return allowed, err
+
+	return r.reviewPullAccess(object, sourceObject)
 }
 
+// Synced checks if the informer of the replicator has synced. Like hasSynced, replicators that have not been set
+// up by NewGenericReplicator count as synced.
 func (r *GenericReplicator) Synced() bool {
-	return r.Controller.HasSynced()
+	return r.Controller == nil || r.Controller.HasSynced()
+}
+
+// hasSynced checks if the informers of the replicator have synced. Unlike Synced, it may be called by event
+// handlers, which block the informers while they run. Replicators that have not been set up by NewGenericReplicator
+// count as synced.
+func (r *GenericReplicator) hasSynced() bool {
+	return r.Controller == nil || r.synced.Load()
 }
 
 func (r *GenericReplicator) Run() {
 	log.WithField("kind", r.Kind).Infof("running %s controller", r.Kind)
+	informersSynced := []cache.InformerSynced{r.Controller.HasSynced}
+	if r.targetController != nil {
+		go r.targetController.Run(wait.NeverStop)
+		informersSynced = append(informersSynced, r.targetController.HasSynced)
+	}
+	go func() {
+		if cache.WaitForCacheSync(wait.NeverStop, informersSynced...) {
+			r.synced.Store(true)
+		}
+	}()
+	go r.runFailureSummaries(wait.NeverStop)
 	r.Controller.Run(wait.NeverStop)
 }
 
 // NamespaceAdded replicates resources with ReplicateTo and ReplicateToMatching
-// annotations into newly created namespaces.
+// annotations, as well as the resources accepted by the namespace's AcceptFrom
+// annotation, into newly created namespaces.
 func (r *GenericReplicator) NamespaceAdded(ns *v1.Namespace) {
-	logger := log.WithField("kind", r.Kind).WithField("target", ns.Name)
+	ctx, span := r.startSpan(context.Background(), "NamespaceAdded", attribute.String("replicator.namespace", ns.Name))
+	defer span.End()
+
+	logger := r.logger(ctx).WithField("target", ns.Name)
 	r.ReplicateToList.Range(func(sourceKey string, _ struct{}) bool {
 		logger := logger.WithField("resource", sourceKey)
 		obj, exists, err := r.Store.GetByKey(sourceKey)
 
 		if err != nil {
-			log.WithError(err).Error("error fetching object from store")
+			logger.WithError(err).Error("error fetching object from store")
 			return true
 		} else if !exists {
-			log.Warn("object not found in store")
+			logger.Warn("object not found in store")
 			return true
 		}
 
 		objectMeta := MustGetObject(obj)
 		replicatedList := make([]string, 0)
-		namespacePatterns, found := objectMeta.GetAnnotations()[ReplicateTo]
+		namespacePatterns, found, err := r.replicateToPatterns(objectMeta)
+		if err != nil {
+			logger.WithError(err).Error("could not resolve namespace patterns")
+		}
 		if found {
-			if err := r.replicateResourceToMatchingNamespaces(obj, namespacePatterns, []v1.Namespace{*ns}); err != nil {
-				logger.
-					WithError(err).
-					Errorf("Failed replicating the resource to the new namespace %s: %v", ns.Name, err)
+			if err := r.replicateResourceToMatchingNamespaces(ctx, obj, namespacePatterns, []v1.Namespace{*ns}); err != nil {
+				logger.WithError(err).Debugf("Failed replicating the resource to the new namespace %s", ns.Name)
 			} else {
 				replicatedList = append(replicatedList, ns.Name)
 			}
@@ -189,10 +451,10 @@ func (r *GenericReplicator) NamespaceAdded(ns *v1.Namespace) {
 
 		obj, exists, err := r.Store.GetByKey(sourceKey)
 		if err != nil {
-			log.WithError(err).Error("error fetching object from store")
+			logger.WithError(err).Error("error fetching object from store")
 			return true
 		} else if !exists {
-			log.Warn("object not found in store")
+			logger.Warn("object not found in store")
 			return true
 		}
 
@@ -200,20 +462,63 @@ func (r *GenericReplicator) NamespaceAdded(ns *v1.Namespace) {
 			return true
 		}
 
-		if _, err := r.replicateResourceToNamespaces(obj, []v1.Namespace{*ns}); err != nil {
-			logger.WithError(err).Error("error while replicating object to namespace")
+		// intersected sources are replicated along with the ReplicateTo patterns above
+		if _, ok := intersectedSelector(MustGetObject(obj)); ok {
+			return true
+		}
+
+		if _, err := r.replicateResourceToNamespaces(ctx, obj, []v1.Namespace{*ns}); err != nil {
+			logger.WithError(err).Debug("error while replicating object to namespace")
 		}
 		return true
 	})
+
+	r.ReplicateToSameTenantList.Range(func(sourceKey string, _ struct{}) bool {
+		logger := logger.WithField("resource", sourceKey)
+
+		obj, exists, err := r.Store.GetByKey(sourceKey)
+		if err != nil {
+			logger.WithError(err).Error("error fetching object from store")
+			return true
+		} else if !exists {
+			logger.Warn("object not found in store")
+			return true
+		}
+
+		selector, err := r.sameTenantSelector(MustGetObject(obj).GetNamespace())
+		if err != nil {
+			logger.WithError(err).Warn("could not determine tenant of source")
+			return true
+		}
+
+		if !selector.Matches(namespaceLabels) {
+			return true
+		}
+
+		if _, err := r.replicateResourceToNamespaces(ctx, obj, []v1.Namespace{*ns}); err != nil {
+			logger.WithError(err).Debug("error while replicating object to namespace")
+		}
+		return true
+	})
+
+	r.replicateAcceptedSources(ctx, ns)
 }
 
 // NamespaceUpdated checks if namespace's labels changed and deletes any 'replicate-to-matching' resources
 // the namespace no longer qualifies for. Then it attempts to replicate resources into the updated ns based
 // on the updated set of labels
 func (r *GenericReplicator) NamespaceUpdated(nsOld *v1.Namespace, nsNew *v1.Namespace) {
-	logger := log.WithField("kind", r.Kind).WithField("target", nsNew.Name)
+	ctx, span := r.startSpan(context.Background(), "NamespaceUpdated", attribute.String("replicator.namespace", nsNew.Name))
+	defer span.End()
+
+	logger := r.logger(ctx).WithField("target", nsNew.Name)
 	// check if labels changed
 	if reflect.DeepEqual(nsNew.Labels, nsOld.Labels) {
+		if nsNew.Annotations[AcceptFrom] != nsOld.Annotations[AcceptFrom] {
+			logger.Infof("accepted sources of namespace %s changed, attempting to replicate %ss", nsNew.Name, r.Kind)
+			r.replicateAcceptedSources(ctx, nsNew)
+			return
+		}
 		logger.Debug("labels didn't change")
 		return
 	} else {
@@ -234,6 +539,10 @@ func (r *GenericReplicator) NamespaceUpdated(nsOld *v1.Namespace, nsNew *v1.Name
 					log.Warn("object not found in store")
 					return true
 				}
+				if keepsReplicasOnLabelChange(obj) {
+					logger.Infof("keeping %s %s in %s, but no longer updating it", r.Kind, sourceKey, nsNew.Name)
+					return true
+				}
 				// delete resource from the updated namespace
 				logger.Infof("removed %s %s from %s", r.Kind, sourceKey, nsNew.Name)
 				r.DeleteResourceInNamespaces(obj, &v1.NamespaceList{Items: []v1.Namespace{*nsNew}})
@@ -241,42 +550,70 @@ func (r *GenericReplicator) NamespaceUpdated(nsOld *v1.Namespace, nsNew *v1.Name
 			return true
 		})
 
+		// check 'replicate-to-same-tenant' resources against new labels
+		r.ReplicateToSameTenantList.Range(func(sourceKey string, _ struct{}) bool {
+			obj, exists, err := r.Store.GetByKey(sourceKey)
+			if err != nil {
+				log.WithError(err).Error("error fetching object from store")
+				return true
+			} else if !exists {
+				log.Warn("object not found in store")
+				return true
+			}
+			selector, err := r.sameTenantSelector(MustGetObject(obj).GetNamespace())
+			if err != nil {
+				logger.WithError(err).Warn("could not determine tenant of source")
+				return true
+			}
+			if selector.Matches(oldLabelSet) && !selector.Matches(newLabelSet) {
+				if keepsReplicasOnLabelChange(obj) {
+					logger.Infof("keeping %s %s in %s, but no longer updating it", r.Kind, sourceKey, nsNew.Name)
+					return true
+				}
+				logger.Infof("removed %s %s from %s", r.Kind, sourceKey, nsNew.Name)
+				r.DeleteResourceInNamespaces(obj, &v1.NamespaceList{Items: []v1.Namespace{*nsNew}})
+			}
+			return true
+		})
+
 		// replicate resources to updated ns
 		logger.Infof("labels of namespace %s changed, attempting to replicate %ss", nsNew.Name, r.Kind)
 		r.NamespaceAdded(nsNew)
 	}
 }
 
-// ResourceAdded checks resources with ReplicateTo or ReplicateFromAnnotation annotation
+// ResourceAdded checks resources with ReplicateTo or ReplicateFromAnnotation annotation. Objects with the same key
+// are processed one after another, whether they are passed by the informer or by a timer.
 func (r *GenericReplicator) ResourceAdded(obj interface{}) {
+	defer r.objectLocks.lock(MustGetKey(obj))()
+	r.resourceAdded(obj)
+}
+
+// resourceAdded implements ResourceAdded; the caller must hold the lock of the object
+func (r *GenericReplicator) resourceAdded(obj interface{}) {
 	objectMeta := MustGetObject(obj)
 	sourceKey := MustGetKey(objectMeta)
-	logger := log.WithField("kind", r.Kind).WithField("resource", sourceKey)
 
-	ctx := context.Background()
+	ctx, span := r.startSpan(context.Background(), "ResourceAdded", attribute.String("replicator.source", sourceKey))
+	defer span.End()
+	defer r.observeReplicationLatency(sourceKey)
+	logger := r.logger(ctx).WithField("resource", sourceKey)
 
-	if replicas, ok := r.DependencyMap[sourceKey]; ok {
-		logger.Debugf("objectMeta %s has %d dependents", sourceKey, len(replicas))
-		if err := r.updateDependents(obj, replicas); err != nil {
-			logger.WithError(err).Error("failed to update cache")
-		}
+	if !hasReplicatorAnnotations(objectMeta) {
+		metricInvalidConfiguration.DeleteLabelValues(r.Kind, sourceKey)
+	} else if err := r.ValidateAnnotations(objectMeta); err != nil {
+		logger.WithError(err).Warn("invalid replicator configuration")
+		metricInvalidConfiguration.WithLabelValues(r.Kind, sourceKey).Set(1)
+	} else {
+		metricInvalidConfiguration.WithLabelValues(r.Kind, sourceKey).Set(0)
 	}
-	source, ok := r.DependentMap[sourceKey]
-	if ok {
-		logger.Debugf("objectMeta %s has source %s", sourceKey, source)
 
-		sourceObject, exists, err := r.Store.GetByKey(source)
-		if err != nil {
-			logger.Debugf("could not get source %s %s: %s", r.Kind, source, err)
-			return
-		} else if !exists {
-			logger.Debugf("could not get source %s %s: does not exist", r.Kind, source)
-			return
-		}
-		targetMap := map[string]interface{}{MustGetKey(obj): ""}
-		if err := r.updateDependents(sourceObject, targetMap); err != nil {
-			logger.WithError(err).
-				Errorf("Failed to update cache for %s: %v", MustGetKey(objectMeta), err)
+	r.scheduleResync(objectMeta)
+
+	if dependents := r.dependentsOf(sourceKey); len(dependents) > 0 {
+		logger.Debugf("objectMeta %s has %d dependents", sourceKey, len(dependents))
+		if err := r.updateDependents(ctx, obj, dependents); err != nil {
+			logger.WithError(err).Debug("failed to update dependents")
 		}
 	}
 
@@ -284,15 +621,39 @@ func (r *GenericReplicator) ResourceAdded(obj interface{}) {
 
 	// Match resources with "replicate-from" annotation
 	if source, ok := annotations[ReplicateFromAnnotation]; ok {
-		if err := r.resourceAddedReplicateFrom(source, obj); err != nil {
-			logger.WithError(err).Error("could not copy from source")
+		if err := r.resourceAddedReplicateFrom(ctx, source, obj); err != nil {
+			r.logFailure(logger, source, sourceKey, err, "could not copy from source")
 		}
 
 		return
 	}
 
+	// Match resources with "merge-from" annotation
+	if _, ok := annotations[MergeFrom]; ok {
+		if err := r.resourceAddedMergeFrom(obj); err != nil {
+			r.logFailure(logger, annotations[MergeFrom], sourceKey, err, "could not merge sources")
+		} else {
+			r.forgetFailure(annotations[MergeFrom], sourceKey)
+		}
+
+		return
+	}
+
+	if reference, ok := annotations[ReplicateToFromConfigMap]; ok {
+		if namespace, name, _, err := ParseConfigMapReference(reference, objectMeta.GetNamespace()); err == nil {
+			r.ReplicateToFromConfigMapList.Store(sourceKey, namespace+"/"+name)
+		}
+	} else {
+		r.ReplicateToFromConfigMapList.Delete(sourceKey)
+	}
+
 	// Match resources with "replicate-to" annotation
-	if namespacePatterns, ok := annotations[ReplicateTo]; ok {
+	namespacePatterns, ok, err := r.replicateToPatterns(objectMeta)
+	if err != nil {
+		logger.WithError(err).Error("could not resolve namespace patterns")
+	}
+	pushed := ok || err != nil
+	if ok {
 		r.ReplicateToList.Store(sourceKey, struct{}{})
 
 		namespacesFromStore := namespaceWatcher.NamespaceStore.List()
@@ -300,15 +661,34 @@ func (r *GenericReplicator) ResourceAdded(obj interface{}) {
 		for i, ns := range namespacesFromStore {
 			namespaces[i] = *ns.(*v1.Namespace)
 		}
-		if err := r.replicateResourceToMatchingNamespaces(obj, namespacePatterns, namespaces); err != nil {
-			logger.WithError(err).Errorf("could not replicate object to other namespaces")
+		namespaces = r.withUncachedNamespaces(namespacePatterns, namespaces)
+		if err := r.replicateResourceToMatchingNamespaces(ctx, obj, namespacePatterns, namespaces); err != nil {
+			logger.WithError(err).Debug("could not replicate object to other namespaces")
 		}
 	} else {
 		r.ReplicateToList.Delete(sourceKey)
 	}
 
+	// Match namespaces with "accept-from" annotations
+	r.replicateToAcceptingNamespaces(ctx, obj)
+
+	// Match resources with "replicate-to-same-tenant" annotation
+	if sameTenant, ok := annotations[ReplicateToSameTenant]; ok && sameTenant == "true" {
+		pushed = true
+		r.ReplicateToSameTenantList.Store(sourceKey, struct{}{})
+
+		if selector, err := r.sameTenantSelector(objectMeta.GetNamespace()); err != nil {
+			logger.WithError(err).Error("could not determine tenant of source")
+		} else if err := r.replicateResourceToMatchingNamespacesByLabel(ctx, obj, selector); err != nil {
+			logger.WithError(err).Debug("error while replicating to namespaces of the same tenant")
+		}
+	} else {
+		r.ReplicateToSameTenantList.Delete(sourceKey)
+	}
+
 	// Match resources with "replicate-to-matching" annotations
 	if namespaceSelectorString, ok := annotations[ReplicateToMatching]; ok {
+		pushed = true
 		namespaceSelector, err := labels.Parse(namespaceSelectorString)
 		if err != nil {
 			r.ReplicateToMatchingList.Delete(sourceKey)
@@ -319,19 +699,26 @@ func (r *GenericReplicator) ResourceAdded(obj interface{}) {
 
 		r.ReplicateToMatchingList.Store(sourceKey, namespaceSelector)
 
-		if err := r.replicateResourceToMatchingNamespacesByLabel(ctx, obj, namespaceSelector); err != nil {
-			logger.WithError(err).Error("error while replicating by label selector")
+		if _, ok := intersectedSelector(objectMeta); ok {
+			logger.Debugf("replicating only to namespaces that also match %s", ReplicateTo)
+		} else if err := r.replicateResourceToMatchingNamespacesByLabel(ctx, obj, namespaceSelector); err != nil {
+			logger.WithError(err).Debug("error while replicating by label selector")
 		}
 	} else {
 		r.ReplicateToMatchingList.Delete(sourceKey)
 	}
+
+	if !pushed && r.DeleteUnselectedReplicas && !r.retainsReplicasOf(obj) {
+		// without push annotations, only the replicas labeled with the source are found
+		r.ResourceDeletedReplicateTo(obj)
+	}
 }
 
 // resourceAddedReplicateFrom replicates resources with ReplicateFromAnnotation
-func (r *GenericReplicator) resourceAddedReplicateFrom(sourceLocation string, target interface{}) error {
+func (r *GenericReplicator) resourceAddedReplicateFrom(ctx context.Context, sourceLocation string, target interface{}) error {
 	cacheKey := MustGetKey(target)
 
-	logger := log.WithField("kind", r.Kind).WithField("source", sourceLocation).WithField("target", cacheKey)
+	logger := r.logger(ctx).WithField("source", sourceLocation).WithField("target", cacheKey)
 	logger.Debugf("%s %s is replicated from %s", r.Kind, cacheKey, sourceLocation)
 	v := strings.SplitN(sourceLocation, "/", 2)
 
@@ -339,16 +726,6 @@ func (r *GenericReplicator) resourceAddedReplicateFrom(sourceLocation string, ta
 		return errors.Errorf("Invalid source location expected '<namespace>/<name>', got '%s'", sourceLocation)
 	}
 
-	if _, ok := r.DependencyMap[sourceLocation]; !ok {
-		r.DependencyMap[sourceLocation] = make(map[string]interface{})
-	}
-
-	r.DependencyMap[sourceLocation][cacheKey] = nil
-
-	if _, ok := r.DependentMap[cacheKey]; !ok {
-		r.DependentMap[cacheKey] = sourceLocation
-	}
-
 	sourceObject, exists, err := r.Store.GetByKey(sourceLocation)
 	if err != nil {
 		return errors.Wrapf(err, "Could not get source %s: %v", sourceLocation, err)
@@ -356,25 +733,67 @@ func (r *GenericReplicator) resourceAddedReplicateFrom(sourceLocation string, ta
 		return errors.Errorf("Could not get source %s: does not exist", sourceLocation)
 	}
 
-	if err := r.UpdateFuncs.ReplicateDataFrom(sourceObject, target); err != nil {
+	if !r.isApproved(sourceObject) || !r.withinSizeLimit(sourceObject) {
+		return nil
+	}
+
+	if isReplicatedOnce(sourceObject, target) {
+		logger.Debugf("%s %s has already been replicated once", r.Kind, cacheKey)
+		return nil
+	}
+
+	replicatedVersion := MustGetObject(target).GetAnnotations()[ReplicatedFromVersionAnnotation]
+	_, span := r.startSpan(ctx, "ReplicateDataFrom", attribute.String("replicator.source", sourceLocation),
+		attribute.String("replicator.target", cacheKey))
+	err = r.UpdateFuncs.ReplicateDataFrom(sourceObject, target)
+	endSpan(span, err)
+	r.recordPullReplication(sourceObject, target, replicatedVersion, err)
+	if err != nil {
+		r.requeueReplicationFrom(sourceLocation, cacheKey)
 		return errors.Wrapf(err, "Failed to replicate %s target %s -> %s: %v",
 			r.Kind, MustGetKey(sourceObject), cacheKey, err,
 		)
 	}
 
+	r.forgetRetries(sourceLocation, cacheKey)
 	return nil
 }
 
+// recordPullReplication records the outcome of replicating the source into a target that pulls it. replicatedVersion
+// is the version of the source that the target had been replicated from before; if the source has not changed since,
+// the target was not written and nothing is recorded.
+func (r *GenericReplicator) recordPullReplication(source interface{}, target interface{}, replicatedVersion string, err error) {
+	r.countReplication(source, err)
+	r.rememberError(source, MustGetKey(target), err)
+
+	changed := replicatedVersion != MustGetObject(source).GetResourceVersion()
+	if err != nil || changed {
+		r.recordReplicationEvents(source, MustGetKey(target), true, err)
+	}
+	if err == nil && changed {
+		r.Audit(AuditOperationUpdate, source, MustGetKey(target), AuditReasonPull)
+	}
+}
+
 // resourceAddedReplicateFrom replicates resources with ReplicateTo annotation
-func (r *GenericReplicator) replicateResourceToMatchingNamespaces(obj interface{}, nsPatternList string, namespaceList []v1.Namespace) error {
+func (r *GenericReplicator) replicateResourceToMatchingNamespaces(ctx context.Context, obj interface{}, nsPatternList string, namespaceList []v1.Namespace) error {
 	cacheKey := MustGetKey(obj)
-	logger := log.WithField("kind", r.Kind).WithField("source", cacheKey)
+	logger := r.logger(ctx).WithField("source", cacheKey)
 
 	logger.Infof("%s %s to be replicated to: [%s]", r.Kind, cacheKey, nsPatternList)
 
 	replicateTo := r.getNamespacesToReplicate(MustGetObject(obj).GetNamespace(), nsPatternList, namespaceList)
+	if selector, ok := intersectedSelector(MustGetObject(obj)); ok {
+		matching := make([]v1.Namespace, 0, len(replicateTo))
+		for _, namespace := range replicateTo {
+			if selector.Matches(labels.Set(namespace.Labels)) {
+				matching = append(matching, namespace)
+			}
+		}
+		replicateTo = matching
+	}
 
-	if replicated, err := r.replicateResourceToNamespaces(obj, replicateTo); err != nil {
+	if replicated, err := r.replicateResourceToNamespaces(ctx, obj, replicateTo); err != nil {
 		return errors.Wrapf(err, "Replicated %s to %d out of %d namespaces",
 			cacheKey, len(replicated), len(replicateTo),
 		)
@@ -386,14 +805,12 @@ func (r *GenericReplicator) replicateResourceToMatchingNamespaces(obj interface{
 func (r *GenericReplicator) replicateResourceToMatchingNamespacesByLabel(ctx context.Context, obj interface{}, selector labels.Selector) error {
 	cacheKey := MustGetKey(obj)
 
-	namespaces, err := r.Client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
-	if err != nil {
-		return errors.Wrap(err, "error while listing namespaces by selector")
-	}
+	// namespaces that are not cached yet are replicated into by NamespaceAdded
+	namespaces := cachedNamespaces(selector)
 
-	if replicated, err := r.replicateResourceToNamespaces(obj, namespaces.Items); err != nil {
+	if replicated, err := r.replicateResourceToNamespaces(ctx, obj, namespaces); err != nil {
 		return errors.Wrapf(err, "Replicated %s to %d out of %d namespaces",
-			cacheKey, len(replicated), len(namespaces.Items),
+			cacheKey, len(replicated), len(namespaces),
 		)
 	}
 
@@ -406,16 +823,12 @@ func (r *GenericReplicator) getNamespacesToReplicate(myNs string, patterns strin
 
 	replicateTo := make([]v1.Namespace, 0)
 	for _, namespace := range namespaces {
-		for _, ns := range StringToPatternList(patterns) {
-			if matched := ns.MatchString(namespace.Name); matched {
-				if namespace.Name == myNs {
-					// Don't replicate upon itself
-					continue
-				}
-				replicateTo = append(replicateTo, namespace)
-				break
-
-			}
+		if namespace.Name == myNs {
+			// Don't replicate upon itself
+			continue
+		}
+		if MatchNamespacePatterns(patterns, namespace.Name) {
+			replicateTo = append(replicateTo, namespace)
 		}
 	}
 	return replicateTo
@@ -423,29 +836,125 @@ func (r *GenericReplicator) getNamespacesToReplicate(myNs string, patterns strin
 
 // replicateResourceToNamespaces will replicate the given object into target namespaces. It will return a list of
 // Namespaces it was successful in replicating into
-func (r *GenericReplicator) replicateResourceToNamespaces(obj interface{}, targets []v1.Namespace) (replicatedTo []v1.Namespace, err error) {
-	cacheKey := MustGetKey(obj)
+func (r *GenericReplicator) replicateResourceToNamespaces(ctx context.Context, obj interface{}, targets []v1.Namespace) (replicatedTo []v1.Namespace, err error) {
+	sourceNamespace := MustGetObject(obj).GetNamespace()
+
+	ctx, span := r.startSpan(ctx, "ReplicateToNamespaces", attribute.String("replicator.source", MustGetKey(obj)),
+		attribute.Int("replicator.namespaces", len(targets)))
+	defer func() {
+		span.SetAttributes(attribute.Int("replicator.replicated", len(replicatedTo)))
+		endSpan(span, err)
+	}()
+
+	if !r.isApproved(obj) || !r.withinSizeLimit(obj) || !r.withinFanOutLimit(obj, targets) {
+		return nil, nil
+	}
 
-	for _, namespace := range targets {
-		if innerErr := r.UpdateFuncs.ReplicateObjectTo(obj, &namespace); innerErr != nil {
-			err = multierror.Append(err, errors.Wrapf(innerErr, "Failed to replicate %s %s -> %s: %v",
-				r.Kind, cacheKey, namespace.Name, innerErr,
-			))
-		} else {
+	replicated := make([]bool, len(targets))
+	errs := make([]error, len(targets))
+	parallelize(r.Workers, len(targets), func(i int) {
+		if targets[i].Name == sourceNamespace {
+			// Don't replicate upon itself
+			return
+		}
+		replicated[i], errs[i] = r.replicateResourceToNamespace(ctx, obj, targets[i])
+	})
+
+	for i, namespace := range targets {
+		if errs[i] != nil {
+			err = multierror.Append(err, errs[i])
+		} else if replicated[i] {
 			replicatedTo = append(replicatedTo, namespace)
-			logger := log.WithField("source", cacheKey)
-			logger.Infof("Replicated %s to: %v", cacheKey, namespace.Name)
 		}
 	}
 
 	return
 }
 
-func (r *GenericReplicator) updateDependents(obj interface{}, dependents map[string]interface{}) error {
+// replicateResourceToNamespace replicates the given object into a single target namespace, unless one of the
+// checks prevents it. It returns whether the object was replicated.
+func (r *GenericReplicator) replicateResourceToNamespace(ctx context.Context, obj interface{}, namespace v1.Namespace) (bool, error) {
+	cacheKey := MustGetKey(obj)
+
+	if r.isUpToDate(obj, namespace.Name) {
+		r.logger(ctx).WithField("source", cacheKey).
+			Debugf("%s %s in %s is already up-to-date", r.Kind, cacheKey, namespace.Name)
+		r.forgetRetries(cacheKey, namespace.Name)
+		replicationOrder.Replicated(r.Kind, cacheKey, namespace.Name)
+		return false, nil
+	}
+
+	if !r.mayReplaceExistingTarget(obj, namespace.Name) {
+		return false, nil
+	}
+
+	if !r.mayReplaceCollidingTarget(obj, namespace) {
+		return false, nil
+	}
+
+	if !r.mayTouchExistingTarget(obj, namespace.Name) {
+		return false, nil
+	}
+
+	if !r.mayUpdateExistingTarget(obj, namespace.Name) {
+		replicationOrder.Replicated(r.Kind, cacheKey, namespace.Name)
+		return false, nil
+	}
+
+	if !r.namespaceHasRequiredResource(obj, namespace) {
+		return false, nil
+	}
+
+	if !r.mayReplicateInOrder(obj, namespace) {
+		return false, nil
+	}
+
+	targetLocation := fmt.Sprintf("%s/%s", namespace.Name, r.ResolveTargetName(MustGetObject(obj), namespace.Name))
+	_, updated, _ := r.cachedTarget(targetLocation)
+
+	_, span := r.startSpan(ctx, "ReplicateObjectTo", attribute.String("replicator.source", cacheKey),
+		attribute.String("replicator.target", targetLocation), attribute.Bool("replicator.update", updated))
+	r.waitForCreation(obj, namespace.Name)
+	err := apiLoadShedder.Do(r.Kind, func() error {
+		return r.UpdateFuncs.ReplicateObjectTo(obj, &namespace)
+	})
+	endSpan(span, err)
+	r.countReplication(obj, err)
+	r.recordReplicationEvents(obj, targetLocation, updated, err)
+	r.recordFailedCreation(obj, targetLocation, updated, err)
+	r.rememberError(obj, targetLocation, err)
+
+	if err != nil {
+		r.logFailure(r.logger(ctx).WithField("source", cacheKey).WithField("target", targetLocation), cacheKey, targetLocation,
+			err, "error while replicating object to namespace")
+		r.requeueReplicationTo(cacheKey, namespace.Name)
+		return false, errors.Wrapf(err, "Failed to replicate %s %s -> %s: %v",
+			r.Kind, cacheKey, namespace.Name, err,
+		)
+	}
+
+	operation := AuditOperationCreate
+	if updated {
+		operation = AuditOperationUpdate
+	}
+	r.Audit(operation, obj, targetLocation, AuditReasonPush)
+
+	r.forgetRetries(cacheKey, namespace.Name)
+	replicationOrder.Replicated(r.Kind, cacheKey, namespace.Name)
+	logger := r.logger(ctx).WithField("source", cacheKey)
+	logger.Infof("Replicated %s to: %v", cacheKey, namespace.Name)
+	return true, nil
+}
+
+func (r *GenericReplicator) updateDependents(ctx context.Context, obj interface{}, dependents []string) error {
 	cacheKey := MustGetKey(obj)
-	logger := log.WithField("kind", r.Kind).WithField("source", cacheKey)
+	logger := r.logger(ctx).WithField("source", cacheKey)
 
-	for dependentKey := range dependents {
+	if !r.isApproved(obj) || !r.withinSizeLimit(obj) {
+		return nil
+	}
+
+	for _, dependentKey := range dependents {
 		logger.Infof("updating dependent %s %s -> %s", r.Kind, cacheKey, dependentKey)
 
 		targetObject, exists, err := r.Store.GetByKey(dependentKey)
@@ -457,7 +966,37 @@ func (r *GenericReplicator) updateDependents(obj interface{}, dependents map[str
 			continue
 		}
 
-		if err := r.UpdateFuncs.ReplicateDataFrom(obj, targetObject); err != nil {
+		if isReplicatedOnce(obj, targetObject) {
+			logger.Debugf("dependent %s %s has already been replicated once", r.Kind, dependentKey)
+			continue
+		}
+
+		if isMergeTarget(targetObject) {
+			err = apiLoadShedder.Do(r.Kind, func() error {
+				return r.resourceAddedMergeFrom(targetObject)
+			})
+			if err == nil {
+				r.forgetFailure(cacheKey, dependentKey)
+			}
+		} else {
+			replicatedVersion := MustGetObject(targetObject).GetAnnotations()[ReplicatedFromVersionAnnotation]
+			_, span := r.startSpan(ctx, "ReplicateDataFrom", attribute.String("replicator.source", cacheKey),
+				attribute.String("replicator.target", dependentKey))
+			err = apiLoadShedder.Do(r.Kind, func() error {
+				return r.UpdateFuncs.ReplicateDataFrom(obj, targetObject)
+			})
+			endSpan(span, err)
+			r.recordPullReplication(obj, targetObject, replicatedVersion, err)
+
+			if err != nil {
+				r.requeueReplicationFrom(cacheKey, dependentKey)
+			} else {
+				r.forgetRetries(cacheKey, dependentKey)
+			}
+		}
+
+		if err != nil {
+			r.logFailure(logger.WithField("target", dependentKey), cacheKey, dependentKey, err, "could not update dependent")
 			return errors.WithStack(err)
 		}
 	}
@@ -485,26 +1024,54 @@ func (r *GenericReplicator) ResourceDeleted(source interface{}) {
 	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
 	logger.Debugf("Deleting %s %s", r.Kind, sourceKey)
 
-	r.ResourceDeletedReplicateTo(source)
-	r.ResourceDeletedReplicateFrom(source)
+	if r.retainsReplicasOf(source) {
+		logger.Infof("retaining replicas of deleted %s %s", r.Kind, sourceKey)
+	} else {
+		r.ResourceDeletedReplicateTo(source)
+		r.ResourceDeletedReplicateFrom(source)
+	}
 
 	r.ReplicateToList.Delete(sourceKey)
-
+	r.ReplicateToSameTenantList.Delete(sourceKey)
+	r.ReplicateToFromConfigMapList.Delete(sourceKey)
+	r.cancelResync(sourceKey)
+	r.cancelDebounce(sourceKey)
+	r.observedChanges.Delete(sourceKey)
+	r.forgetErrors(sourceKey)
+	r.forgetFailedCreations(sourceKey)
+	r.writtenHashes.Delete(sourceKey)
+
+	metricInvalidConfiguration.DeleteLabelValues(r.Kind, sourceKey)
+	metricOversizedSources.DeleteLabelValues(r.Kind, sourceKey)
+	metricFanOutLimitExceeded.DeleteLabelValues(r.Kind, sourceKey)
+	metricLastFailure.DeleteLabelValues(r.Kind, sourceKey)
+	replicationOrder.Forget(r.Kind, sourceKey)
 }
 
 func (r *GenericReplicator) ResourceDeletedReplicateTo(source interface{}) {
 	sourceKey := MustGetKey(source)
 	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
 	objMeta := MustGetObject(source)
-	namespaceList, replicateTo := objMeta.GetAnnotations()[ReplicateTo]
+
+	// namespaces are collected first, so that replicas found by more than one of the checks below are only
+	// deleted once. They are taken from the namespace cache, so that deleting many sources at once doesn't
+	// result in as many requests to the API server.
+	targets := make(map[string]v1.Namespace)
+	addTargets := func(namespaces []v1.Namespace) {
+		for _, namespace := range namespaces {
+			targets[namespace.Name] = namespace
+		}
+	}
+
+	namespaceList, replicateTo, err := r.replicateToPatterns(objMeta)
+	if err != nil {
+		logger.WithError(err).Errorf("Could not resolve namespace patterns: %+v", err)
+	}
 	if replicateTo {
-		filters := strings.Split(namespaceList, ",")
-		list, err := r.Client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			err = errors.Wrapf(err, "Failed to list namespaces: %v", err)
-			logger.WithError(err).Errorf("Could not get namespaces: %+v", err)
-		} else {
-			r.DeleteResources(source, list, filters)
+		for _, namespace := range cachedNamespaces(labels.Everything()) {
+			if MatchNamespacePatterns(namespaceList, namespace.Name) {
+				targets[namespace.Name] = namespace
+			}
 		}
 	}
 
@@ -516,27 +1083,30 @@ func (r *GenericReplicator) ResourceDeletedReplicateTo(source interface{}) {
 			err = errors.Wrapf(err, "Failed parse namespace selector: %v", err)
 			logger.WithError(err).Errorf("Could not get namespaces: %+v", err)
 		} else {
-			var namespaces *v1.NamespaceList
-			namespaces, err = r.Client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: namespaceSelector.String()})
-			if err != nil {
-				err = errors.Wrapf(err, "Failed to list namespaces: %v", err)
-				logger.WithError(err).Errorf("Could not get namespaces: %+v", err)
-			} else {
-				r.DeleteResourceInNamespaces(source, namespaces)
-			}
+			addTargets(cachedNamespaces(namespaceSelector))
 		}
 	}
-}
 
-func (r *GenericReplicator) DeleteResources(source interface{}, list *v1.NamespaceList, filters []string) {
-	for _, namespace := range list.Items {
-		for _, ns := range filters {
-			ns = strings.TrimSpace(ns)
-			if matched, _ := regexp.MatchString(ns, namespace.Name); matched {
-				r.DeleteResource(namespace, source)
-			}
+	// delete replicated resources in namespaces of the same tenant
+	if sameTenant, ok := objMeta.GetAnnotations()[ReplicateToSameTenant]; ok && sameTenant == "true" {
+		selector, err := r.sameTenantSelector(objMeta.GetNamespace())
+		if err != nil {
+			logger.WithError(err).Errorf("Could not determine tenant of source: %+v", err)
+		} else {
+			addTargets(cachedNamespaces(selector))
+		}
+	}
+
+	// delete replicas that are labeled with the source, but are in namespaces that are no longer selected
+	for _, namespace := range r.trackedReplicaNamespaces(objMeta) {
+		if _, ok := targets[namespace]; !ok {
+			targets[namespace] = v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
 		}
 	}
+
+	for _, namespace := range targets {
+		r.DeleteResource(namespace, source)
+	}
 }
 
 // DeleteResourceInNamespaces deletes resources in a list of namespaces acquired by evaluating namespace labels
@@ -556,8 +1126,8 @@ func (r *GenericReplicator) DeleteResource(namespace v1.Namespace, source interf
 		// Don't work upon itself
 		return
 	}
-	targetLocation := fmt.Sprintf("%s/%s", namespace.Name, objMeta.GetName())
-	targetResource, exists, err := r.Store.GetByKey(targetLocation)
+	targetLocation := fmt.Sprintf("%s/%s", namespace.Name, r.ResolveTargetName(objMeta, namespace.Name))
+	targetResource, exists, err := r.LoadTarget(targetLocation)
 	if err != nil {
 		logger.WithError(err).Errorf("Could not get objectMeta %s: %+v", targetLocation, err)
 		return
@@ -565,34 +1135,104 @@ func (r *GenericReplicator) DeleteResource(namespace v1.Namespace, source interf
 	if !exists {
 		return
 	}
+	if otherSource, collides := r.collidingSource(sourceKey, targetLocation); collides {
+		logger.Debugf("Not deleting %s since it is replicated from %s", targetLocation, otherSource)
+		return
+	}
+	if isLegacyReplica(MustGetObject(targetResource)) {
+		logger.Infof("Not deleting %s since it is a legacy replica that has not been adopted", targetLocation)
+		return
+	}
+	if !r.mayTouchExistingTarget(source, namespace.Name) {
+		return
+	}
+	if isRetained(targetResource) {
+		logger.Infof("Not deleting %s since it is marked to be retained", targetLocation)
+		return
+	}
 	if err := r.UpdateFuncs.DeleteReplicatedResource(targetResource); err != nil {
 		logger.WithError(err).Errorf("Could not delete resource %s: %+v", targetLocation, err)
+		return
 	}
+	r.Audit(AuditOperationDelete, source, targetLocation, AuditReasonSourceDeleted)
 }
 
 func (r *GenericReplicator) ResourceDeletedReplicateFrom(source interface{}) {
 	sourceKey := MustGetKey(source)
 
 	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
-	replicas, ok := r.DependencyMap[sourceKey]
-	if !ok {
+	dependents := r.dependentsOf(sourceKey)
+	if len(dependents) == 0 {
 		logger.Debugf("%s %s has no dependents and can be deleted without issues", r.Kind, sourceKey)
 		return
 	}
 
-	for dependentKey := range replicas {
+	for _, dependentKey := range dependents {
 		target, err := r.ObjectFromStore(dependentKey)
 		if err != nil {
 			logger.WithError(err).Warnf("could not load dependent %s %s: %v", r.Kind, dependentKey, err)
 			continue
 		}
+		if isRetained(target) {
+			logger.Infof("not clearing dependent %s %s since it is marked to be retained", r.Kind, dependentKey)
+			continue
+		}
+		if isMergeTarget(target) {
+			// the deleted source is not in the store anymore, so it is left out when merging again
+			if err := r.resourceAddedMergeFrom(target); err != nil {
+				logger.WithError(err).Warnf("could not merge dependent %s %s again", r.Kind, dependentKey)
+			}
+			continue
+		}
 		s, err := r.UpdateFuncs.PatchDeleteDependent(sourceKey, target)
 		if err != nil {
 			logger.WithError(err).Warnf("could not patch dependent %s %s: %v", r.Kind, dependentKey, err)
 			continue
 		}
+		r.Audit(AuditOperationUpdate, sourceKey, dependentKey, AuditReasonSourceDeleted)
 		if err := r.Store.Update(s); err != nil {
 			logger.WithError(err).Errorf("Error updating store for %s %s: %v", r.Kind, MustGetKey(s), err)
 		}
 	}
 }
+
+// isLegacyReplica checks if the object has been created by the legacy replication engine and has not been
+// adopted into this controller's bookkeeping yet.
+func isLegacyReplica(object metav1.Object) bool {
+	annotations := object.GetAnnotations()
+	_, legacy := annotations[LegacyReplicatedByAnnotation]
+	_, adopted := annotations[ReplicatedFromVersionAnnotation]
+	return legacy && !adopted
+}
+
+// mayReplaceExistingTarget checks if the source may be replicated into an existing target in the given
+// namespace. Legacy replicas are only adopted if adoption is enabled and they were created from the same source.
+// Once adopted, the target carries the regular bookkeeping annotations and is treated like any other replica.
+func (r *GenericReplicator) mayReplaceExistingTarget(source interface{}, namespace string) bool {
+	sourceKey := MustGetKey(source)
+	targetLocation := fmt.Sprintf("%s/%s", namespace, r.ResolveTargetName(MustGetObject(source), namespace))
+	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey).WithField("target", targetLocation)
+
+	target, exists, err := r.cachedTarget(targetLocation)
+	if err != nil || !exists {
+		return true
+	}
+
+	targetObject := MustGetObject(target)
+	if !isLegacyReplica(targetObject) {
+		return true
+	}
+
+	if !r.AdoptLegacyReplicas {
+		logger.Warnf("%s is a legacy replica; not replicating into it since adoption is disabled", targetLocation)
+		return false
+	}
+
+	if replicatedBy := targetObject.GetAnnotations()[LegacyReplicatedByAnnotation]; replicatedBy != sourceKey {
+		logger.Warnf("%s is a legacy replica of %s; not adopting it", targetLocation, replicatedBy)
+		return false
+	}
+
+	logger.Infof("adopting legacy replica %s", targetLocation)
+	return true
+}
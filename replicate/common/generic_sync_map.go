@@ -31,6 +31,14 @@ func (gm *GenericMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool)
 	return actual, loaded
 }
 
+func (gm *GenericMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	rawValue, loaded := gm.m.LoadAndDelete(key)
+	if loaded {
+		value = rawValue.(V)
+	}
+	return value, loaded
+}
+
 func (gm *GenericMap[K, V]) Delete(key K) {
 	gm.m.Delete(key)
 }
@@ -42,3 +50,13 @@ func (gm *GenericMap[K, V]) Range(f func(key K, value V) bool) {
 		return f(key, value)
 	})
 }
+
+// Len counts the entries of the map. Since the map may be modified concurrently, the result is only a snapshot.
+func (gm *GenericMap[K, V]) Len() int {
+	n := 0
+	gm.m.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
@@ -0,0 +1,181 @@
+package common
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// ReplicationModePush marks replicas created because of an annotation of the source, like replicate-to
+	ReplicationModePush = "push"
+
+	// ReplicationModePull marks targets that are replicated from the source with the replicate-from annotation
+	ReplicationModePull = "pull"
+
+	// ReplicationModeMerge marks targets that merge the source with others with the merge-from annotation
+	ReplicationModeMerge = "merge"
+)
+
+// replicationError is the error of the latest failed replication into a target
+type replicationError struct {
+	source string
+	err    string
+	time   time.Time
+}
+
+// ReplicationTarget describes a target that a source is replicated into
+type ReplicationTarget struct {
+	Target            string     `json:"target"`
+	Mode              string     `json:"mode"`
+	ReplicatedVersion string     `json:"replicatedVersion,omitempty"`
+	LastError         string     `json:"lastError,omitempty"`
+	LastErrorTime     *time.Time `json:"lastErrorTime,omitempty"`
+}
+
+// ReplicationSource describes a source and the targets it is replicated into. Annotations lists the push
+// annotations by which the source selects its target namespaces. Missing is set for sources that targets pull from,
+// but that don't exist.
+type ReplicationSource struct {
+	Kind        string              `json:"kind"`
+	Source      string              `json:"source"`
+	Version     string              `json:"version,omitempty"`
+	Missing     bool                `json:"missing,omitempty"`
+	Annotations []string            `json:"annotations,omitempty"`
+	Targets     []ReplicationTarget `json:"targets"`
+}
+
+// GraphReporter is implemented by replicators that can list their sources and the targets they are replicated into
+type GraphReporter interface {
+	Replications() []ReplicationSource
+}
+
+// rememberError remembers the error of a failed replication from the source into the target at the given location
+// for Replications, or forgets the previous one after a successful replication
+func (r *GenericReplicator) rememberError(source interface{}, targetLocation string, err error) {
+	if err == nil {
+		r.lastErrors.Delete(targetLocation)
+		r.forgetFailure(MustGetKey(source), targetLocation)
+		return
+	}
+	r.lastErrors.Store(targetLocation, replicationError{source: MustGetKey(source), err: err.Error(), time: time.Now().UTC()})
+}
+
+// forgetErrors forgets the errors of replications from the deleted source with the given key
+func (r *GenericReplicator) forgetErrors(sourceKey string) {
+	r.lastErrors.Range(func(targetLocation string, e replicationError) bool {
+		if e.source == sourceKey {
+			r.lastErrors.Delete(targetLocation)
+		}
+		return true
+	})
+}
+
+// Replications lists the sources that are replicated in push mode or pulled by a target, together with their
+// cached targets and the errors of their latest failed replications. Sources and targets are sorted by key.
+func (r *GenericReplicator) Replications() []ReplicationSource {
+	sources := make(map[string]*ReplicationSource)
+	source := func(sourceKey string) *ReplicationSource {
+		if s, ok := sources[sourceKey]; ok {
+			return s
+		}
+		s := &ReplicationSource{Kind: r.Kind, Source: sourceKey, Targets: []ReplicationTarget{}}
+		sources[sourceKey] = s
+		return s
+	}
+
+	addPushSource := func(annotation string) func(string) {
+		return func(sourceKey string) {
+			s := source(sourceKey)
+			s.Annotations = append(s.Annotations, annotation)
+		}
+	}
+	rangeKeys(&r.ReplicateToList, addPushSource(ReplicateTo))
+	rangeKeys(&r.ReplicateToMatchingList, addPushSource(ReplicateToMatching))
+	rangeKeys(&r.ReplicateToSameTenantList, addPushSource(ReplicateToSameTenant))
+	rangeKeys(&r.ReplicateToFromConfigMapList, addPushSource(ReplicateToFromConfigMap))
+
+	for _, obj := range r.Store.List() {
+		sourceKeys, _ := dependencyIndexFunc(obj)
+		for _, sourceKey := range sourceKeys {
+			source(sourceKey)
+		}
+	}
+
+	r.lastErrors.Range(func(_ string, e replicationError) bool {
+		source(e.source)
+		return true
+	})
+
+	result := make([]ReplicationSource, 0, len(sources))
+	for sourceKey, s := range sources {
+		if obj, exists, err := r.Store.GetByKey(sourceKey); err == nil && exists {
+			s.Version = MustGetObject(obj).GetResourceVersion()
+		} else {
+			s.Missing = true
+		}
+		s.Targets = r.replicationTargets(sourceKey)
+		sort.Strings(s.Annotations)
+		result = append(result, *s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Source < result[j].Source
+	})
+	return result
+}
+
+// replicationTargets lists the cached replicas and dependents of the source with the given key, as well as the
+// targets into which it could not be replicated
+func (r *GenericReplicator) replicationTargets(sourceKey string) []ReplicationTarget {
+	targets := make(map[string]*ReplicationTarget)
+
+	for _, replica := range r.replicasOf(sourceKey) {
+		targets[MustGetKey(replica)] = &ReplicationTarget{
+			Target:            MustGetKey(replica),
+			Mode:              ReplicationModePush,
+			ReplicatedVersion: MustGetObject(replica).GetAnnotations()[ReplicatedFromVersionAnnotation],
+		}
+	}
+
+	for _, dependent := range byIndex(r.Store, DependencyIndex, sourceKey) {
+		annotations := MustGetObject(dependent).GetAnnotations()
+		target := &ReplicationTarget{Target: MustGetKey(dependent), Mode: ReplicationModeMerge}
+		if annotations[ReplicateFromAnnotation] == sourceKey {
+			target.Mode = ReplicationModePull
+			target.ReplicatedVersion = annotations[ReplicatedFromVersionAnnotation]
+		}
+		targets[target.Target] = target
+	}
+
+	r.lastErrors.Range(func(targetLocation string, e replicationError) bool {
+		if e.source != sourceKey {
+			return true
+		}
+		target, ok := targets[targetLocation]
+		if !ok {
+			target = &ReplicationTarget{Target: targetLocation, Mode: ReplicationModePush}
+			targets[targetLocation] = target
+		}
+		errorTime := e.time
+		target.LastError = e.err
+		target.LastErrorTime = &errorTime
+		return true
+	})
+
+	result := make([]ReplicationTarget, 0, len(targets))
+	for _, target := range targets {
+		result = append(result, *target)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Target < result[j].Target
+	})
+	return result
+}
+
+// rangeKeys calls f with every key of the map
+func rangeKeys[V any](m *GenericMap[string, V], f func(string)) {
+	m.Range(func(key string, _ V) bool {
+		f(key)
+		return true
+	})
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestReplications(t *testing.T) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, Indexers)
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default", ResourceVersion: "42"}}
+	for _, obj := range []interface{}{
+		source,
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "source",
+			Namespace:   "team-a",
+			Annotations: map[string]string{ReplicatedSourceAnnotation: "default/source", ReplicatedFromVersionAnnotation: "41"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "copy",
+			Namespace:   "team-b",
+			Annotations: map[string]string{ReplicateFromAnnotation: "default/source", ReplicatedFromVersionAnnotation: "42"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "merged",
+			Namespace:   "team-b",
+			Annotations: map[string]string{MergeFrom: "default/source,default/missing"},
+		}},
+	} {
+		require.NoError(t, store.Add(obj))
+	}
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: store}
+	r.ReplicateToList.Store("default/source", struct{}{})
+	r.rememberError(source, "team-c/source", errors.New("forbidden"))
+
+	replications := r.Replications()
+	require.Len(t, replications, 2)
+
+	assert.Equal(t, ReplicationSource{Kind: "Secret", Source: "default/missing", Missing: true, Targets: []ReplicationTarget{
+		{Target: "team-b/merged", Mode: ReplicationModeMerge},
+	}}, replications[0])
+
+	assert.Equal(t, "default/source", replications[1].Source)
+	assert.Equal(t, "42", replications[1].Version)
+	assert.Equal(t, []string{ReplicateTo}, replications[1].Annotations)
+	targets := replications[1].Targets
+	require.Len(t, targets, 4)
+	assert.Equal(t, ReplicationTarget{Target: "team-a/source", Mode: ReplicationModePush, ReplicatedVersion: "41"}, targets[0])
+	assert.Equal(t, ReplicationTarget{Target: "team-b/copy", Mode: ReplicationModePull, ReplicatedVersion: "42"}, targets[1])
+	assert.Equal(t, ReplicationTarget{Target: "team-b/merged", Mode: ReplicationModeMerge}, targets[2])
+	assert.Equal(t, "team-c/source", targets[3].Target)
+	assert.Equal(t, "forbidden", targets[3].LastError)
+	assert.NotNil(t, targets[3].LastErrorTime)
+
+	// a successful replication clears the error
+	r.rememberError(source, "team-c/source", nil)
+	assert.Len(t, r.Replications()[1].Targets, 3)
+
+	r.rememberError(source, "team-c/source", errors.New("forbidden"))
+	r.forgetErrors("default/source")
+	assert.Len(t, r.Replications()[1].Targets, 3)
+}
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"slices"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// DependencyIndex indexes pull targets by the keys of the sources they are replicated or merged from
+	DependencyIndex = "dependencies"
+
+	// ReplicaIndex indexes replicas created in push mode by the key of their source
+	ReplicaIndex = "replicas"
+)
+
+// Indexers are the indexers of the informers of all replicators
+var Indexers = cache.Indexers{
+	DependencyIndex: dependencyIndexFunc,
+	ReplicaIndex:    replicaIndexFunc,
+}
+
+// dependencyIndexFunc returns the keys of the sources that the object is replicated or merged from
+func dependencyIndexFunc(obj interface{}) ([]string, error) {
+	annotations := MustGetObject(obj).GetAnnotations()
+
+	var sources []string
+	if source, ok := annotations[ReplicateFromAnnotation]; ok {
+		sources = append(sources, source)
+	}
+	if value, ok := annotations[MergeFrom]; ok {
+		if merged, err := ParseMergeSources(value); err == nil {
+			sources = append(sources, merged...)
+		}
+	}
+	return sources, nil
+}
+
+// replicaIndexFunc returns the key of the source that the object is a replica of
+func replicaIndexFunc(obj interface{}) ([]string, error) {
+	if source, ok := MustGetObject(obj).GetAnnotations()[ReplicatedSourceAnnotation]; ok {
+		return []string{source}, nil
+	}
+	return nil, nil
+}
+
+// byIndex returns the objects in the store whose index values include the given one. Stores without the index
+// are searched linearly.
+func byIndex(store cache.Store, name string, value string) []interface{} {
+	if indexer, ok := store.(cache.Indexer); ok {
+		if objects, err := indexer.ByIndex(name, value); err == nil {
+			return objects
+		}
+	}
+
+	var result []interface{}
+	for _, obj := range store.List() {
+		if values, err := Indexers[name](obj); err == nil && slices.Contains(values, value) {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
+
+// dependentsOf returns the keys of the objects that are replicated or merged from the source with the given key
+func (r *GenericReplicator) dependentsOf(sourceKey string) []string {
+	objects := byIndex(r.Store, DependencyIndex, sourceKey)
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, MustGetKey(obj))
+	}
+	return keys
+}
+
+// replicasOf returns the cached replicas of the source with the given key
+func (r *GenericReplicator) replicasOf(sourceKey string) []interface{} {
+	replicas := byIndex(r.TargetStore, ReplicaIndex, sourceKey)
+	if r.targetController != nil {
+		replicas = append(replicas, byIndex(r.Store, ReplicaIndex, sourceKey)...)
+	}
+	return replicas
+}
+
+// dependencyCounts returns the number of sources that have dependents and the number of dependents
+func (r *GenericReplicator) dependencyCounts() (int, int) {
+	dependencies := make(map[string]struct{})
+	dependents := 0
+	for _, obj := range r.Store.List() {
+		sources, _ := dependencyIndexFunc(obj)
+		for _, source := range sources {
+			dependencies[source] = struct{}{}
+		}
+		if len(sources) > 0 {
+			dependents++
+		}
+	}
+	return len(dependencies), dependents
+}
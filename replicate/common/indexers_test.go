@@ -0,0 +1,51 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestDependentsOf(t *testing.T) {
+	objects := []interface{}{
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "copy",
+			Namespace:   "target",
+			Annotations: map[string]string{ReplicateFromAnnotation: "default/source"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "merged",
+			Namespace:   "target",
+			Annotations: map[string]string{MergeFrom: "default/source,default/other"},
+		}},
+	}
+
+	for name, store := range map[string]cache.Store{
+		"indexer": cache.NewIndexer(cache.MetaNamespaceKeyFunc, Indexers),
+		"store":   cache.NewStore(cache.MetaNamespaceKeyFunc),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, obj := range objects {
+				require.NoError(t, store.Add(obj))
+			}
+			r := &GenericReplicator{Store: store, TargetStore: store}
+
+			assert.ElementsMatch(t, []string{"target/copy", "target/merged"}, r.dependentsOf("default/source"))
+			assert.Equal(t, []string{"target/merged"}, r.dependentsOf("default/other"))
+			assert.Empty(t, r.dependentsOf("target/copy"))
+
+			dependencies, dependents := r.dependencyCounts()
+			assert.Equal(t, 2, dependencies)
+			assert.Equal(t, 2, dependents)
+
+			// removing the annotation removes the dependency
+			require.NoError(t, store.Update(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "copy", Namespace: "target"}}))
+			assert.Equal(t, []string{"target/merged"}, r.dependentsOf("default/source"))
+		})
+	}
+}
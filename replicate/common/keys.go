@@ -0,0 +1,95 @@
+package common
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IsKeyReplicated checks if a data key of the source should be copied to its replicas. If the source has a
+// ReplicateKeys annotation, only the keys listed there are replicated; otherwise, all keys are. Keys matching one
+// of the glob patterns in the ReplicateKeysExclude annotation are never replicated.
+func IsKeyReplicated(source metav1.Object, key string) bool {
+	annotations := source.GetAnnotations()
+
+	if patterns, ok := annotations[ReplicateKeysExclude]; ok {
+		for _, pattern := range strings.Split(patterns, ",") {
+			if matched, _ := path.Match(strings.TrimSpace(pattern), key); matched {
+				return false
+			}
+		}
+	}
+
+	keyList, ok := annotations[ReplicateKeys]
+	if !ok {
+		return true
+	}
+
+	for _, k := range strings.Split(keyList, ",") {
+		if strings.TrimSpace(k) == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PulledKey returns the key under which a data key of the source is stored in a target that pulls the source via
+// the ReplicateFromAnnotation, and whether the key is replicated into the target at all. The ReplicateKeys,
+// ReplicateKeysExclude and ReplicateKeyMap annotations of the target are applied to the keys as named by the
+// source, so that a target can project a large source into a small, differently keyed object.
+func PulledKey(source metav1.Object, target metav1.Object, key string) (string, bool) {
+	if !IsKeyReplicated(source, key) {
+		return "", false
+	}
+
+	key = TargetKey(source, key)
+	if !IsKeyReplicated(target, key) {
+		return "", false
+	}
+
+	return TargetKey(target, key), true
+}
+
+// ParseKeyMap parses a key mapping of the form "source1=target1,source2=target2"
+func ParseKeyMap(keyMap string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, entry := range strings.Split(keyMap, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(entry, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, errors.Errorf("invalid key mapping %q: expected '<source key>=<target key>'", entry)
+		}
+
+		result[from] = to
+	}
+
+	return result, nil
+}
+
+// TargetKey returns the key under which a data key of the source is stored in its replicas, according to the
+// ReplicateKeyMap annotation of the source. Keys without a mapping keep their name.
+func TargetKey(source metav1.Object, key string) string {
+	keyMap, ok := source.GetAnnotations()[ReplicateKeyMap]
+	if !ok {
+		return key
+	}
+
+	mapping, err := ParseKeyMap(keyMap)
+	if err != nil {
+		return key
+	}
+
+	if targetKey, ok := mapping[key]; ok {
+		return targetKey
+	}
+
+	return key
+}
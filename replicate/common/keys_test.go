@@ -0,0 +1,67 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTargetKey(t *testing.T) {
+	source := &metav1.ObjectMeta{Name: "source"}
+	assert.Equal(t, "password", TargetKey(source, "password"))
+
+	source.Annotations = map[string]string{ReplicateKeyMap: "password=DB_PASSWORD, username = DB_USER"}
+	assert.Equal(t, "DB_PASSWORD", TargetKey(source, "password"))
+	assert.Equal(t, "DB_USER", TargetKey(source, "username"))
+	assert.Equal(t, "host", TargetKey(source, "host"))
+
+	_, err := ParseKeyMap("password")
+	assert.Error(t, err)
+}
+
+func TestIsKeyReplicated(t *testing.T) {
+	source := &metav1.ObjectMeta{Name: "source"}
+	assert.True(t, IsKeyReplicated(source, "tls.key"))
+
+	source.Annotations = map[string]string{ReplicateKeys: "tls.crt, ca.crt"}
+	assert.True(t, IsKeyReplicated(source, "tls.crt"))
+	assert.True(t, IsKeyReplicated(source, "ca.crt"))
+	assert.False(t, IsKeyReplicated(source, "tls.key"))
+
+	source.Annotations = map[string]string{ReplicateKeysExclude: "*.key, id_*"}
+	assert.True(t, IsKeyReplicated(source, "tls.crt"))
+	assert.False(t, IsKeyReplicated(source, "tls.key"))
+	assert.False(t, IsKeyReplicated(source, "id_rsa"))
+
+	source.Annotations[ReplicateKeys] = "tls.crt,tls.key"
+	assert.True(t, IsKeyReplicated(source, "tls.crt"))
+	assert.False(t, IsKeyReplicated(source, "tls.key"))
+}
+
+func TestPulledKey(t *testing.T) {
+	source := &metav1.ObjectMeta{Name: "source", Annotations: map[string]string{
+		ReplicateKeysExclude: "*.key",
+		ReplicateKeyMap:      "ca.crt=ca-bundle.crt",
+	}}
+	target := &metav1.ObjectMeta{Name: "target"}
+
+	key, ok := PulledKey(source, target, "tls.crt")
+	assert.True(t, ok)
+	assert.Equal(t, "tls.crt", key)
+
+	_, ok = PulledKey(source, target, "tls.key")
+	assert.False(t, ok)
+
+	target.Annotations = map[string]string{
+		ReplicateKeys:   "ca-bundle.crt",
+		ReplicateKeyMap: "ca-bundle.crt=root-ca.pem",
+	}
+
+	key, ok = PulledKey(source, target, "ca.crt")
+	assert.True(t, ok)
+	assert.Equal(t, "root-ca.pem", key)
+
+	_, ok = PulledKey(source, target, "tls.crt")
+	assert.False(t, ok)
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ParseAddedLabels parses the labels of the ReplicateAddLabels annotation, which are given in the form
+// "key1=value1,key2=value2"
+func ParseAddedLabels(value string) (map[string]string, error) {
+	added, err := labels.ConvertSelectorToLabelsMap(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid labels %q: expected '<key>=<value>,...'", value)
+	}
+	return added, nil
+}
+
+// StripLabelsWithPrefixes removes the labels from a replica whose keys start with one of the prefixes listed in the
+// StripLabelPrefixes annotation of the source, e.g. "helm.sh/,argocd.argoproj.io/"
+func StripLabelsWithPrefixes(source metav1.Object, target map[string]string) {
+	value, ok := source.GetAnnotations()[StripLabelPrefixes]
+	if !ok {
+		return
+	}
+
+	for _, prefix := range strings.Split(value, ",") {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		for key := range target {
+			if strings.HasPrefix(key, prefix) {
+				delete(target, key)
+			}
+		}
+	}
+}
+
+// AddLabels adds the labels listed in the ReplicateAddLabels annotation of the source to the labels of a replica.
+// Added labels take precedence over labels copied from the source.
+func AddLabels(source metav1.Object, target map[string]string) {
+	value, ok := source.GetAnnotations()[ReplicateAddLabels]
+	if !ok {
+		return
+	}
+
+	added, err := ParseAddedLabels(value)
+	if err != nil {
+		return
+	}
+
+	for key, value := range added {
+		target[key] = value
+	}
+}
+
+// trackingLabels returns the labels that identify the source of a replica. Names that are not valid label values
+// (i.e. that are longer than 63 characters) are not included; such replicas can only be identified by their
+// ReplicatedSourceAnnotation.
+func trackingLabels(source metav1.Object) map[string]string {
+	result := make(map[string]string)
+	if source.GetNamespace() != "" {
+		result[ReplicatedByNamespaceLabel] = source.GetNamespace()
+	}
+	if len(validation.IsValidLabelValue(source.GetName())) == 0 {
+		result[ReplicatedByNameLabel] = source.GetName()
+	}
+	return result
+}
+
+// SetTrackingLabels labels a replica with the namespace and name of its source, so that all replicas of a source
+// can be listed with a label selector
+func SetTrackingLabels(source metav1.Object, target map[string]string) {
+	delete(target, ReplicatedByNamespaceLabel)
+	delete(target, ReplicatedByNameLabel)
+
+	for key, value := range trackingLabels(source) {
+		target[key] = value
+	}
+}
+
+// SetProvenanceAnnotations records the source of a pushed replica and the identity of the replicator that
+// created it in the replica's annotations
+func (r *GenericReplicator) SetProvenanceAnnotations(source metav1.Object, target map[string]string) {
+	target[ReplicatedSourceAnnotation] = MustGetKey(source)
+	if r.ControllerIdentity != "" {
+		target[ReplicatedByControllerAnnotation] = r.ControllerIdentity
+	} else {
+		delete(target, ReplicatedByControllerAnnotation)
+	}
+}
+
+// trackedReplicaNamespaces returns the namespaces of all cached replicas that are labeled with the given source
+func (r *GenericReplicator) trackedReplicaNamespaces(source metav1.Object) []string {
+	selector := labels.SelectorFromSet(trackingLabels(source))
+	if selector.Empty() {
+		return nil
+	}
+
+	sourceKey := MustGetKey(source)
+
+	var namespaces []string
+	for _, obj := range r.replicasOf(sourceKey) {
+		target := MustGetObject(obj)
+		if target.GetNamespace() == source.GetNamespace() {
+			continue
+		}
+		if selector.Matches(labels.Set(target.GetLabels())) {
+			namespaces = append(namespaces, target.GetNamespace())
+		}
+	}
+
+	return namespaces
+}
@@ -0,0 +1,109 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestAddLabels(t *testing.T) {
+	source := &metav1.ObjectMeta{
+		Annotations: map[string]string{
+			ReplicateAddLabels: "managed-by=replicator, tier=shared",
+		},
+	}
+	target := map[string]string{"app": "web", "tier": "frontend"}
+
+	AddLabels(source, target)
+
+	assert.Equal(t, map[string]string{"app": "web", "tier": "shared", "managed-by": "replicator"}, target)
+}
+
+func TestAddLabelsInvalid(t *testing.T) {
+	source := &metav1.ObjectMeta{
+		Annotations: map[string]string{
+			ReplicateAddLabels: "managed-by",
+		},
+	}
+	target := map[string]string{"app": "web"}
+
+	AddLabels(source, target)
+
+	assert.Equal(t, map[string]string{"app": "web"}, target)
+
+	_, err := ParseAddedLabels("tier=shared,invalid key=foo")
+	assert.Error(t, err)
+}
+
+func TestStripLabelsWithPrefixes(t *testing.T) {
+	source := &metav1.ObjectMeta{
+		Annotations: map[string]string{
+			StripLabelPrefixes: "helm.sh/, argocd.argoproj.io/,",
+		},
+	}
+	target := map[string]string{"app": "web", "helm.sh/chart": "web-1.0.0", "argocd.argoproj.io/instance": "web"}
+
+	StripLabelsWithPrefixes(source, target)
+
+	assert.Equal(t, map[string]string{"app": "web"}, target)
+}
+
+func TestSetTrackingLabels(t *testing.T) {
+	source := &metav1.ObjectMeta{Namespace: "source", Name: "credentials"}
+	target := map[string]string{"app": "web", ReplicatedByNameLabel: "other"}
+
+	SetTrackingLabels(source, target)
+	assert.Equal(t, map[string]string{
+		"app":                      "web",
+		ReplicatedByNamespaceLabel: "source",
+		ReplicatedByNameLabel:      "credentials",
+	}, target)
+
+	source.Name = strings.Repeat("a", 64)
+	SetTrackingLabels(source, target)
+	assert.Equal(t, map[string]string{
+		"app":                      "web",
+		ReplicatedByNamespaceLabel: "source",
+	}, target)
+}
+
+func TestTrackedReplicaNamespaces(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "source", Name: "credentials"}}
+
+	replica := func(namespace string, sourceKey string) *v1.Secret {
+		secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        "credentials",
+			Labels:      map[string]string{},
+			Annotations: map[string]string{ReplicatedSourceAnnotation: sourceKey},
+		}}
+		SetTrackingLabels(source, secret.Labels)
+		return secret
+	}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(source))
+	assert.NoError(t, store.Add(replica("a", "source/credentials")))
+	assert.NoError(t, store.Add(replica("b", "other/credentials")))
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "c", Name: "credentials"}}))
+
+	repl := GenericReplicator{TargetStore: store}
+	assert.Equal(t, []string{"a"}, repl.trackedReplicaNamespaces(source))
+}
+
+func TestSetProvenanceAnnotations(t *testing.T) {
+	source := &metav1.ObjectMeta{Namespace: "infra", Name: "registry"}
+	target := map[string]string{ReplicatedByControllerAnnotation: "other"}
+
+	r := &GenericReplicator{}
+	r.SetProvenanceAnnotations(source, target)
+	assert.Equal(t, map[string]string{ReplicatedSourceAnnotation: "infra/registry"}, target)
+
+	r.ControllerIdentity = "replicator-prod"
+	r.SetProvenanceAnnotations(source, target)
+	assert.Equal(t, "replicator-prod", target[ReplicatedByControllerAnnotation])
+}
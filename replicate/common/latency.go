@@ -0,0 +1,29 @@
+package common
+
+import (
+	"time"
+)
+
+// observeChange remembers when a change of the given source was observed, so that the time until it has been
+// replicated can be measured. If an earlier change has not been replicated yet (e.g. while updates are debounced),
+// the time of the earlier change is kept. Objects listed before the informers have synced are not considered changed.
+func (r *GenericReplicator) observeChange(obj interface{}) {
+	if !r.hasSynced() {
+		return
+	}
+	r.observedChanges.LoadOrStore(MustGetKey(obj), time.Now())
+}
+
+// observeReplicationLatency records the time since the last unreplicated change of the source with the given key was
+// observed, once it has been replicated to all of its targets
+func (r *GenericReplicator) observeReplicationLatency(sourceKey string) {
+	if observed, ok := r.observedChanges.LoadAndDelete(sourceKey); ok {
+		metricReplicationLatency.WithLabelValues(r.Kind).Observe(time.Since(observed).Seconds())
+	}
+}
+
+// resourceCreated handles watched objects that have been created
+func (r *GenericReplicator) resourceCreated(obj interface{}) {
+	r.observeChange(obj)
+	r.ResourceAdded(obj)
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReplicationLatency(t *testing.T) {
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "LatencyTest"}}
+	old := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "source", ResourceVersion: "1"}}
+	updated := old.DeepCopy()
+	updated.ResourceVersion = "2"
+
+	r.observeChange(updated)
+	first, ok := r.observedChanges.Load("source/shared")
+	assert.True(t, ok)
+
+	// a further change before the first one has been replicated doesn't reset the observation
+	r.observeChange(updated)
+	second, _ := r.observedChanges.Load("source/shared")
+	assert.Equal(t, first, second)
+
+	r.observeReplicationLatency("source/shared")
+	_, ok = r.observedChanges.Load("source/shared")
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), latencySamples(t, "LatencyTest"))
+
+	// replicating the source again without a change (e.g. on resync) is not observed
+	r.observeReplicationLatency("source/shared")
+	assert.Equal(t, uint64(1), latencySamples(t, "LatencyTest"))
+}
+
+// latencySamples returns the number of observations of the replication latency of the given kind
+func latencySamples(t *testing.T, kind string) uint64 {
+	var metric dto.Metric
+	require.NoError(t, metricReplicationLatency.WithLabelValues(kind).(prometheus.Metric).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// loadSheddingThreshold is the number of consecutive throttled requests after which writes are slowed down
+	loadSheddingThreshold = 3
+	loadSheddingMinDelay  = 100 * time.Millisecond
+	loadSheddingMaxDelay  = 10 * time.Second
+
+	// loadSheddingAttempts is the number of times a throttled write request is sent before it is given up
+	loadSheddingAttempts = 3
+)
+
+// apiLoadShedder is shared by all replicators, since they all talk to the same API server
+var apiLoadShedder = &LoadShedder{}
+
+// LoadShedder slows down writes to the API server while it is responding with "429 Too Many Requests".
+// Each sustained throttling response doubles the delay between writes; each successful request halves it.
+// A Retry-After sent by the API server (e.g. by API Priority and Fairness) pauses all writes until it has passed.
+type LoadShedder struct {
+	mu          sync.Mutex
+	throttled   int
+	delay       time.Duration
+	pausedUntil time.Time
+
+	// pacing is held while waiting, so that the writes of concurrent workers are spaced out instead of being
+	// sent in bursts
+	pacing sync.Mutex
+}
+
+// pause returns how long the next write has to wait
+func (l *LoadShedder) pause() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return max(l.delay, time.Until(l.pausedUntil))
+}
+
+// Wait blocks for the current load shedding delay
+func (l *LoadShedder) Wait() {
+	if l.pause() <= 0 {
+		return
+	}
+
+	l.pacing.Lock()
+	defer l.pacing.Unlock()
+
+	if pause := l.pause(); pause > 0 {
+		time.Sleep(pause)
+	}
+}
+
+// Do sends a write request once the load shedding delay has passed. Requests that are throttled by the API
+// server are sent again after waiting, up to loadSheddingAttempts times.
+func (l *LoadShedder) Do(kind string, write func() error) error {
+	for attempt := 1; ; attempt++ {
+		l.Wait()
+		err := write()
+		l.Observe(kind, err)
+
+		if err == nil || !apierrors.IsTooManyRequests(err) || attempt >= loadSheddingAttempts {
+			return err
+		}
+		if l.pause() <= 0 {
+			time.Sleep(time.Duration(attempt) * loadSheddingMinDelay)
+		}
+	}
+}
+
+// Observe adjusts the load shedding delay according to the result of an API request
+func (l *LoadShedder) Observe(kind string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err != nil && apierrors.IsTooManyRequests(err) {
+		metricThrottledRequests.WithLabelValues(kind).Inc()
+
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			if until := time.Now().Add(time.Duration(seconds) * time.Second); until.After(l.pausedUntil) {
+				log.Warnf("API server asked to retry after %ds; pausing replication", seconds)
+				l.pausedUntil = until
+			}
+		}
+
+		l.throttled++
+		if l.throttled < loadSheddingThreshold {
+			return
+		}
+
+		if l.delay == 0 {
+			log.Warnf("API server is throttling requests; slowing down replication")
+			l.delay = loadSheddingMinDelay
+		} else if l.delay < loadSheddingMaxDelay {
+			l.delay = min(2*l.delay, loadSheddingMaxDelay)
+		}
+	} else {
+		l.throttled = 0
+
+		if l.delay == 0 {
+			return
+		}
+
+		l.delay /= 2
+		if l.delay < loadSheddingMinDelay {
+			log.Infof("API server is no longer throttling requests; resuming replication at full speed")
+			l.delay = 0
+		}
+	}
+
+	metricLoadSheddingDelay.Set(l.delay.Seconds())
+	if l.delay > 0 {
+		metricLoadSheddingActive.Set(1)
+	} else {
+		metricLoadSheddingActive.Set(0)
+	}
+}
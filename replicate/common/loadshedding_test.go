@@ -0,0 +1,72 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestLoadShedderSlowsDownOnSustainedThrottling(t *testing.T) {
+	shedder := LoadShedder{}
+	throttled := errors.Wrap(apierrors.NewTooManyRequests("slow down", 1), "Failed to update secret")
+
+	for i := 0; i < loadSheddingThreshold-1; i++ {
+		shedder.Observe("Secret", throttled)
+	}
+	assert.Zero(t, shedder.delay, "single throttled requests should not trigger load shedding")
+
+	shedder.Observe("Secret", throttled)
+	assert.Equal(t, loadSheddingMinDelay, shedder.delay)
+
+	shedder.Observe("Secret", throttled)
+	assert.Equal(t, 2*loadSheddingMinDelay, shedder.delay)
+
+	shedder.Observe("Secret", nil)
+	assert.Equal(t, loadSheddingMinDelay, shedder.delay)
+
+	shedder.Observe("Secret", nil)
+	assert.Zero(t, shedder.delay)
+}
+
+func TestLoadShedderIgnoresOtherErrors(t *testing.T) {
+	shedder := LoadShedder{}
+
+	for i := 0; i < 2*loadSheddingThreshold; i++ {
+		shedder.Observe("Secret", errors.New("connection refused"))
+	}
+	assert.Zero(t, shedder.delay)
+}
+
+func TestLoadShedderPausesForRetryAfter(t *testing.T) {
+	shedder := LoadShedder{}
+	shedder.Observe("Secret", errors.Wrap(apierrors.NewTooManyRequests("slow down", 2), "Failed to update secret"))
+
+	assert.Zero(t, shedder.delay)
+	assert.Greater(t, shedder.pause(), time.Second)
+}
+
+func TestLoadShedderRetriesThrottledWrites(t *testing.T) {
+	shedder := LoadShedder{}
+
+	attempts := 0
+	err := shedder.Do("Secret", func() error {
+		attempts++
+		if attempts < 2 {
+			return apierrors.NewTooManyRequests("slow down", 0)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	err = shedder.Do("Secret", func() error {
+		attempts++
+		return errors.New("connection refused")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,43 @@
+package common
+
+import "sync"
+
+// keyLocks serializes the processing of objects with the same key, which may be triggered concurrently by the
+// informer, the timers of debounced updates, per-source resyncs and retries. Its zero value is ready to use.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock is the lock of a single key, together with the number of goroutines holding or waiting for it
+type keyLock struct {
+	sync.Mutex
+	users int
+}
+
+// lock blocks until the lock of the given key is acquired and returns the function that releases it
+func (l *keyLocks) lock(key string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &keyLock{}
+		l.locks[key] = lock
+	}
+	lock.users++
+	l.mu.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if lock.users--; lock.users == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
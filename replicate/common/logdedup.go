@@ -0,0 +1,111 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// failureLogEntry is the latest failure logged for a pair of source and target
+type failureLogEntry struct {
+	message    string
+	logged     time.Time
+	suppressed int
+	logger     *log.Entry
+}
+
+// failureLog deduplicates the warnings about failed replications, which would otherwise be logged again on every
+// resync. Its zero value is ready to use.
+type failureLog struct {
+	mu      sync.Mutex
+	entries map[string]*failureLogEntry
+}
+
+// logFailure logs a failed replication from the source into the target (the location of the target, as passed to
+// forgetFailure by rememberError) as a warning. The same error for the same pair is only
+// logged again after LogDeduplicationPeriod; repetitions until then are counted and reported with the next warning
+// or by summarizeFailures.
+func (r *GenericReplicator) logFailure(logger *log.Entry, sourceKey string, target string, err error, message string) {
+	logger = logger.WithError(err)
+	if r.LogDeduplicationPeriod <= 0 {
+		logger.Warn(message)
+		return
+	}
+
+	key := sourceKey + "->" + target
+	now := time.Now()
+
+	r.failureLog.mu.Lock()
+	defer r.failureLog.mu.Unlock()
+
+	if r.failureLog.entries == nil {
+		r.failureLog.entries = make(map[string]*failureLogEntry)
+	}
+
+	entry, ok := r.failureLog.entries[key]
+	if ok && entry.message == err.Error() {
+		if now.Sub(entry.logged) < r.LogDeduplicationPeriod {
+			entry.suppressed++
+			return
+		}
+		if entry.suppressed > 0 {
+			logger = logger.WithField("repeated", entry.suppressed)
+		}
+	}
+
+	logger.Warn(message)
+	r.failureLog.entries[key] = &failureLogEntry{message: err.Error(), logged: now, logger: logger}
+}
+
+// forgetFailure forgets the failure logged for the source and target after a successful replication, so that the
+// next failure is logged immediately
+func (r *GenericReplicator) forgetFailure(sourceKey string, target string) {
+	r.failureLog.mu.Lock()
+	defer r.failureLog.mu.Unlock()
+
+	delete(r.failureLog.entries, sourceKey+"->"+target)
+}
+
+// summarizeFailures logs how often each failure has been suppressed since it was last logged, once the
+// LogDeduplicationPeriod has passed. Failures that have not been repeated since are forgotten.
+func (r *GenericReplicator) summarizeFailures() {
+	now := time.Now()
+
+	r.failureLog.mu.Lock()
+	defer r.failureLog.mu.Unlock()
+
+	for key, entry := range r.failureLog.entries {
+		if now.Sub(entry.logged) < r.LogDeduplicationPeriod {
+			continue
+		}
+		if entry.suppressed == 0 {
+			delete(r.failureLog.entries, key)
+			continue
+		}
+
+		entry.logger.WithField("repeated", entry.suppressed).
+			Warnf("failure repeated %d times in the last %s", entry.suppressed, r.LogDeduplicationPeriod)
+		entry.suppressed = 0
+		entry.logged = now
+	}
+}
+
+// runFailureSummaries calls summarizeFailures every LogDeduplicationPeriod until stop is closed
+func (r *GenericReplicator) runFailureSummaries(stop <-chan struct{}) {
+	if r.LogDeduplicationPeriod <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.LogDeduplicationPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.summarizeFailures()
+		case <-stop:
+			return
+		}
+	}
+}
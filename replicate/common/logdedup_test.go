@@ -0,0 +1,118 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestLogFailureDeduplicates(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	entry := log.NewEntry(logger)
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{LogDeduplicationPeriod: time.Hour}}
+	denied := errors.New("source default/source does not allow replication")
+
+	r.logFailure(entry, "default/source", "team-a/copy", denied, "could not copy from source")
+	r.logFailure(entry, "default/source", "team-a/copy", denied, "could not copy from source")
+	r.logFailure(entry, "default/source", "team-a/copy", denied, "could not copy from source")
+	assert.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+
+	// other targets and other errors are logged
+	r.logFailure(entry, "default/source", "team-b/copy", denied, "could not copy from source")
+	r.logFailure(entry, "default/source", "team-a/copy", errors.New("conflict"), "could not copy from source")
+	assert.Len(t, hook.AllEntries(), 3)
+
+	// a successful replication resets the deduplication
+	r.forgetFailure("default/source", "team-a/copy")
+	r.logFailure(entry, "default/source", "team-a/copy", errors.New("conflict"), "could not copy from source")
+	assert.Len(t, hook.AllEntries(), 4)
+}
+
+func TestSummarizeFailures(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	entry := log.NewEntry(logger)
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{LogDeduplicationPeriod: time.Hour}}
+	denied := errors.New("source default/source does not allow replication")
+
+	r.logFailure(entry, "default/source", "team-a/copy", denied, "could not copy from source")
+	r.logFailure(entry, "default/source", "team-a/copy", denied, "could not copy from source")
+	r.logFailure(entry, "default/source", "team-b/copy", denied, "could not copy from source")
+	hook.Reset()
+
+	// summaries are only logged once the period has passed
+	r.summarizeFailures()
+	assert.Empty(t, hook.AllEntries())
+
+	for _, e := range r.failureLog.entries {
+		e.logged = e.logged.Add(-time.Hour)
+	}
+	r.summarizeFailures()
+	assert.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, 1, hook.LastEntry().Data["repeated"])
+	assert.Equal(t, denied, hook.LastEntry().Data[log.ErrorKey])
+
+	// failures that were not repeated are forgotten
+	assert.Len(t, r.failureLog.entries, 1)
+}
+
+func TestLogFailureWithoutDeduplication(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	entry := log.NewEntry(logger)
+	r := &GenericReplicator{}
+
+	r.logFailure(entry, "default/source", "team-a/copy", errors.New("conflict"), "could not copy from source")
+	r.logFailure(entry, "default/source", "team-a/copy", errors.New("conflict"), "could not copy from source")
+	assert.Len(t, hook.AllEntries(), 2)
+}
+
+func TestLogFailureIsResetByPushReplication(t *testing.T) {
+	hook := test.NewGlobal()
+	defer log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+
+	failures := []error{errors.New("conflict"), errors.New("conflict"), nil, errors.New("conflict")}
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Secret", LogDeduplicationPeriod: time.Hour},
+		Store:            cache.NewStore(cache.MetaNamespaceKeyFunc),
+		TargetStore:      cache.NewStore(cache.MetaNamespaceKeyFunc),
+		UpdateFuncs: UpdateFuncs{
+			ReplicateObjectTo: func(source interface{}, target *v1.Namespace) error {
+				err := failures[0]
+				failures = failures[1:]
+				return err
+			},
+		},
+	}
+
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"}}
+	target := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+
+	warnings := func() int {
+		count := 0
+		for _, e := range hook.AllEntries() {
+			if e.Level == log.WarnLevel {
+				count++
+			}
+		}
+		return count
+	}
+
+	// the repeated failure is suppressed, the one after the successful replication is not
+	_, _ = r.replicateResourceToNamespace(context.Background(), source, target)
+	_, _ = r.replicateResourceToNamespace(context.Background(), source, target)
+	assert.Equal(t, 1, warnings())
+
+	_, err := r.replicateResourceToNamespace(context.Background(), source, target)
+	assert.NoError(t, err)
+
+	_, _ = r.replicateResourceToNamespace(context.Background(), source, target)
+	assert.Equal(t, 2, warnings())
+}
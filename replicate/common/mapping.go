@@ -0,0 +1,102 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MappingConfigMap maintains a config map that lists what is replicated where, so that users without access to
+// the replicator's logs or status address can inspect it. The config map contains the sources of each replicator
+// and their targets (as returned by Replications) as JSON under the key "<kind>.json".
+type MappingConfigMap struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+
+	// written is the data that has been written last, so that the config map is only updated when it changes
+	written map[string]string
+}
+
+// NewMappingConfigMap creates a mapping for the config map at the given location ("<namespace>/<name>")
+func NewMappingConfigMap(client kubernetes.Interface, location string) (*MappingConfigMap, error) {
+	namespace, name, ok := strings.Cut(location, "/")
+	if !ok || namespace == "" || name == "" {
+		return nil, errors.Errorf("invalid mapping config map %q; expected '<namespace>/<name>'", location)
+	}
+	return &MappingConfigMap{Client: client, Namespace: namespace, Name: name}, nil
+}
+
+// Update writes the replications of the given replicators to the config map, creating it if necessary. Nothing
+// is written if the replications have not changed since the last update.
+func (m *MappingConfigMap) Update(replicators []Replicator) error {
+	data := make(map[string]string)
+	for _, replicator := range replicators {
+		reporter, ok := replicator.(GraphReporter)
+		if !ok {
+			continue
+		}
+
+		replications := reporter.Replications()
+		if len(replications) == 0 {
+			continue
+		}
+		encoded, err := json.MarshalIndent(replications, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "could not encode replications")
+		}
+		data[strings.ToLower(replications[0].Kind)+".json"] = string(encoded)
+	}
+
+	if m.written != nil && maps.Equal(m.written, data) {
+		return nil
+	}
+
+	configMaps := m.Client.CoreV1().ConfigMaps(m.Namespace)
+	configMap, err := configMaps.Get(context.TODO(), m.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(context.TODO(), &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: m.Name, Namespace: m.Namespace},
+			Data:       data,
+		}, metav1.CreateOptions{})
+	} else if err == nil {
+		configMap.Data = data
+		_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not save replications to config map %s/%s", m.Namespace, m.Name)
+	}
+
+	m.written = data
+	return nil
+}
+
+// RunMappingUpdates updates the mapping config map periodically, once all of the given replicators have synced
+func RunMappingUpdates(mapping *MappingConfigMap, replicators []Replicator, interval time.Duration) {
+	for range time.Tick(interval) {
+		synced := true
+		for _, replicator := range replicators {
+			if !replicator.Synced() {
+				synced = false
+				break
+			}
+		}
+
+		if !synced {
+			log.Debug("not updating mapping config map: replicators have not synced yet")
+			continue
+		}
+		if err := mapping.Update(replicators); err != nil {
+			log.WithError(err).Warn("could not update mapping config map")
+		}
+	}
+}
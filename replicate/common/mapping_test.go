@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestMappingConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	mapping, err := NewMappingConfigMap(client, "kube-system/replicator-mapping")
+	require.NoError(t, err)
+
+	_, err = NewMappingConfigMap(client, "replicator-mapping")
+	assert.Error(t, err)
+
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, Indexers)
+	require.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default", ResourceVersion: "1"}}))
+	require.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "copy",
+		Namespace:   "team-a",
+		Annotations: map[string]string{ReplicateFromAnnotation: "default/source", ReplicatedFromVersionAnnotation: "1"},
+	}}))
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: store}
+
+	require.NoError(t, mapping.Update([]Replicator{r}))
+	require.NoError(t, mapping.Update([]Replicator{r}))
+
+	configMap, err := client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), "replicator-mapping", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	var replications []ReplicationSource
+	require.NoError(t, json.Unmarshal([]byte(configMap.Data["secret.json"]), &replications))
+	assert.Equal(t, r.Replications(), replications)
+
+	// unchanged replications are not written again
+	updates := 0
+	for _, action := range client.Actions() {
+		if action.Matches("update", "configmaps") || action.Matches("create", "configmaps") {
+			updates++
+		}
+	}
+	assert.Equal(t, 1, updates)
+
+	client.ClearActions()
+	require.NoError(t, store.Delete(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "copy", Namespace: "team-a"}}))
+	require.NoError(t, mapping.Update([]Replicator{r}))
+	assert.True(t, hasAction(client.Actions(), "update"))
+
+	configMap, err = client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), "replicator-mapping", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, configMap.Data)
+}
+
+func hasAction(actions []k8stesting.Action, verb string) bool {
+	for _, action := range actions {
+		if action.Matches(verb, "configmaps") {
+			return true
+		}
+	}
+	return false
+}
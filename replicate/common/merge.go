@@ -0,0 +1,75 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ParseMergeSources parses the value of the MergeFrom annotation, a comma-separated list of
+// "<namespace>/<name>" references
+func ParseMergeSources(value string) ([]string, error) {
+	var sources []string
+	for _, source := range strings.Split(value, ",") {
+		source = strings.TrimSpace(source)
+		if source == "" {
+			continue
+		}
+		if v := strings.SplitN(source, "/", 2); len(v) < 2 || v[0] == "" || v[1] == "" {
+			return nil, errors.Errorf("expected '<namespace>/<name>', got %q", source)
+		}
+		sources = append(sources, source)
+	}
+
+	if len(sources) == 0 {
+		return nil, errors.New("no sources given")
+	}
+	return sources, nil
+}
+
+// isMergeTarget checks if the object merges the data of several sources with the MergeFrom annotation
+func isMergeTarget(target interface{}) bool {
+	_, ok := MustGetObject(target).GetAnnotations()[MergeFrom]
+	return ok
+}
+
+// resourceAddedMergeFrom merges the data of all sources listed in the MergeFrom annotation of the target into it.
+// Sources that do not exist (anymore) are left out, so the target is merged again whenever one of its sources
+// is added, changed or deleted.
+func (r *GenericReplicator) resourceAddedMergeFrom(target interface{}) error {
+	cacheKey := MustGetKey(target)
+	logger := log.WithField("kind", r.Kind).WithField("target", cacheKey)
+
+	if r.UpdateFuncs.MergeDataFrom == nil {
+		return errors.Errorf("%s is not supported for %ss", MergeFrom, r.Kind)
+	}
+
+	sourceKeys, err := ParseMergeSources(MustGetObject(target).GetAnnotations()[MergeFrom])
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s annotation", MergeFrom)
+	}
+
+	sources := make([]interface{}, 0, len(sourceKeys))
+	for _, sourceKey := range sourceKeys {
+		source, exists, err := r.Store.GetByKey(sourceKey)
+		if err != nil {
+			return errors.Wrapf(err, "could not get source %s", sourceKey)
+		} else if !exists {
+			logger.Debugf("source %s does not exist; not merging it", sourceKey)
+			continue
+		}
+
+		if !r.isApproved(source) || !r.withinSizeLimit(source) {
+			continue
+		}
+
+		sources = append(sources, source)
+	}
+
+	if err := r.UpdateFuncs.MergeDataFrom(sources, target); err != nil {
+		return errors.Wrapf(err, "failed to merge %s target %s", r.Kind, cacheKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMergeSources(t *testing.T) {
+	sources, err := ParseMergeSources("infra/registry, team/registry,")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"infra/registry", "team/registry"}, sources)
+
+	for _, invalid := range []string{"", " , ", "registry", "infra/", "/registry"} {
+		_, err := ParseMergeSources(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
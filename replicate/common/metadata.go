@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// supportsMetadataOnlyTargets checks if replicas can be cached as metadata only, which requires the replicator
+// to be able to fetch the complete replicas from the API when they are updated
+func supportsMetadataOnlyTargets(config ReplicatorConfig) bool {
+	return config.MetadataOnlyTargets && config.MetadataClient != nil && config.GetFunc != nil && !config.TargetResource.Empty()
+}
+
+// withReplicaRequirement restricts the selector to objects that are (or are not) labeled as replicas by
+// SetTrackingLabels
+func withReplicaRequirement(selector labels.Selector, replicas bool) labels.Selector {
+	if selector == nil {
+		selector = labels.Everything()
+	}
+
+	operator := selection.DoesNotExist
+	if replicas {
+		operator = selection.Exists
+	}
+
+	requirement, err := labels.NewRequirement(ReplicatedByNamespaceLabel, operator, nil)
+	if err != nil {
+		panic(err)
+	}
+	return selector.Add(*requirement)
+}
+
+// newMetadataTargetInformer creates an informer that caches only the metadata of the replicas created in push
+// mode. The objects in its store are of type *metav1.PartialObjectMetadata.
+func newMetadataTargetInformer(config ReplicatorConfig) (cache.Store, cache.Controller) {
+	resource := config.MetadataClient.Resource(config.TargetResource)
+	selector := withReplicaRequirement(config.WatchSelector, true).String()
+
+	return cache.NewInformerWithOptions(cache.InformerOptions{
+		ListerWatcher: InstrumentListWatch(config.Kind+"/replicas", &cache.ListWatch{
+			ListFunc: PagedListFunc(func(lo metav1.ListOptions) (runtime.Object, error) {
+				lo.LabelSelector = selector
+				return resource.List(context.TODO(), lo)
+			}, config.ListPageSize),
+			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+				lo.LabelSelector = selector
+				return resource.Watch(context.TODO(), lo)
+			},
+		}),
+		ObjectType:   &metav1.PartialObjectMetadata{},
+		ResyncPeriod: config.ResyncPeriod,
+		Handler:      cache.ResourceEventHandlerFuncs{},
+		Indexers:     Indexers,
+		Transform:    StripBulkyMetadata,
+	})
+}
+
+// cachedTarget looks up the target at the given location in the cache. If replicas are cached as metadata only,
+// targets that are not labeled as replicas (like pre-existing objects or legacy replicas) are found in the
+// regular cache.
+func (r *GenericReplicator) cachedTarget(targetLocation string) (interface{}, bool, error) {
+	obj, exists, err := r.TargetStore.GetByKey(targetLocation)
+	if err != nil || exists || r.targetController == nil {
+		return obj, exists, err
+	}
+	return r.Store.GetByKey(targetLocation)
+}
+
+// LoadTarget returns the complete target at the given location. If replicas are cached as metadata only, the
+// target is fetched from the API.
+func (r *GenericReplicator) LoadTarget(targetLocation string) (interface{}, bool, error) {
+	obj, exists, err := r.cachedTarget(targetLocation)
+	if err != nil || !exists {
+		return obj, exists, err
+	}
+
+	if _, metadataOnly := obj.(*metav1.PartialObjectMetadata); !metadataOnly {
+		return obj, true, nil
+	}
+
+	namespace, name, _ := strings.Cut(targetLocation, "/")
+	target, err := r.GetFunc(namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, errors.Wrapf(err, "could not get %s %s", r.Kind, targetLocation)
+	}
+	return target, true, nil
+}
+
+// CacheTarget updates the cached copy of a replica that has just been written in push mode, so that it doesn't
+// have to wait for the watch event. If replicas are cached as metadata only, only its metadata is cached.
+func (r *GenericReplicator) CacheTarget(obj interface{}) error {
+	if r.targetController == nil {
+		return r.TargetStore.Update(obj)
+	}
+
+	object := MustGetObject(obj)
+	return r.TargetStore.Update(&metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+		Name:            object.GetName(),
+		Namespace:       object.GetNamespace(),
+		UID:             object.GetUID(),
+		ResourceVersion: object.GetResourceVersion(),
+		Labels:          object.GetLabels(),
+		Annotations:     object.GetAnnotations(),
+		OwnerReferences: object.GetOwnerReferences(),
+	}})
+}
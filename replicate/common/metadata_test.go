@@ -0,0 +1,69 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWithReplicaRequirement(t *testing.T) {
+	assert.Equal(t, "!"+ReplicatedByNamespaceLabel, withReplicaRequirement(nil, false).String())
+	assert.Equal(t, ReplicatedByNamespaceLabel, withReplicaRequirement(nil, true).String())
+}
+
+func TestLoadTarget(t *testing.T) {
+	annotations := map[string]string{ReplicatedSourceAnnotation: "source/secret"}
+	replica := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "replica", Namespace: "target", ResourceVersion: "2", Annotations: annotations}}
+	legacy := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "target", Annotations: annotations}}
+
+	config := ReplicatorConfig{
+		Kind:                "Secret",
+		MetadataOnlyTargets: true,
+		MetadataClient:      metadatafake.NewSimpleMetadataClient(runtime.NewScheme()),
+		TargetResource:      v1.SchemeGroupVersion.WithResource("secrets"),
+		GetFunc: func(namespace string, name string) (runtime.Object, error) {
+			require.Equal(t, "target/replica", namespace+"/"+name)
+			return replica, nil
+		},
+	}
+	require.True(t, supportsMetadataOnlyTargets(config))
+
+	r := &GenericReplicator{ReplicatorConfig: config, Store: cache.NewStore(cache.MetaNamespaceKeyFunc)}
+	r.TargetStore, r.targetController = newMetadataTargetInformer(config)
+
+	require.NoError(t, r.TargetStore.Add(&metav1.PartialObjectMetadata{ObjectMeta: replica.ObjectMeta}))
+	require.NoError(t, r.Store.Add(legacy))
+
+	target, exists, err := r.LoadTarget("target/replica")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Same(t, replica, target)
+
+	target, exists, err = r.LoadTarget("target/legacy")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Same(t, legacy, target)
+
+	_, exists, err = r.LoadTarget("target/missing")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.Len(t, r.replicasOf("source/secret"), 2)
+
+	updated := replica.DeepCopy()
+	updated.Name = "updated"
+	updated.Data = map[string][]byte{"key": []byte("value")}
+	require.NoError(t, r.CacheTarget(updated))
+
+	cached, exists, err := r.TargetStore.GetByKey("target/updated")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.IsType(t, &metav1.PartialObjectMetadata{}, cached)
+	assert.Len(t, r.Store.List(), 1)
+}
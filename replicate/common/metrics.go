@@ -0,0 +1,161 @@
+package common
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const metricsNamespace = "kubernetes_replicator"
+
+var (
+	metricThrottledRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "throttled_requests_total",
+		Help:      "Number of write requests rejected by the API server with 429 Too Many Requests",
+	}, []string{"kind"})
+
+	metricLoadSheddingActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "load_shedding_active",
+		Help:      "Whether replication is currently slowed down due to API server throttling (1) or not (0)",
+	})
+
+	metricLoadSheddingDelay = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "load_shedding_delay_seconds",
+		Help:      "Current delay between write requests caused by API server throttling",
+	})
+
+	metricInvalidConfiguration = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "invalid_configuration",
+		Help:      "Whether the replicator annotations of an object are invalid (1) or not (0)",
+	}, []string{"kind", "source"})
+
+	metricOversizedSources = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "oversized_source_bytes",
+		Help:      "Size of sources that are not replicated because they exceed the configured size limit",
+	}, []string{"kind", "source"})
+
+	metricFanOutLimitExceeded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "fan_out_limit_exceeded_namespaces",
+		Help:      "Number of namespaces that sources would be replicated to if they did not exceed their max-replicas limit",
+	}, []string{"kind", "source"})
+
+	metricPendingRetries = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "pending_retries",
+		Help:      "Number of failed replications that are scheduled to be retried",
+	}, []string{"kind"})
+
+	metricNamespaceQueueLength = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "namespace_queue_length",
+		Help:      "Number of namespace events waiting to be processed, by priority",
+	}, []string{"priority"})
+
+	metricQueuedCreations = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "queued_creations",
+		Help:      "Number of target creations waiting for the creation rate limit",
+	})
+
+	metricInformerLists = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "informer_lists_total",
+		Help:      "Number of times an informer listed all of its objects",
+	}, []string{"informer"})
+
+	metricInformerWatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "informer_watches_total",
+		Help:      "Number of watches started by an informer",
+	}, []string{"informer"})
+
+	metricInformerWatchRestarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "informer_watch_restarts_total",
+		Help:      "Number of watches started by an informer after its first one, e.g. because the previous watch was closed",
+	}, []string{"informer"})
+
+	metricInformerListDuration = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "informer_last_list_duration_seconds",
+		Help:      "Time it took an informer to list all of its objects the last time, including all pages",
+	}, []string{"informer"})
+
+	metricInformerExpiredWatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "informer_expired_watches_total",
+		Help:      "Number of watches of an informer that ended because their resource version was too old",
+	}, []string{"informer"})
+
+	metricReplicationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "replication_failures_total",
+		Help:      "Number of failed attempts to write a replica, by reason",
+	}, []string{"kind", "reason"})
+
+	metricLastFailure = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_failure_timestamp_seconds",
+		Help:      "Time of the last failed attempt to write a replica of a source",
+	}, []string{"kind", "source"})
+
+	metricReplicationLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "replication_latency_seconds",
+		Help:      "Time from observing a change of a source until it has been replicated to all of its targets",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+	}, []string{"kind"})
+
+	metricDeniedReplications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "denied_replications_total",
+		Help:      "Number of replications from a source that were denied by its annotations, by reason and target namespace",
+	}, []string{"kind", "reason", "namespace"})
+
+	metricReplications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "replications_total",
+		Help:      "Number of attempts to write a replica, by result",
+	}, []string{"kind", "result"})
+)
+
+// countReplication counts an attempt to write a replica of the given source, and remembers the time of successful
+// ones. Failures are counted by their reason, see failureReason.
+func (r *GenericReplicator) countReplication(source interface{}, err error) {
+	if err == nil {
+		r.lastReplication.Store(time.Now().UnixNano())
+		metricReplications.WithLabelValues(r.Kind, "success").Inc()
+		return
+	}
+
+	metricReplications.WithLabelValues(r.Kind, "failure").Inc()
+	metricReplicationFailures.WithLabelValues(r.Kind, failureReason(err)).Inc()
+	metricLastFailure.WithLabelValues(r.Kind, MustGetKey(source)).SetToCurrentTime()
+}
+
+// failureReason classifies the error of a failed replication for metricReplicationFailures
+func failureReason(err error) string {
+	switch {
+	case isReplicationDenied(err):
+		return "denied"
+	case apierrors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		return "quota"
+	case apierrors.IsForbidden(err):
+		return "forbidden"
+	case apierrors.IsConflict(err):
+		return "conflict"
+	case apierrors.IsNotFound(err):
+		return "not-found"
+	default:
+		return "other"
+	}
+}
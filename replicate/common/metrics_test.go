@@ -0,0 +1,64 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCountReplication(t *testing.T) {
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Test"}}
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "source"}}
+
+	r.countReplication(source, apierrors.NewConflict(schema.GroupResource{Resource: "secrets"}, "shared", errors.New("modified")))
+	assert.Zero(t, r.lastReplication.Load())
+	assert.NotZero(t, testutil.ToFloat64(metricLastFailure.WithLabelValues("Test", "source/shared")))
+
+	r.countReplication(source, nil)
+	r.countReplication(source, nil)
+	assert.NotZero(t, r.lastReplication.Load())
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metricReplications.WithLabelValues("Test", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metricReplications.WithLabelValues("Test", "failure")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metricReplicationFailures.WithLabelValues("Test", "conflict")))
+}
+
+func TestFailureReason(t *testing.T) {
+	secrets := schema.GroupResource{Resource: "secrets"}
+
+	assert.Equal(t, "denied", failureReason(deniedf(DeniedReasonNotAllowed, "source does not allow replication")))
+	assert.Equal(t, "quota", failureReason(apierrors.NewForbidden(secrets, "shared",
+		errors.New("exceeded quota: compute-resources, requested: count/secrets=1, used: count/secrets=10, limited: count/secrets=10"))))
+	assert.Equal(t, "forbidden", failureReason(apierrors.NewForbidden(secrets, "shared", errors.New("no RBAC policy matched"))))
+	assert.Equal(t, "conflict", failureReason(apierrors.NewConflict(secrets, "shared", errors.New("modified"))))
+	assert.Equal(t, "not-found", failureReason(apierrors.NewNotFound(secrets, "shared")))
+	assert.Equal(t, "other", failureReason(errors.New("connection refused")))
+}
+
+func TestCountDeniedReplications(t *testing.T) {
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "DeniedTest"}}
+	target := &metav1.ObjectMeta{Name: "copy", Namespace: "team-a"}
+
+	for _, annotations := range []map[string]string{
+		nil,
+		{ReplicationAllowed: "false"},
+		{ReplicationAllowed: "maybe"},
+		{ReplicationAllowed: "true"},
+		{ReplicationAllowed: "true", ReplicationAllowedNamespaces: "team-b"},
+		{ReplicationAllowed: "true", ReplicationAllowedNamespaceLabels: "team in ("},
+		{ReplicationAllowed: "true", ReplicationAllowedNamespaces: "team-a"},
+	} {
+		source := &metav1.ObjectMeta{Name: "source", Namespace: "default", Annotations: annotations}
+		_, _ = r.IsReplicationPermitted(target, source)
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metricDeniedReplications.WithLabelValues("DeniedTest", DeniedReasonNotAllowed, "team-a")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metricDeniedReplications.WithLabelValues("DeniedTest", DeniedReasonNamespaceNotPermitted, "team-a")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metricDeniedReplications.WithLabelValues("DeniedTest", DeniedReasonBadAnnotation, "team-a")))
+}
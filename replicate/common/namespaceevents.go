@@ -0,0 +1,61 @@
+package common
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// creationFailureCause describes why a replica could not be created in its target namespace, for the owners of the
+// namespace
+func creationFailureCause(err error) string {
+	switch {
+	case apierrors.IsForbidden(err) && strings.Contains(err.Error(), "being terminated"):
+		return "the namespace is being terminated"
+	case failureReason(err) == "quota":
+		return "a resource quota of the namespace is exhausted"
+	case failureReason(err) == "forbidden":
+		return "the replicator is not permitted to create it"
+	default:
+		return "of an error"
+	}
+}
+
+// recordFailedCreation counts consecutive failed creations of a replica of the source at the target location. Once
+// NamespaceEventThreshold creations in a row have failed, every further failure records a warning event in the
+// target namespace, so that the owners of the namespace (and not only those of the source) see why the replica is
+// missing. The count is reset once the replica has been written.
+func (r *GenericReplicator) recordFailedCreation(source interface{}, targetLocation string, updated bool, err error) {
+	key := MustGetKey(source) + "->" + targetLocation
+	if err == nil {
+		r.failedCreations.Delete(key)
+		return
+	}
+	if updated || isReplicationDenied(err) || r.NamespaceEventThreshold <= 0 {
+		return
+	}
+
+	failures, _ := r.failedCreations.Load(key)
+	failures++
+	r.failedCreations.Store(key, failures)
+	if failures < r.NamespaceEventThreshold {
+		return
+	}
+
+	if target := r.targetReference(source, targetLocation); target != nil {
+		r.recordEvent(target, v1.EventTypeWarning, "ReplicaCreationFailing",
+			"%s %s cannot be replicated into this namespace because %s: %v", r.Kind, MustGetKey(source),
+			creationFailureCause(err), err)
+	}
+}
+
+// forgetFailedCreations forgets the failed creations of replicas of the deleted source with the given key
+func (r *GenericReplicator) forgetFailedCreations(sourceKey string) {
+	r.failedCreations.Range(func(key string, _ int) bool {
+		if strings.HasPrefix(key, sourceKey+"->") {
+			r.failedCreations.Delete(key)
+		}
+		return true
+	})
+}
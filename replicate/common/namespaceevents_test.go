@@ -0,0 +1,62 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestCreationFailureCause(t *testing.T) {
+	secrets := schema.GroupResource{Resource: "secrets"}
+
+	terminating := apierrors.NewForbidden(secrets, "shared", nil)
+	terminating.ErrStatus.Message = `secrets "shared" is forbidden: unable to create new content in namespace team-a because it is being terminated`
+	assert.Equal(t, "the namespace is being terminated", creationFailureCause(terminating))
+
+	quota := apierrors.NewForbidden(secrets, "shared", nil)
+	quota.ErrStatus.Message = `secrets "shared" is forbidden: exceeded quota: team-a, requested: secrets=1, used: secrets=10, limited: secrets=10`
+	assert.Equal(t, "a resource quota of the namespace is exhausted", creationFailureCause(quota))
+
+	assert.Equal(t, "the replicator is not permitted to create it", creationFailureCause(apierrors.NewForbidden(secrets, "shared", nil)))
+	assert.Equal(t, "of an error", creationFailureCause(apierrors.NewConflict(secrets, "shared", nil)))
+}
+
+func TestRecordFailedCreation(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "source"}}
+	err := apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "shared", nil)
+
+	recorder := record.NewFakeRecorder(10)
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Secret", EventRecorder: recorder, NamespaceEventThreshold: 2},
+		Store:            store,
+		TargetStore:      store,
+	}
+
+	r.recordFailedCreation(source, "target/shared", false, err)
+	assert.Empty(t, recorder.Events)
+
+	r.recordFailedCreation(source, "target/shared", false, err)
+	assert.Equal(t, "Warning ReplicaCreationFailing Secret source/shared cannot be replicated into this namespace "+
+		"because the replicator is not permitted to create it: "+err.Error(), <-recorder.Events)
+
+	// failed updates, denied replications and other targets are not counted
+	r.recordFailedCreation(source, "target/shared", true, err)
+	r.recordFailedCreation(source, "target/shared", false, deniedf(DeniedReasonNotAllowed, "not allowed"))
+	r.recordFailedCreation(source, "other/shared", false, err)
+	assert.Empty(t, recorder.Events)
+
+	// a successful replication resets the count
+	r.recordFailedCreation(source, "target/shared", false, nil)
+	r.recordFailedCreation(source, "target/shared", false, err)
+	assert.Empty(t, recorder.Events)
+
+	r.forgetFailedCreations("source/shared")
+	assert.Zero(t, r.failedCreations.Len())
+}
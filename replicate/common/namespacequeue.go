@@ -0,0 +1,111 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// namespaceQueueCapacity is the number of pending namespace events after which the namespace watcher blocks
+	namespaceQueueCapacity = 1000
+
+	// freshNamespaceAge is the age up to which an added namespace is considered to be newly created, rather than
+	// listed by the namespace watcher when it starts
+	freshNamespaceAge = 5 * time.Minute
+
+	// freshNamespaceBurst is the number of events of newly created namespaces that are processed in a row while
+	// events of other namespaces are pending
+	freshNamespaceBurst = 4
+)
+
+// namespaceQueue processes the events of the namespace watcher with a bounded number of workers. Newly created
+// namespaces are prioritized, so that they receive their replicas promptly when many namespaces are created at
+// once, but events of other namespaces are still processed in between.
+type namespaceQueue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+
+	fresh []func()
+	other []func()
+	burst int
+}
+
+func newNamespaceQueue() *namespaceQueue {
+	q := &namespaceQueue{}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	return q
+}
+
+// isFreshNamespace checks if the namespace has been created recently
+func isFreshNamespace(namespace *v1.Namespace) bool {
+	return time.Since(namespace.CreationTimestamp.Time) < freshNamespaceAge
+}
+
+// add enqueues an event handler, blocking while the queue is full
+func (q *namespaceQueue) add(fresh bool, handler func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.fresh)+len(q.other) >= namespaceQueueCapacity {
+		q.notFull.Wait()
+	}
+
+	if fresh {
+		q.fresh = append(q.fresh, handler)
+	} else {
+		q.other = append(q.other, handler)
+	}
+	q.observe()
+	q.notEmpty.Signal()
+}
+
+// next blocks until an event handler is pending and dequeues it
+func (q *namespaceQueue) next() func() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.fresh)+len(q.other) == 0 {
+		q.notEmpty.Wait()
+	}
+
+	var handler func()
+	if len(q.fresh) > 0 && (q.burst < freshNamespaceBurst || len(q.other) == 0) {
+		handler, q.fresh = q.fresh[0], q.fresh[1:]
+		q.burst++
+	} else {
+		handler, q.other = q.other[0], q.other[1:]
+		q.burst = 0
+	}
+	q.observe()
+	q.notFull.Signal()
+
+	return handler
+}
+
+// len returns the number of pending event handlers
+func (q *namespaceQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.fresh) + len(q.other)
+}
+
+func (q *namespaceQueue) observe() {
+	metricNamespaceQueueLength.WithLabelValues("fresh").Set(float64(len(q.fresh)))
+	metricNamespaceQueueLength.WithLabelValues("other").Set(float64(len(q.other)))
+}
+
+// run starts the given number of workers, which process the queue until the process exits
+func (q *namespaceQueue) run(workers int) {
+	for i := 0; i < max(workers, 1); i++ {
+		go func() {
+			for {
+				q.next()()
+			}
+		}()
+	}
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsFreshNamespace(t *testing.T) {
+	assert.True(t, isFreshNamespace(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()}}))
+	assert.False(t, isFreshNamespace(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+	}}))
+}
+
+func TestNamespaceQueuePrioritizesFreshNamespaces(t *testing.T) {
+	q := newNamespaceQueue()
+
+	var order []string
+	enqueue := func(fresh bool, name string) {
+		q.add(fresh, func() { order = append(order, name) })
+	}
+
+	enqueue(false, "old-1")
+	enqueue(false, "old-2")
+	for _, name := range []string{"new-1", "new-2", "new-3", "new-4", "new-5"} {
+		enqueue(true, name)
+	}
+
+	for i := 0; i < 7; i++ {
+		q.next()()
+	}
+
+	assert.Equal(t, []string{"new-1", "new-2", "new-3", "new-4", "old-1", "new-5", "old-2"}, order)
+}
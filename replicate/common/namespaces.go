@@ -2,12 +2,17 @@ package common
 
 import (
 	"context"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
@@ -17,12 +22,19 @@ import (
 
 var namespaceWatcher NamespaceWatcher
 
+const (
+	// namespaceLookupRetries is the number of GET requests issued for a namespace that is missing from the cache
+	namespaceLookupRetries       = 3
+	namespaceLookupRetryInterval = 100 * time.Millisecond
+)
+
 type AddFunc func(obj *v1.Namespace)
 
 type UpdateFunc func(old *v1.Namespace, new *v1.Namespace)
 
 type NamespaceWatcher struct {
 	doOnce sync.Once
+	queue  *namespaceQueue
 
 	NamespaceStore      cache.Store
 	NamespaceController cache.Controller
@@ -32,12 +44,15 @@ type NamespaceWatcher struct {
 }
 
 // create will create a new namespace if one does not already exist. If it does, it will do nothing.
-func (nw *NamespaceWatcher) create(client kubernetes.Interface, resyncPeriod time.Duration) {
+func (nw *NamespaceWatcher) create(client kubernetes.Interface, resyncPeriod time.Duration, pageSize int64, workers int) {
 	nw.doOnce.Do(func() {
+		nw.queue = newNamespaceQueue()
+
 		namespaceAdded := func(obj interface{}) {
 			namespace := obj.(*v1.Namespace)
+			fresh := isFreshNamespace(namespace)
 			for _, addFunc := range nw.AddFuncs {
-				go addFunc(namespace)
+				nw.queue.add(fresh, func() { addFunc(namespace) })
 			}
 		}
 
@@ -45,41 +60,157 @@ func (nw *NamespaceWatcher) create(client kubernetes.Interface, resyncPeriod tim
 			nsOld := old.(*v1.Namespace)
 			nsNew := new.(*v1.Namespace)
 			for _, updateFunc := range nw.UpdateFuncs {
-				go updateFunc(nsOld, nsNew)
+				nw.queue.add(false, func() { updateFunc(nsOld, nsNew) })
 			}
 		}
 
-		nw.NamespaceStore, nw.NamespaceController = cache.NewInformer(
-			&cache.ListWatch{
-				ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+		nw.NamespaceStore, nw.NamespaceController = cache.NewInformerWithOptions(cache.InformerOptions{
+			ListerWatcher: InstrumentListWatch("Namespace", &cache.ListWatch{
+				ListFunc: PagedListFunc(func(lo metav1.ListOptions) (runtime.Object, error) {
 					return client.CoreV1().Namespaces().List(context.TODO(), lo)
-				},
+				}, pageSize),
 				WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
 					return client.CoreV1().Namespaces().Watch(context.TODO(), lo)
 				},
-			},
-			&v1.Namespace{},
-			resyncPeriod,
-			cache.ResourceEventHandlerFuncs{
+			}),
+			ObjectType:   &v1.Namespace{},
+			ResyncPeriod: resyncPeriod,
+			Handler: cache.ResourceEventHandlerFuncs{
 				AddFunc:    namespaceAdded,
 				UpdateFunc: namespaceUpdated,
 			},
-		)
+			Transform: StripBulkyMetadata,
+		})
 
 		log.WithField("kind", "Namespace").Infof("running Namespace controller")
+		nw.queue.run(workers)
 		go nw.NamespaceController.Run(wait.NeverStop)
 
 	})
 }
 
 // OnNamespaceAdded will add another method to a list of functions to be called when a new namespace is created
-func (nw *NamespaceWatcher) OnNamespaceAdded(client kubernetes.Interface, resyncPeriod time.Duration, addFunc AddFunc) {
-	nw.create(client, resyncPeriod)
+func (nw *NamespaceWatcher) OnNamespaceAdded(client kubernetes.Interface, resyncPeriod time.Duration, pageSize int64, workers int, addFunc AddFunc) {
+	nw.create(client, resyncPeriod, pageSize, workers)
 	nw.AddFuncs = append(nw.AddFuncs, addFunc)
 }
 
 // OnNamespaceUpdated will add another method to a list of functions to be called when a namespace is updated
-func (nw *NamespaceWatcher) OnNamespaceUpdated(client kubernetes.Interface, resyncPeriod time.Duration, updateFunc UpdateFunc) {
-	nw.create(client, resyncPeriod)
+func (nw *NamespaceWatcher) OnNamespaceUpdated(client kubernetes.Interface, resyncPeriod time.Duration, pageSize int64, workers int, updateFunc UpdateFunc) {
+	nw.create(client, resyncPeriod, pageSize, workers)
 	nw.UpdateFuncs = append(nw.UpdateFuncs, updateFunc)
 }
+
+// getNamespace looks up a namespace in the namespace cache. Since the cache may lag behind the API server when
+// namespaces are created in rapid succession, a cache miss is confirmed with a few GET requests before the
+// namespace is reported as nonexistent.
+func (r *GenericReplicator) getNamespace(name string) (*v1.Namespace, bool, error) {
+	obj, exists, err := namespaceWatcher.NamespaceStore.GetByKey(name)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "could not get namespace %s from cache", name)
+	} else if exists {
+		return obj.(*v1.Namespace), true, nil
+	}
+
+	for i := 0; i < namespaceLookupRetries; i++ {
+		if i > 0 {
+			time.Sleep(namespaceLookupRetryInterval)
+		}
+
+		ns, err := r.Client.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+		if err == nil {
+			log.WithField("kind", r.Kind).Debugf("namespace %s not yet in cache, found it via the API", name)
+			return ns, true, nil
+		} else if !apierrors.IsNotFound(err) {
+			return nil, false, errors.Wrapf(err, "could not get namespace %s", name)
+		}
+	}
+
+	return nil, false, nil
+}
+
+// cachedNamespaces returns the namespaces in the namespace cache that match the selector
+func cachedNamespaces(selector labels.Selector) []v1.Namespace {
+	var result []v1.Namespace
+	for _, obj := range namespaceWatcher.NamespaceStore.List() {
+		if namespace := obj.(*v1.Namespace); selector.Matches(labels.Set(namespace.Labels)) {
+			result = append(result, *namespace)
+		}
+	}
+	return result
+}
+
+// withUncachedNamespaces adds namespaces that are named literally in the given patterns, but are not yet present
+// in the namespace cache
+func (r *GenericReplicator) withUncachedNamespaces(patterns string, namespaces []v1.Namespace) []v1.Namespace {
+	known := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		known[ns.Name] = struct{}{}
+	}
+
+	for _, pattern := range strings.Split(patterns, ",") {
+		name := strings.TrimSpace(pattern)
+		if name == "" || strings.HasPrefix(name, "!") || regexp.QuoteMeta(name) != name {
+			continue
+		}
+		if _, ok := known[name]; ok {
+			continue
+		}
+
+		ns, exists, err := r.getNamespace(name)
+		if err != nil {
+			log.WithField("kind", r.Kind).WithError(err).Warnf("could not verify existence of namespace %s", name)
+			continue
+		} else if !exists {
+			continue
+		}
+
+		known[name] = struct{}{}
+		namespaces = append(namespaces, *ns)
+	}
+
+	return namespaces
+}
+
+// namespaceMatchesSelector checks if the labels of the given namespace match a label selector
+func (r *GenericReplicator) namespaceMatchesSelector(namespace string, selector string) (bool, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid label selector %q", selector)
+	}
+
+	ns, exists, err := r.getNamespace(namespace)
+	if err != nil {
+		return false, err
+	} else if !exists {
+		return false, nil
+	}
+
+	return parsed.Matches(labels.Set(ns.Labels)), nil
+}
+
+// intersectedSelector returns the label selector of the ReplicateToMatching annotation of a source that is only
+// replicated into namespaces matching both its ReplicateTo (or ReplicateToFromConfigMap) patterns and that selector,
+// as requested by the ReplicateToIntersect annotation. An invalid selector matches no namespace.
+func intersectedSelector(source metav1.Object) (labels.Selector, bool) {
+	annotations := source.GetAnnotations()
+	if annotations[ReplicateToIntersect] != "true" {
+		return nil, false
+	}
+
+	selector, ok := annotations[ReplicateToMatching]
+	if !ok {
+		return nil, false
+	}
+	_, hasPatterns := annotations[ReplicateTo]
+	_, hasConfigMap := annotations[ReplicateToFromConfigMap]
+	if !hasPatterns && !hasConfigMap {
+		return nil, false
+	}
+
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return labels.Nothing(), true
+	}
+	return parsed, true
+}
@@ -0,0 +1,126 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWithUncachedNamespaces(t *testing.T) {
+	cached := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "cached"}}
+	uncached := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "uncached"}}
+
+	namespaceWatcher.NamespaceStore = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, namespaceWatcher.NamespaceStore.Add(&cached))
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{
+		Kind:   "Secret",
+		Client: fake.NewSimpleClientset(&cached, &uncached),
+	}}
+
+	namespaces := r.withUncachedNamespaces("cached, uncached, missing, prefix-.*", []v1.Namespace{cached})
+
+	names := make([]string, len(namespaces))
+	for i, ns := range namespaces {
+		names[i] = ns.Name
+	}
+	assert.Equal(t, []string{"cached", "uncached"}, names)
+}
+
+func TestIsReplicationPermittedByNamespaceLabels(t *testing.T) {
+	namespaceWatcher.NamespaceStore = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, namespaceWatcher.NamespaceStore.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "team-a",
+		Labels: map[string]string{"team": "a"},
+	}}))
+	assert.NoError(t, namespaceWatcher.NamespaceStore.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "team-b",
+		Labels: map[string]string{"team": "b"},
+	}}))
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{
+		Kind:   "Secret",
+		Client: fake.NewSimpleClientset(),
+	}}
+
+	source := &metav1.ObjectMeta{Namespace: "source", Name: "source", Annotations: map[string]string{
+		ReplicationAllowed:                "true",
+		ReplicationAllowedNamespaceLabels: "team=a",
+	}}
+
+	allowed, err := r.IsReplicationPermitted(&metav1.ObjectMeta{Namespace: "team-a", Name: "target"}, source)
+	assert.True(t, allowed)
+	assert.NoError(t, err)
+
+	allowed, err = r.IsReplicationPermitted(&metav1.ObjectMeta{Namespace: "team-b", Name: "target"}, source)
+	assert.False(t, allowed)
+	assert.Error(t, err)
+
+	source.Annotations[ReplicationAllowedNamespaces] = "team-b"
+	allowed, _ = r.IsReplicationPermitted(&metav1.ObjectMeta{Namespace: "team-b", Name: "target"}, source)
+	assert.True(t, allowed)
+}
+
+func TestCachedNamespaces(t *testing.T) {
+	namespaceWatcher.NamespaceStore = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, namespaceWatcher.NamespaceStore.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "team-a",
+		Labels: map[string]string{"team": "a"},
+	}}))
+	assert.NoError(t, namespaceWatcher.NamespaceStore.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}))
+
+	selector, err := labels.Parse("team=a")
+	assert.NoError(t, err)
+
+	namespaces := cachedNamespaces(selector)
+	assert.Len(t, namespaces, 1)
+	assert.Equal(t, "team-a", namespaces[0].Name)
+
+	assert.Len(t, cachedNamespaces(labels.Everything()), 2)
+}
+
+func TestReplicateToMatchingNamespacesByLabelUsesCache(t *testing.T) {
+	teamA := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}}
+	teamB := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"team": "b"}}}
+	uncached := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "uncached", Labels: map[string]string{"team": "a"}}}
+
+	namespaceWatcher.NamespaceStore = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, namespaceWatcher.NamespaceStore.Add(teamA))
+	assert.NoError(t, namespaceWatcher.NamespaceStore.Add(teamB))
+
+	var lock sync.Mutex
+	var replicatedTo []string
+	client := fake.NewSimpleClientset(teamA, teamB, uncached)
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Secret", Client: client},
+		Store:            cache.NewStore(cache.MetaNamespaceKeyFunc),
+		TargetStore:      cache.NewStore(cache.MetaNamespaceKeyFunc),
+		UpdateFuncs: UpdateFuncs{
+			ReplicateObjectTo: func(source interface{}, target *v1.Namespace) error {
+				lock.Lock()
+				defer lock.Unlock()
+				replicatedTo = append(replicatedTo, target.Name)
+				return nil
+			},
+		},
+	}
+
+	selector, err := labels.Parse("team=a")
+	assert.NoError(t, err)
+
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"}}
+	assert.NoError(t, r.replicateResourceToMatchingNamespacesByLabel(context.Background(), source, selector))
+
+	// namespaces that are not cached yet are left to NamespaceAdded
+	assert.Equal(t, []string{"team-a"}, replicatedTo)
+	for _, action := range client.Actions() {
+		assert.False(t, action.Matches("list", "namespaces"), "namespaces must not be listed from the API")
+	}
+}
@@ -0,0 +1,141 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+)
+
+// replicationOrder is shared by all replicators, since the source referenced by a ReplicateAfter annotation is
+// usually of a different kind
+var replicationOrder = ReplicationOrder{}
+
+// ReplicationOrder keeps track of the namespaces that sources have been replicated to, so that sources with a
+// ReplicateAfter annotation can wait for the referenced source to be replicated first
+type ReplicationOrder struct {
+	lock sync.Mutex
+
+	// replicated maps source keys to the namespaces they have been replicated to, and these to the kinds of
+	// the sources with that key
+	replicated map[string]map[string]map[string]struct{}
+
+	// waiting maps source keys and namespaces to the replications that wait for the source, by kind and key of
+	// the waiting source
+	waiting map[string]map[string]map[string]func()
+}
+
+// Replicated records that a source has been replicated to a namespace and starts the replications that waited
+// for it
+func (o *ReplicationOrder) Replicated(kind string, sourceKey string, namespace string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.replicated == nil {
+		o.replicated = make(map[string]map[string]map[string]struct{})
+	}
+	if o.replicated[sourceKey] == nil {
+		o.replicated[sourceKey] = make(map[string]map[string]struct{})
+	}
+	if o.replicated[sourceKey][namespace] == nil {
+		o.replicated[sourceKey][namespace] = make(map[string]struct{})
+	}
+	o.replicated[sourceKey][namespace][kind] = struct{}{}
+
+	for waiter, callback := range o.waiting[sourceKey][namespace] {
+		if waiter == kind+"/"+sourceKey {
+			continue
+		}
+		delete(o.waiting[sourceKey][namespace], waiter)
+		go callback()
+	}
+}
+
+// Forget removes all records of a deleted source
+func (o *ReplicationOrder) Forget(kind string, sourceKey string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for _, kinds := range o.replicated[sourceKey] {
+		delete(kinds, kind)
+	}
+
+	for _, waiters := range o.waiting {
+		for _, callbacks := range waiters {
+			delete(callbacks, kind+"/"+sourceKey)
+		}
+	}
+}
+
+// WaitFor checks if the source "after" has been replicated to the namespace by a replicator of another kind or
+// for another source than the waiting one. If it has not, callback is called once it is.
+func (o *ReplicationOrder) WaitFor(kind string, sourceKey string, after string, namespace string, callback func()) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for otherKind := range o.replicated[after][namespace] {
+		if otherKind != kind || after != sourceKey {
+			return true
+		}
+	}
+
+	if o.waiting == nil {
+		o.waiting = make(map[string]map[string]map[string]func())
+	}
+	if o.waiting[after] == nil {
+		o.waiting[after] = make(map[string]map[string]func())
+	}
+	if o.waiting[after][namespace] == nil {
+		o.waiting[after][namespace] = make(map[string]func())
+	}
+	o.waiting[after][namespace][kind+"/"+sourceKey] = callback
+
+	return false
+}
+
+// mayReplicateInOrder checks if the source referenced by the ReplicateAfter annotation of obj has already been
+// replicated to the namespace (or lives in it). If it has not, obj is replicated to the namespace once it is.
+func (r *GenericReplicator) mayReplicateInOrder(obj interface{}, namespace v1.Namespace) bool {
+	after, ok := MustGetObject(obj).GetAnnotations()[ReplicateAfter]
+	if !ok {
+		return true
+	}
+
+	if afterNamespace, _, _ := strings.Cut(after, "/"); afterNamespace == namespace.Name {
+		return true
+	}
+
+	sourceKey := MustGetKey(obj)
+	if replicationOrder.WaitFor(r.Kind, sourceKey, after, namespace.Name, func() { r.replicateInOrder(sourceKey, namespace) }) {
+		return true
+	}
+
+	log.WithField("kind", r.Kind).WithField("source", sourceKey).
+		Infof("not replicating to %s before %s has been replicated there", namespace.Name, after)
+	return false
+}
+
+// replicateInOrder replicates a source to a namespace after the source it waited for has been replicated there
+func (r *GenericReplicator) replicateInOrder(sourceKey string, namespace v1.Namespace) {
+	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
+
+	obj, exists, err := r.Store.GetByKey(sourceKey)
+	if err != nil {
+		logger.WithError(err).Error("error fetching object from store")
+		return
+	} else if !exists {
+		return
+	}
+
+	ctx, span := r.startSpan(context.Background(), "ReplicateInOrder",
+		attribute.String("replicator.source", sourceKey), attribute.String("replicator.namespace", namespace.Name))
+	defer span.End()
+	logger = logger.WithField(reconciliationField, ReconciliationID(ctx))
+
+	if _, err := r.replicateResourceToNamespaces(ctx, obj, []v1.Namespace{namespace}); err != nil {
+		logger.WithError(err).Errorf("could not replicate to %s: %+v", namespace.Name, err)
+	}
+}
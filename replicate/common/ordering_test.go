@@ -0,0 +1,41 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicationOrder(t *testing.T) {
+	order := ReplicationOrder{}
+	called := make(chan struct{}, 1)
+	callback := func() { called <- struct{}{} }
+
+	assert.False(t, order.WaitFor("RoleBinding", "default/admin", "default/admin", "team-a", callback))
+
+	// the waiting source itself does not satisfy the order
+	order.Replicated("RoleBinding", "default/admin", "team-a")
+	assert.False(t, order.WaitFor("RoleBinding", "default/admin", "default/admin", "team-a", callback))
+
+	order.Replicated("Role", "default/admin", "team-a")
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("waiting replication was not started")
+	}
+
+	assert.True(t, order.WaitFor("RoleBinding", "default/admin", "default/admin", "team-a", callback))
+	assert.False(t, order.WaitFor("RoleBinding", "default/admin", "default/admin", "team-b", callback))
+
+	order.Forget("Role", "default/admin")
+	assert.False(t, order.WaitFor("RoleBinding", "default/admin", "default/admin", "team-a", callback))
+
+	order.Forget("RoleBinding", "default/admin")
+	order.Replicated("Role", "default/admin", "team-b")
+	select {
+	case <-called:
+		t.Fatal("replication of a deleted source was started")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
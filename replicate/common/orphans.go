@@ -0,0 +1,44 @@
+package common
+
+// OrphanCounter is implemented by replicators that can report the number of replicas whose source is gone
+type OrphanCounter interface {
+	// OrphanCounts returns the kind of the replicator and the number of orphaned replicas by ReplicationModePush and
+	// ReplicationModePull, or false if they can't be determined yet
+	OrphanCounts() (string, map[string]int, bool)
+}
+
+// OrphanCounts counts the cached replicas created in push mode and the targets with a replicate-from annotation
+// whose source does not exist (anymore), e.g. because replicas were retained when it was deleted. Nothing is
+// counted until the informers have synced, since sources might not have been cached yet.
+func (r *GenericReplicator) OrphanCounts() (string, map[string]int, bool) {
+	if !r.hasSynced() {
+		return r.Kind, nil, false
+	}
+
+	counts := map[string]int{ReplicationModePush: 0, ReplicationModePull: 0}
+	sourceExists := func(sourceKey string) bool {
+		_, exists, err := r.Store.GetByKey(sourceKey)
+		return err != nil || exists
+	}
+
+	count := func(obj interface{}) {
+		annotations := MustGetObject(obj).GetAnnotations()
+		if source, ok := annotations[ReplicatedSourceAnnotation]; ok && !sourceExists(source) {
+			counts[ReplicationModePush]++
+		}
+	}
+	for _, obj := range r.TargetStore.List() {
+		count(obj)
+	}
+	for _, obj := range r.Store.List() {
+		if r.targetController != nil {
+			// replicas that are not labeled as such are in the regular cache
+			count(obj)
+		}
+		if source, ok := MustGetObject(obj).GetAnnotations()[ReplicateFromAnnotation]; ok && !sourceExists(source) {
+			counts[ReplicationModePull]++
+		}
+	}
+
+	return r.Kind, counts, true
+}
@@ -0,0 +1,64 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestOrphanCounts(t *testing.T) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, Indexers)
+	for _, obj := range []interface{}{
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "source",
+			Namespace:   "team-a",
+			Annotations: map[string]string{ReplicatedSourceAnnotation: "default/source"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "deleted",
+			Namespace:   "team-a",
+			Annotations: map[string]string{ReplicatedSourceAnnotation: "default/deleted"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "copy",
+			Namespace:   "team-b",
+			Annotations: map[string]string{ReplicateFromAnnotation: "default/source"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "dangling",
+			Namespace:   "team-b",
+			Annotations: map[string]string{ReplicateFromAnnotation: "default/deleted"},
+		}},
+	} {
+		require.NoError(t, store.Add(obj))
+	}
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: store}
+
+	kind, counts, ok := r.OrphanCounts()
+	assert.True(t, ok)
+	assert.Equal(t, "Secret", kind)
+	assert.Equal(t, map[string]int{ReplicationModePush: 1, ReplicationModePull: 1}, counts)
+
+	expected := `
+# HELP kubernetes_replicator_orphaned_replicas Number of replicas whose source does not exist, by the mode in which they were replicated
+# TYPE kubernetes_replicator_orphaned_replicas gauge
+kubernetes_replicator_orphaned_replicas{kind="Secret",mode="pull"} 1
+kubernetes_replicator_orphaned_replicas{kind="Secret",mode="push"} 1
+`
+	collector := &CacheCollector{Replicators: []Replicator{r}}
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "kubernetes_replicator_orphaned_replicas"))
+
+	// orphans are not reported until the informers have synced
+	r.Controller = &unsyncedController{}
+	_, _, ok = r.OrphanCounts()
+	assert.False(t, ok)
+	assert.Zero(t, testutil.CollectAndCount(collector, "kubernetes_replicator_orphaned_replicas"))
+}
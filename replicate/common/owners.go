@@ -0,0 +1,109 @@
+package common
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Values of the KeepOwnerReferences annotation besides "true" and "false"
+const (
+	// OwnerReferencesRewrite points the owner references of replicas to the equally named owners in the target
+	// namespace
+	OwnerReferencesRewrite = "rewrite"
+)
+
+// TargetOwnerReferences returns the owner references that a replica of the source in the given namespace should
+// have, according to the KeepOwnerReferences annotation of the source. If the annotation is not set, the owner
+// references of the replica are left as they are and false is returned.
+func (r *GenericReplicator) TargetOwnerReferences(source metav1.Object, namespace string) ([]metav1.OwnerReference, bool) {
+	switch source.GetAnnotations()[KeepOwnerReferences] {
+	case "true":
+		return source.GetOwnerReferences(), true
+	case OwnerReferencesRewrite:
+		return r.rewriteOwnerReferences(source, namespace), true
+	default:
+		return nil, false
+	}
+}
+
+// rewriteOwnerReferences replaces the UIDs of the source's owner references with the UIDs of the owners with the
+// same kind and name in the target namespace. References to owners that do not exist in the target namespace
+// are dropped, since the garbage collector would delete the replica otherwise.
+func (r *GenericReplicator) rewriteOwnerReferences(source metav1.Object, namespace string) []metav1.OwnerReference {
+	logger := log.WithField("kind", r.Kind).WithField("source", MustGetKey(source)).WithField("target", namespace)
+
+	var result []metav1.OwnerReference
+	for _, ref := range source.GetOwnerReferences() {
+		owner, err := r.lookupOwner(ref, namespace)
+		if err != nil {
+			logger.WithError(err).Warnf("could not look up owner %s %s, dropping owner reference", ref.Kind, ref.Name)
+			continue
+		} else if owner == nil {
+			logger.Debugf("owner %s %s does not exist in target namespace, dropping owner reference", ref.Kind, ref.Name)
+			continue
+		}
+
+		rewritten := ref
+		rewritten.UID = owner.GetUID()
+		result = append(result, rewritten)
+	}
+
+	return result
+}
+
+// lookupOwner fetches the metadata of the object an owner reference points to from the given namespace. It
+// returns nil if no such object exists.
+func (r *GenericReplicator) lookupOwner(ref metav1.OwnerReference, namespace string) (metav1.Object, error) {
+	if r.DynamicClient == nil {
+		return nil, errors.New("no dynamic client configured")
+	}
+
+	resource, err := r.namespacedResource(ref.APIVersion, ref.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := r.DynamicClient.Resource(resource).Namespace(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "could not get %s %s/%s", ref.Kind, namespace, ref.Name)
+	}
+
+	return owner, nil
+}
+
+// namespacedResource resolves the resource of a namespaced kind using the discovery API. Resolved resources
+// are cached, since owners usually are of a handful of kinds.
+func (r *GenericReplicator) namespacedResource(apiVersion string, kind string) (schema.GroupVersionResource, error) {
+	cacheKey := apiVersion + "/" + kind
+	if resource, ok := r.namespacedResources.Load(cacheKey); ok {
+		return resource, nil
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, errors.Wrapf(err, "invalid API version %q", apiVersion)
+	}
+
+	resources, err := r.Client.Discovery().ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, errors.Wrapf(err, "could not discover resources of %s", apiVersion)
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Kind == kind && resource.Namespaced && !strings.Contains(resource.Name, "/") {
+			gvr := gv.WithResource(resource.Name)
+			r.namespacedResources.Store(cacheKey, gvr)
+			return gvr, nil
+		}
+	}
+
+	return schema.GroupVersionResource{}, errors.Errorf("%s is not a namespaced kind of %s", kind, apiVersion)
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTargetOwnerReferencesRewrite(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+				{Name: "deployments/scale", Kind: "Scale", Namespaced: true},
+			},
+		},
+	}
+
+	owner := &unstructured.Unstructured{}
+	owner.SetAPIVersion("apps/v1")
+	owner.SetKind("Deployment")
+	owner.SetNamespace("target")
+	owner.SetName("web")
+	owner.SetUID("target-uid")
+
+	repl := GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{
+			Client:        client,
+			DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), owner),
+		},
+	}
+
+	source := &metav1.ObjectMeta{
+		Namespace: "source",
+		Name:      "config",
+		Annotations: map[string]string{
+			KeepOwnerReferences: OwnerReferencesRewrite,
+		},
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "source-uid"},
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker", UID: "source-uid-2"},
+		},
+	}
+
+	refs, ok := repl.TargetOwnerReferences(source, "target")
+	assert.True(t, ok)
+	assert.Equal(t, []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "target-uid"},
+	}, refs)
+
+	refs, ok = repl.TargetOwnerReferences(source, "other")
+	assert.True(t, ok)
+	assert.Empty(t, refs)
+}
+
+func TestTargetOwnerReferences(t *testing.T) {
+	repl := GenericReplicator{}
+	source := &metav1.ObjectMeta{
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "source-uid"},
+		},
+	}
+
+	_, ok := repl.TargetOwnerReferences(source, "target")
+	assert.False(t, ok)
+
+	source.Annotations = map[string]string{KeepOwnerReferences: "true"}
+	refs, ok := repl.TargetOwnerReferences(source, "target")
+	assert.True(t, ok)
+	assert.Equal(t, source.OwnerReferences, refs)
+}
@@ -1,5 +1,12 @@
 package common
 
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
 // JSONPatchOperation is a struct that defines PATCH operations on
 // a JSON structure.
 type JSONPatchOperation struct {
@@ -7,3 +14,24 @@ type JSONPatchOperation struct {
 	Path      string      `json:"path"`
 	Value     interface{} `json:"value,omitempty"`
 }
+
+// StrategicMergePatch computes a strategic merge patch that turns the original object into the modified one,
+// so that only the changed keys and annotations of a replica are sent to the API server instead of the whole
+// object. Both objects must be of the same (built-in) type.
+func StrategicMergePatch(original interface{}, modified interface{}) ([]byte, error) {
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode original object")
+	}
+
+	modifiedJSON, err := json.Marshal(modified)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode modified object")
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(originalJSON, modifiedJSON, modified)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create patch")
+	}
+	return patch, nil
+}
@@ -0,0 +1,33 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStrategicMergePatch(t *testing.T) {
+	original := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "replica",
+			Namespace:   "target",
+			Annotations: map[string]string{ReplicatedAtAnnotation: "2024-01-01T00:00:00Z"},
+		},
+		Data: map[string][]byte{"unchanged": []byte("large value"), "changed": []byte("old"), "removed": []byte("x")},
+	}
+
+	modified := original.DeepCopy()
+	modified.Annotations[ReplicatedAtAnnotation] = "2024-01-02T00:00:00Z"
+	modified.Data["changed"] = []byte("new")
+	delete(modified.Data, "removed")
+
+	patch, err := StrategicMergePatch(original, modified)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"metadata": {"annotations": {"replicator.v1.mittwald.de/replicated-at": "2024-01-02T00:00:00Z"}},
+		"data": {"changed": "bmV3", "removed": null}
+	}`, string(patch))
+}
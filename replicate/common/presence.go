@@ -0,0 +1,163 @@
+package common
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RequiredResource is a resource that has to exist in a namespace for a source to be replicated into it, as
+// configured by the ReplicateToNamespacesWith annotation
+type RequiredResource struct {
+	// Resource is the resource type, as accepted by kubectl, e.g. "deployment" or "deployments.apps"
+	Resource string
+
+	// Name is the name of the required object; if empty, any object matching Selector is required
+	Name string
+
+	// Selector selects the required objects if no name is given
+	Selector labels.Selector
+}
+
+// ParseRequiredResource parses the value of the ReplicateToNamespacesWith annotation, which is either
+// "<resource>/<name>" or "<resource>:<label selector>"
+func ParseRequiredResource(value string) (RequiredResource, error) {
+	value = strings.TrimSpace(value)
+
+	if resource, name, ok := strings.Cut(value, "/"); ok {
+		resource, name = strings.TrimSpace(resource), strings.TrimSpace(name)
+		if resource == "" || name == "" {
+			return RequiredResource{}, errors.Errorf("expected '<resource>/<name>', got %q", value)
+		}
+		return RequiredResource{Resource: resource, Name: name}, nil
+	}
+
+	if resource, selector, ok := strings.Cut(value, ":"); ok {
+		resource = strings.TrimSpace(resource)
+		if resource == "" {
+			return RequiredResource{}, errors.Errorf("expected '<resource>:<label selector>', got %q", value)
+		}
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return RequiredResource{}, errors.Wrapf(err, "invalid label selector %q", selector)
+		}
+		return RequiredResource{Resource: resource, Selector: parsed}, nil
+	}
+
+	return RequiredResource{}, errors.Errorf("expected '<resource>/<name>' or '<resource>:<label selector>', got %q", value)
+}
+
+// namespaceHasRequiredResource checks if the namespace contains the resource required by the
+// ReplicateToNamespacesWith annotation of the source. Sources without that annotation may be replicated into
+// any namespace.
+func (r *GenericReplicator) namespaceHasRequiredResource(obj interface{}, namespace v1.Namespace) bool {
+	value, ok := MustGetObject(obj).GetAnnotations()[ReplicateToNamespacesWith]
+	if !ok {
+		return true
+	}
+
+	logger := log.WithField("kind", r.Kind).WithField("source", MustGetKey(obj)).WithField("target", namespace.Name)
+
+	required, err := ParseRequiredResource(value)
+	if err != nil {
+		logger.WithError(err).Errorf("invalid %s annotation", ReplicateToNamespacesWith)
+		return false
+	}
+
+	found, err := r.lookupRequiredResource(required, namespace.Name)
+	if err != nil {
+		logger.WithError(err).Warnf("could not look up %s; not replicating", value)
+		return false
+	} else if !found {
+		logger.Debugf("%s does not exist in namespace %s; not replicating", value, namespace.Name)
+	}
+
+	return found
+}
+
+// lookupRequiredResource checks if an object matching the required resource exists in the given namespace
+func (r *GenericReplicator) lookupRequiredResource(required RequiredResource, namespace string) (bool, error) {
+	if r.DynamicClient == nil {
+		return false, errors.New("no dynamic client configured")
+	}
+
+	resource, err := r.namedResource(required.Resource)
+	if err != nil {
+		return false, err
+	}
+
+	client := r.DynamicClient.Resource(resource).Namespace(namespace)
+
+	if required.Name != "" {
+		_, err := client.Get(context.TODO(), required.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, errors.Wrapf(err, "could not get %s %s/%s", required.Resource, namespace, required.Name)
+		}
+		return true, nil
+	}
+
+	list, err := client.List(context.TODO(), metav1.ListOptions{LabelSelector: required.Selector.String(), Limit: 1})
+	if err != nil {
+		return false, errors.Wrapf(err, "could not list %s in %s", required.Resource, namespace)
+	}
+
+	return len(list.Items) > 0, nil
+}
+
+// namedResource resolves a namespaced resource given like in kubectl, i.e. by its plural, singular or short
+// name, or its kind, optionally followed by the API group ("deployments.apps"). Resolved resources are cached.
+func (r *GenericReplicator) namedResource(name string) (schema.GroupVersionResource, error) {
+	cacheKey := "name:" + strings.ToLower(name)
+	if resource, ok := r.namespacedResources.Load(cacheKey); ok {
+		return resource, nil
+	}
+
+	resourceName, group, _ := strings.Cut(strings.ToLower(name), ".")
+
+	groups, lists, err := r.Client.Discovery().ServerGroupsAndResources()
+	if err != nil && len(lists) == 0 {
+		return schema.GroupVersionResource{}, errors.Wrap(err, "could not discover resources")
+	}
+
+	preferred := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		preferred[g.PreferredVersion.GroupVersion] = struct{}{}
+	}
+
+	for _, list := range lists {
+		if _, ok := preferred[list.GroupVersion]; !ok {
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil || (group != "" && gv.Group != group) {
+			continue
+		}
+
+		for _, resource := range list.APIResources {
+			if !resource.Namespaced || strings.Contains(resource.Name, "/") {
+				continue
+			}
+
+			names := append([]string{resource.Name, resource.SingularName, strings.ToLower(resource.Kind)}, resource.ShortNames...)
+			for _, candidate := range names {
+				if candidate == resourceName {
+					gvr := gv.WithResource(resource.Name)
+					r.namespacedResources.Store(cacheKey, gvr)
+					return gvr, nil
+				}
+			}
+		}
+	}
+
+	return schema.GroupVersionResource{}, errors.Errorf("unknown namespaced resource %q", name)
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseRequiredResource(t *testing.T) {
+	required, err := ParseRequiredResource("deployment/my-app")
+	assert.NoError(t, err)
+	assert.Equal(t, "deployment", required.Resource)
+	assert.Equal(t, "my-app", required.Name)
+
+	required, err = ParseRequiredResource("deployments.apps:app=my-app")
+	assert.NoError(t, err)
+	assert.Equal(t, "deployments.apps", required.Resource)
+	assert.Equal(t, "app=my-app", required.Selector.String())
+
+	for _, invalid := range []string{"deployment", "deployment/", "/my-app", ":app=my-app", "deployment:app in (a"} {
+		_, err := ParseRequiredResource(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestNamespaceHasRequiredResource(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", SingularName: "deployment", ShortNames: []string{"deploy"}, Kind: "Deployment", Namespaced: true},
+			},
+		},
+	}
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetNamespace("with-app")
+	deployment.SetName("my-app")
+	deployment.SetLabels(map[string]string{"app": "my-app"})
+
+	repl := GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{
+			Client: client,
+			DynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList"},
+				deployment),
+		},
+	}
+
+	withApp := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "with-app"}}
+	withoutApp := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "without-app"}}
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "source"}}
+
+	assert.True(t, repl.namespaceHasRequiredResource(source, withoutApp))
+
+	for _, value := range []string{"deployment/my-app", "deploy/my-app", "deployments.apps/my-app", "deployment:app=my-app"} {
+		source.Annotations = map[string]string{ReplicateToNamespacesWith: value}
+		assert.True(t, repl.namespaceHasRequiredResource(source, withApp), value)
+		assert.False(t, repl.namespaceHasRequiredResource(source, withoutApp), value)
+	}
+
+	for _, value := range []string{"deployment/other-app", "deployment:app=other-app", "deployments.batch/my-app", "statefulset/my-app"} {
+		source.Annotations = map[string]string{ReplicateToNamespacesWith: value}
+		assert.False(t, repl.namespaceHasRequiredResource(source, withApp), value)
+	}
+}
@@ -0,0 +1,43 @@
+package common
+
+import "time"
+
+// ReplicatorStatus describes the readiness of a replicator. Synced is not set by Status, since replicators may
+// override Synced.
+type ReplicatorStatus struct {
+	Kind             string     `json:"kind"`
+	Synced           bool       `json:"synced"`
+	LastReplication  *time.Time `json:"lastReplication,omitempty"`
+	PendingRetries   int        `json:"pendingRetries"`
+	PendingDebounces int        `json:"pendingDebounces"`
+}
+
+// StatusReporter is implemented by replicators that can report details about their readiness
+type StatusReporter interface {
+	Status() ReplicatorStatus
+}
+
+// Status reports when the replicator last wrote a replica successfully, and how many replications are waiting to be
+// retried or for their source to settle
+func (r *GenericReplicator) Status() ReplicatorStatus {
+	status := ReplicatorStatus{
+		Kind:             r.Kind,
+		PendingRetries:   r.retries.Len(),
+		PendingDebounces: r.debounceTimers.Len(),
+	}
+
+	if last := r.lastReplication.Load(); last != 0 {
+		lastReplication := time.Unix(0, last).UTC()
+		status.LastReplication = &lastReplication
+	}
+
+	return status
+}
+
+// NamespaceQueueLength returns the number of namespace events that are waiting to be processed by the replicators
+func NamespaceQueueLength() int {
+	if namespaceWatcher.queue == nil {
+		return 0
+	}
+	return namespaceWatcher.queue.len()
+}
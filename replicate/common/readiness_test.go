@@ -0,0 +1,27 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestStatus(t *testing.T) {
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}}
+	r.retries.Store("source/a->target", &pendingRetry{attempts: 1, timer: time.NewTimer(time.Hour)})
+
+	status := r.Status()
+	assert.Equal(t, "Secret", status.Kind)
+	assert.Equal(t, 1, status.PendingRetries)
+	assert.Zero(t, status.PendingDebounces)
+	assert.Nil(t, status.LastReplication)
+
+	r.countReplication(&v1.Secret{}, nil)
+
+	status = r.Status()
+	if assert.NotNil(t, status.LastReplication) {
+		assert.WithinDuration(t, time.Now(), *status.LastReplication, time.Minute)
+	}
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// reconciliationField is the log field holding the reconciliation ID
+const reconciliationField = "reconciliation"
+
+// reconciliationIDKey is the context key of the reconciliation ID
+type reconciliationIDKey struct{}
+
+// withReconciliationID identifies the reconciliation that is started by an event (e.g. a changed source or a new
+// namespace) in the given context, so that all log lines of the resulting replications can be gathered. If the
+// event is traced, the ID of its trace is used, which also allows to find the trace for the log lines.
+func withReconciliationID(ctx context.Context, span trace.Span) context.Context {
+	if spanContext := span.SpanContext(); spanContext.HasTraceID() {
+		return context.WithValue(ctx, reconciliationIDKey{}, spanContext.TraceID().String())
+	}
+
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return context.WithValue(ctx, reconciliationIDKey{}, hex.EncodeToString(id))
+}
+
+// ReconciliationID returns the ID of the reconciliation in the given context, or an empty string
+func ReconciliationID(ctx context.Context) string {
+	id, _ := ctx.Value(reconciliationIDKey{}).(string)
+	return id
+}
+
+// logger returns a logger for the replicator that adds the ID of the reconciliation in the given context (if any)
+// to all log lines
+func (r *GenericReplicator) logger(ctx context.Context) *log.Entry {
+	logger := log.WithField("kind", r.Kind)
+	if id := ReconciliationID(ctx); id != "" {
+		logger = logger.WithField(reconciliationField, id)
+	}
+	return logger
+}
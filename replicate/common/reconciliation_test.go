@@ -0,0 +1,45 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestReconciliationID(t *testing.T) {
+	assert.Empty(t, ReconciliationID(context.Background()))
+
+	// without tracing, a random ID is generated
+	untraced := trace.SpanFromContext(context.Background())
+	first := ReconciliationID(withReconciliationID(context.Background(), untraced))
+	second := ReconciliationID(withReconciliationID(context.Background(), untraced))
+	assert.Len(t, first, 16)
+	assert.NotEqual(t, first, second)
+
+	// with tracing, the trace ID is used
+	_, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "ResourceAdded")
+	defer span.End()
+	assert.Equal(t, span.SpanContext().TraceID().String(), ReconciliationID(withReconciliationID(context.Background(), span)))
+}
+
+func TestReconciliationIsInherited(t *testing.T) {
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}}
+
+	ctx, parent := r.startSpan(context.Background(), "ResourceAdded")
+	defer parent.End()
+	childCtx, child := r.startSpan(ctx, "ReplicateObjectTo")
+	defer child.End()
+
+	id := ReconciliationID(ctx)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, id, ReconciliationID(childCtx))
+
+	logger := r.logger(childCtx)
+	assert.Equal(t, "Secret", logger.Data["kind"])
+	assert.Equal(t, id, logger.Data[reconciliationField])
+
+	assert.NotContains(t, r.logger(context.Background()).Data, reconciliationField)
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// minResyncPeriod is the shortest resync period that can be configured per source, to protect the API server
+const minResyncPeriod = 10 * time.Second
+
+// ParseResyncPeriod parses the value of the ResyncPeriod annotation, e.g. "1m"
+func ParseResyncPeriod(value string) (time.Duration, error) {
+	period, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid duration %q", value)
+	}
+	if period < minResyncPeriod {
+		return 0, errors.Errorf("resync period %s is shorter than the minimum of %s", period, minResyncPeriod)
+	}
+	return period, nil
+}
+
+// ParseResyncPeriods parses per-kind resync periods of the form "Secret=10m,ConfigMap=1h". Kinds are matched
+// case-insensitively.
+func ParseResyncPeriods(periods string) (map[string]time.Duration, error) {
+	result := make(map[string]time.Duration)
+
+	for _, entry := range strings.Split(periods, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		kind, period, ok := strings.Cut(entry, "=")
+		kind, period = strings.TrimSpace(kind), strings.TrimSpace(period)
+		if !ok || kind == "" {
+			return nil, errors.Errorf("invalid resync period %q: expected '<kind>=<duration>'", entry)
+		}
+
+		duration, err := time.ParseDuration(period)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid resync period %q", entry)
+		}
+
+		result[strings.ToLower(kind)] = duration
+	}
+
+	return result, nil
+}
+
+// kindResyncPeriod returns the resync period of the informer of the configured kind: the period configured for
+// the kind in ResyncPeriods (or the global ResyncPeriod), extended by a random fraction of up to ResyncJitter,
+// so that the informers of different kinds don't resync at the same time
+func kindResyncPeriod(config ReplicatorConfig) time.Duration {
+	period := config.ResyncPeriod
+	if kindPeriod, ok := config.ResyncPeriods[strings.ToLower(config.Kind)]; ok {
+		period = kindPeriod
+	}
+
+	if period <= 0 || config.ResyncJitter <= 0 {
+		return period
+	}
+	return wait.Jitter(period, config.ResyncJitter)
+}
+
+// GetResyncPeriod returns the resync period of the source as configured by the ResyncPeriod annotation. It
+// returns false if the source is resynced with the global resync period only.
+func GetResyncPeriod(source metav1.Object) (time.Duration, bool) {
+	value, ok := source.GetAnnotations()[ResyncPeriod]
+	if !ok {
+		return 0, false
+	}
+
+	period, err := ParseResyncPeriod(value)
+	if err != nil {
+		return 0, false
+	}
+	return period, true
+}
+
+// scheduleResync (re)starts the timer that resyncs the source after its own resync period. Any previously
+// scheduled resync of the source is cancelled, since the source has just been processed.
+func (r *GenericReplicator) scheduleResync(source metav1.Object) {
+	sourceKey := MustGetKey(source)
+	r.cancelResync(sourceKey)
+
+	period, ok := GetResyncPeriod(source)
+	if !ok {
+		return
+	}
+
+	r.resyncTimers.Store(sourceKey, time.AfterFunc(period, func() { r.resync(sourceKey) }))
+}
+
+// cancelResync stops the timer that resyncs the source with the given key, if any
+func (r *GenericReplicator) cancelResync(sourceKey string) {
+	if timer, ok := r.resyncTimers.Load(sourceKey); ok {
+		timer.Stop()
+		r.resyncTimers.Delete(sourceKey)
+	}
+}
+
+// resync processes the source with the given key again, just like the informer does on a regular resync. The
+// source is fetched from the store only once it is no longer processed otherwise, so that the latest version of
+// it is replicated.
+func (r *GenericReplicator) resync(sourceKey string) {
+	defer r.objectLocks.lock(sourceKey)()
+
+	obj, exists, err := r.Store.GetByKey(sourceKey)
+	if err != nil {
+		log.WithField("kind", r.Kind).WithField("source", sourceKey).WithError(err).Error("error fetching object from store")
+		return
+	} else if !exists {
+		return
+	}
+
+	log.WithField("kind", r.Kind).WithField("source", sourceKey).Debugf("resyncing %s %s", r.Kind, sourceKey)
+	r.resourceAdded(obj)
+}
@@ -0,0 +1,101 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestResyncPeriod(t *testing.T) {
+	period, err := ParseResyncPeriod("1m")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, period)
+
+	_, err = ParseResyncPeriod("1s")
+	assert.Error(t, err)
+
+	_, err = ParseResyncPeriod("soon")
+	assert.Error(t, err)
+
+	source := &metav1.ObjectMeta{Namespace: "ns", Name: "credentials"}
+	_, ok := GetResyncPeriod(source)
+	assert.False(t, ok)
+
+	r := &GenericReplicator{}
+	r.scheduleResync(source)
+	assert.Equal(t, 0, r.resyncTimers.Len())
+
+	source.Annotations = map[string]string{ResyncPeriod: "2m"}
+	period, ok = GetResyncPeriod(source)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, period)
+
+	r.scheduleResync(source)
+	r.scheduleResync(source)
+	assert.Equal(t, 1, r.resyncTimers.Len())
+
+	r.cancelResync("ns/credentials")
+	assert.Equal(t, 0, r.resyncTimers.Len())
+}
+
+func TestParseResyncPeriods(t *testing.T) {
+	periods, err := ParseResyncPeriods("Secret=10m, configmap=1h,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"secret": 10 * time.Minute, "configmap": time.Hour}, periods)
+
+	_, err = ParseResyncPeriods("Secret")
+	assert.Error(t, err)
+	_, err = ParseResyncPeriods("Secret=often")
+	assert.Error(t, err)
+}
+
+func TestKindResyncPeriod(t *testing.T) {
+	config := ReplicatorConfig{
+		Kind:          "Secret",
+		ResyncPeriod:  30 * time.Minute,
+		ResyncPeriods: map[string]time.Duration{"secret": 10 * time.Minute},
+	}
+	assert.Equal(t, 10*time.Minute, kindResyncPeriod(config))
+
+	config.Kind = "ConfigMap"
+	assert.Equal(t, 30*time.Minute, kindResyncPeriod(config))
+
+	config.ResyncJitter = 0.1
+	period := kindResyncPeriod(config)
+	assert.GreaterOrEqual(t, period, 30*time.Minute)
+	assert.LessOrEqual(t, period, 33*time.Minute)
+}
+
+func TestResyncWaitsForOngoingReplication(t *testing.T) {
+	namespaceWatcher.NamespaceStore = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default", ResourceVersion: "1"}}))
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, Store: store, TargetStore: store}
+
+	// the informer is processing the source
+	unlock := r.objectLocks.lock("default/source")
+
+	done := make(chan struct{})
+	go func() {
+		r.resync("default/source")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("resync did not wait for the ongoing replication")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("resync did not run once the ongoing replication finished")
+	}
+}
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+)
+
+// pendingRetry is a scheduled retry of a failed replication from a source to a target
+type pendingRetry struct {
+	attempts int
+	timer    *time.Timer
+}
+
+// RetryBackoff returns the delay before the given (1-based) retry of a failed replication. The delay starts at
+// initial and doubles with every attempt, up to max.
+func RetryBackoff(initial time.Duration, max time.Duration, attempt int) time.Duration {
+	delay := initial
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// requeue schedules retry to be called after an exponentially growing delay, since replicating the source to the
+// target (a namespace for push replication, or the key of a replica for pull replication) failed. A retry that
+// is already scheduled for the same pair is replaced. Nothing is retried if RetryBackoff is zero.
+func (r *GenericReplicator) requeue(sourceKey string, target string, retry func()) {
+	if r.RetryBackoff <= 0 {
+		return
+	}
+
+	key := sourceKey + "->" + target
+	attempts := 1
+	if previous, ok := r.retries.Load(key); ok {
+		previous.timer.Stop()
+		attempts = previous.attempts + 1
+	} else {
+		metricPendingRetries.WithLabelValues(r.Kind).Inc()
+	}
+
+	delay := RetryBackoff(r.RetryBackoff, r.MaxRetryBackoff, attempts)
+	log.WithField("kind", r.Kind).WithField("source", sourceKey).WithField("target", target).
+		Debugf("retrying replication of %s %s to %s in %s (attempt %d)", r.Kind, sourceKey, target, delay, attempts)
+
+	r.retries.Store(key, &pendingRetry{
+		attempts: attempts,
+		timer:    time.AfterFunc(delay, retry),
+	})
+}
+
+// forgetRetries resets the backoff of the given source and target after a successful replication
+func (r *GenericReplicator) forgetRetries(sourceKey string, target string) {
+	key := sourceKey + "->" + target
+	if previous, ok := r.retries.Load(key); ok {
+		previous.timer.Stop()
+		r.retries.Delete(key)
+		metricPendingRetries.WithLabelValues(r.Kind).Dec()
+	}
+}
+
+// requeueReplicationTo schedules a retry of the replication of the source with the given key into a namespace
+func (r *GenericReplicator) requeueReplicationTo(sourceKey string, namespace string) {
+	r.requeue(sourceKey, namespace, func() {
+		defer r.objectLocks.lock(sourceKey)()
+		logger := log.WithField("kind", r.Kind).WithField("source", sourceKey).WithField("target", namespace)
+
+		source, exists, err := r.Store.GetByKey(sourceKey)
+		if err != nil {
+			logger.WithError(err).Error("error fetching object from store")
+			return
+		} else if !exists {
+			r.forgetRetries(sourceKey, namespace)
+			return
+		}
+
+		ns, exists, err := r.getNamespace(namespace)
+		if err != nil {
+			logger.WithError(err).Error("error fetching namespace")
+			return
+		} else if !exists {
+			r.forgetRetries(sourceKey, namespace)
+			return
+		}
+
+		ctx, span := r.startSpan(context.Background(), "RetryReplication",
+			attribute.String("replicator.source", sourceKey), attribute.String("replicator.namespace", namespace))
+		defer span.End()
+		logger = logger.WithField(reconciliationField, ReconciliationID(ctx))
+
+		if _, err := r.replicateResourceToNamespaces(ctx, source, []v1.Namespace{*ns}); err != nil {
+			logger.WithError(err).Warn("retry failed")
+		}
+	})
+}
+
+// requeueReplicationFrom schedules a retry of the replication of a source into the target with the given key
+func (r *GenericReplicator) requeueReplicationFrom(sourceKey string, targetKey string) {
+	r.requeue(sourceKey, targetKey, func() {
+		defer r.objectLocks.lock(targetKey)()
+		logger := log.WithField("kind", r.Kind).WithField("source", sourceKey).WithField("target", targetKey)
+
+		target, exists, err := r.Store.GetByKey(targetKey)
+		if err != nil {
+			logger.WithError(err).Error("error fetching object from store")
+			return
+		} else if !exists || !slices.Contains(r.dependentsOf(sourceKey), targetKey) {
+			r.forgetRetries(sourceKey, targetKey)
+			return
+		}
+
+		ctx, span := r.startSpan(context.Background(), "RetryReplication",
+			attribute.String("replicator.source", sourceKey), attribute.String("replicator.target", targetKey))
+		defer span.End()
+		logger = logger.WithField(reconciliationField, ReconciliationID(ctx))
+
+		if err := r.resourceAddedReplicateFrom(ctx, sourceKey, target); err != nil {
+			logger.WithError(err).Warn("retry failed")
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, RetryBackoff(time.Second, time.Minute, 1))
+	assert.Equal(t, 2*time.Second, RetryBackoff(time.Second, time.Minute, 2))
+	assert.Equal(t, 16*time.Second, RetryBackoff(time.Second, time.Minute, 5))
+	assert.Equal(t, time.Minute, RetryBackoff(time.Second, time.Minute, 7))
+	assert.Equal(t, time.Minute, RetryBackoff(time.Second, time.Minute, 1000))
+}
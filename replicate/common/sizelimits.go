@@ -0,0 +1,68 @@
+package common
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ParseSizeLimits parses per-kind size limits of the form "Secret=256Ki,ConfigMap=1Mi". Kinds are matched
+// case-insensitively.
+func ParseSizeLimits(limits string) (map[string]int64, error) {
+	result := make(map[string]int64)
+
+	for _, entry := range strings.Split(limits, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		kind, size, ok := strings.Cut(entry, "=")
+		kind, size = strings.TrimSpace(kind), strings.TrimSpace(size)
+		if !ok || kind == "" {
+			return nil, errors.Errorf("invalid size limit %q: expected '<kind>=<size>'", entry)
+		}
+
+		quantity, err := resource.ParseQuantity(size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid size limit %q", entry)
+		}
+
+		result[strings.ToLower(kind)] = quantity.Value()
+	}
+
+	return result, nil
+}
+
+// withinSizeLimit checks if the source is small enough to be replicated according to the size limit configured
+// for the replicator's kind
+func (r *GenericReplicator) withinSizeLimit(source interface{}) bool {
+	limit, ok := r.MaxObjectSizes[strings.ToLower(r.Kind)]
+	if !ok {
+		return true
+	}
+
+	sourceKey := MustGetKey(source)
+	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
+
+	data, err := json.Marshal(source)
+	if err != nil {
+		logger.WithError(err).Error("could not determine object size")
+		return false
+	}
+
+	size := int64(len(data))
+	if size <= limit {
+		metricOversizedSources.DeleteLabelValues(r.Kind, sourceKey)
+		return true
+	}
+
+	logger.Warnf("not replicating %s: its size of %d bytes exceeds the limit of %d bytes", sourceKey, size, limit)
+	metricOversizedSources.WithLabelValues(r.Kind, sourceKey).Set(float64(size))
+	r.recordEvent(source, v1.EventTypeWarning, "SizeLimitExceeded",
+		"Not replicating %s: its size of %d bytes exceeds the limit of %d bytes", r.Kind, size, limit)
+	return false
+}
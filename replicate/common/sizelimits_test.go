@@ -0,0 +1,40 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSizeLimits(t *testing.T) {
+	limits, err := ParseSizeLimits("Secret=1Ki, configmap=1M")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"secret": 1024, "configmap": 1000000}, limits)
+
+	_, err = ParseSizeLimits("Secret")
+	assert.Error(t, err)
+
+	_, err = ParseSizeLimits("Secret=lots")
+	assert.Error(t, err)
+}
+
+func TestWithinSizeLimit(t *testing.T) {
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{
+		Kind:           "Secret",
+		MaxObjectSizes: map[string]int64{"secret": 200},
+	}}
+
+	source := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"},
+		Data:       map[string][]byte{"key": []byte("small")},
+	}
+	assert.True(t, r.withinSizeLimit(source))
+
+	source.Data["key"] = make([]byte, 1024)
+	assert.False(t, r.withinSizeLimit(source))
+
+	r.Kind = "ConfigMap"
+	assert.True(t, r.withinSizeLimit(source))
+}
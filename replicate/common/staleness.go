@@ -0,0 +1,74 @@
+package common
+
+import (
+	"time"
+)
+
+// StalenessReporter is implemented by replicators that can report targets which lag behind their source
+type StalenessReporter interface {
+	// StaleTargets returns the kind of the replicator and the number of stale targets by the key of their source,
+	// or false if they can't be determined yet
+	StaleTargets() (string, map[string]int, bool)
+}
+
+// StaleTargets counts the targets whose replicated-from-version has differed from the version of their source for
+// longer than StalenessThreshold, which catches replications that fail silently (e.g. because of RBAC changes in a
+// single namespace). The time since which a target lags is tracked from the first call that finds it lagging.
+// Sources that are replicated only once or require approval lag on purpose and are not considered.
+func (r *GenericReplicator) StaleTargets() (string, map[string]int, bool) {
+	if r.StalenessThreshold <= 0 || !r.hasSynced() {
+		return r.Kind, nil, false
+	}
+
+	now := time.Now()
+	stale := make(map[string]int)
+	lagging := make(map[string]struct{})
+
+	check := func(target interface{}, sourceKey string) {
+		source, exists, err := r.Store.GetByKey(sourceKey)
+		if err != nil || !exists {
+			return
+		}
+		sourceMeta := MustGetObject(source)
+		annotations := sourceMeta.GetAnnotations()
+		if annotations[ReplicateOnce] == "true" || annotations[RequiresApproval] == "true" {
+			return
+		}
+		if MustGetObject(target).GetAnnotations()[ReplicatedFromVersionAnnotation] == sourceMeta.GetResourceVersion() {
+			return
+		}
+
+		targetKey := MustGetKey(target)
+		lagging[targetKey] = struct{}{}
+		since, _ := r.laggingTargets.LoadOrStore(targetKey, now)
+		if now.Sub(since) > r.StalenessThreshold {
+			stale[sourceKey]++
+		}
+	}
+
+	for _, obj := range r.TargetStore.List() {
+		if source, ok := MustGetObject(obj).GetAnnotations()[ReplicatedSourceAnnotation]; ok {
+			check(obj, source)
+		}
+	}
+	for _, obj := range r.Store.List() {
+		annotations := MustGetObject(obj).GetAnnotations()
+		if source, ok := annotations[ReplicatedSourceAnnotation]; ok && r.targetController != nil {
+			// replicas that are not labeled as such are in the regular cache
+			check(obj, source)
+		}
+		if source, ok := annotations[ReplicateFromAnnotation]; ok {
+			check(obj, source)
+		}
+	}
+
+	// targets that have caught up (or are gone) start over when they lag again
+	r.laggingTargets.Range(func(targetKey string, _ time.Time) bool {
+		if _, ok := lagging[targetKey]; !ok {
+			r.laggingTargets.Delete(targetKey)
+		}
+		return true
+	})
+
+	return r.Kind, stale, true
+}
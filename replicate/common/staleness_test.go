@@ -0,0 +1,85 @@
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestStaleTargets(t *testing.T) {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, Indexers)
+	for _, obj := range []interface{}{
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default", ResourceVersion: "2"}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "once",
+			Namespace:   "default",
+			Annotations: map[string]string{ReplicateOnce: "true"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "source",
+			Namespace:   "up-to-date",
+			Annotations: map[string]string{ReplicatedSourceAnnotation: "default/source", ReplicatedFromVersionAnnotation: "2"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "source",
+			Namespace:   "lagging",
+			Annotations: map[string]string{ReplicatedSourceAnnotation: "default/source", ReplicatedFromVersionAnnotation: "1"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "copy",
+			Namespace:   "lagging",
+			Annotations: map[string]string{ReplicateFromAnnotation: "default/source", ReplicatedFromVersionAnnotation: "1"},
+		}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "once",
+			Namespace:   "lagging",
+			Annotations: map[string]string{ReplicatedSourceAnnotation: "default/once", ReplicatedFromVersionAnnotation: "1"},
+		}},
+	} {
+		require.NoError(t, store.Add(obj))
+	}
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret", StalenessThreshold: time.Minute}, Store: store, TargetStore: store}
+
+	// lagging targets are only stale after the threshold
+	kind, stale, ok := r.StaleTargets()
+	assert.True(t, ok)
+	assert.Equal(t, "Secret", kind)
+	assert.Empty(t, stale)
+	assert.Equal(t, 2, r.laggingTargets.Len())
+
+	r.laggingTargets.Range(func(targetKey string, since time.Time) bool {
+		r.laggingTargets.Store(targetKey, since.Add(-time.Hour))
+		return true
+	})
+
+	expected := `
+# HELP kubernetes_replicator_stale_targets Number of targets of a source that have lagged behind its version for longer than the staleness threshold
+# TYPE kubernetes_replicator_stale_targets gauge
+kubernetes_replicator_stale_targets{kind="Secret",source="default/source"} 2
+`
+	collector := &CacheCollector{Replicators: []Replicator{r}}
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "kubernetes_replicator_stale_targets"))
+
+	// targets that caught up are forgotten
+	require.NoError(t, store.Update(&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "copy",
+		Namespace:   "lagging",
+		Annotations: map[string]string{ReplicateFromAnnotation: "default/source", ReplicatedFromVersionAnnotation: "2"},
+	}}))
+	_, stale, _ = r.StaleTargets()
+	assert.Equal(t, map[string]int{"default/source": 1}, stale)
+	assert.Equal(t, 1, r.laggingTargets.Len())
+
+	// staleness is not tracked without a threshold
+	r.StalenessThreshold = 0
+	_, _, ok = r.StaleTargets()
+	assert.False(t, ok)
+}
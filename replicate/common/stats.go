@@ -0,0 +1,91 @@
+package common
+
+// ReplicatorStats describes the size of the internal caches of a replicator
+type ReplicatorStats struct {
+	Kind                     string `json:"kind"`
+	CachedObjects            int    `json:"cachedObjects"`
+	CachedTargets            int    `json:"cachedTargets"`
+	Dependencies             int    `json:"dependencies"`
+	Dependents               int    `json:"dependents"`
+	ReplicateTo              int    `json:"replicateTo"`
+	ReplicateToMatching      int    `json:"replicateToMatching"`
+	ReplicateToSameTenant    int    `json:"replicateToSameTenant"`
+	ReplicateToFromConfigMap int    `json:"replicateToFromConfigMap"`
+}
+
+// StatsReporter is implemented by replicators that can report the size of their internal caches
+type StatsReporter interface {
+	Stats() ReplicatorStats
+}
+
+// Stats reports the size of the internal caches of the replicator
+func (r *GenericReplicator) Stats() ReplicatorStats {
+	dependencies, dependents := r.dependencyCounts()
+	return ReplicatorStats{
+		Kind:                     r.Kind,
+		CachedObjects:            len(r.Store.ListKeys()),
+		CachedTargets:            len(r.TargetStore.ListKeys()),
+		Dependencies:             dependencies,
+		Dependents:               dependents,
+		ReplicateTo:              r.ReplicateToList.Len(),
+		ReplicateToMatching:      r.ReplicateToMatchingList.Len(),
+		ReplicateToSameTenant:    r.ReplicateToSameTenantList.Len(),
+		ReplicateToFromConfigMap: r.ReplicateToFromConfigMapList.Len(),
+	}
+}
+
+// SharedCacheStats reports the number of objects in the caches that are shared by all replicators
+func SharedCacheStats() map[string]int {
+	stats := make(map[string]int)
+	if namespaceWatcher.NamespaceStore != nil {
+		stats["namespaces"] = len(namespaceWatcher.NamespaceStore.ListKeys())
+	}
+	if configMapWatcher.ConfigMapStore != nil {
+		stats["referencedConfigMaps"] = len(configMapWatcher.ConfigMapStore.ListKeys())
+	}
+	return stats
+}
+
+// ReplicaCount is the number of replicas of a source in a target namespace
+type ReplicaCount struct {
+	Kind      string
+	Namespace string
+	Source    string
+	Count     int
+}
+
+// ReplicaCounter is implemented by replicators that can report how many replicas exist per target namespace and source
+type ReplicaCounter interface {
+	ReplicaCounts() []ReplicaCount
+}
+
+// ReplicaCounts reports the number of cached replicas per target namespace and source. Replicas created in push mode
+// as well as targets that pull or merge from a source are counted.
+func (r *GenericReplicator) ReplicaCounts() []ReplicaCount {
+	type countKey struct{ namespace, source string }
+	counts := make(map[countKey]int)
+	count := func(obj interface{}) {
+		sources, _ := replicaIndexFunc(obj)
+		pulled, _ := dependencyIndexFunc(obj)
+		namespace := MustGetObject(obj).GetNamespace()
+		for _, source := range append(sources, pulled...) {
+			counts[countKey{namespace, source}]++
+		}
+	}
+
+	for _, obj := range r.TargetStore.List() {
+		count(obj)
+	}
+	if r.targetController != nil {
+		// replicas that are not labeled as such, and pull targets, are in the regular cache
+		for _, obj := range r.Store.List() {
+			count(obj)
+		}
+	}
+
+	result := make([]ReplicaCount, 0, len(counts))
+	for key, n := range counts {
+		result = append(result, ReplicaCount{Kind: r.Kind, Namespace: key.namespace, Source: key.source, Count: n})
+	}
+	return result
+}
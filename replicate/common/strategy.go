@@ -0,0 +1,136 @@
+package common
+
+import (
+	"fmt"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Values of the ReplicationStrategy annotation
+const (
+	// ReplicationStrategyMerge overwrites replicated keys in the target and preserves all others
+	ReplicationStrategyMerge = "merge"
+
+	// ReplicationStrategyReplace removes all keys from the target that are not replicated from the source
+	ReplicationStrategyReplace = "replace"
+
+	// ReplicationStrategyIgnoreExisting leaves targets untouched that existed before the replicator created them
+	ReplicationStrategyIgnoreExisting = "ignore-existing"
+)
+
+// ReplicationStrategies lists all valid values of the ReplicationStrategy annotation
+var ReplicationStrategies = []string{ReplicationStrategyMerge, ReplicationStrategyReplace, ReplicationStrategyIgnoreExisting}
+
+// GetReplicationStrategy returns the replication strategy of the source, which defaults to "merge"
+func GetReplicationStrategy(source metav1.Object) string {
+	strategy, ok := source.GetAnnotations()[ReplicationStrategy]
+	if !ok || !slices.Contains(ReplicationStrategies, strategy) {
+		return ReplicationStrategyMerge
+	}
+	return strategy
+}
+
+// DeleteKeysExcept removes all keys from the data map that are not in the given list of keys, and returns whether
+// any keys were removed
+func DeleteKeysExcept[V any](data map[string]V, keep []string) bool {
+	deleted := false
+	for key := range data {
+		if !slices.Contains(keep, key) {
+			delete(data, key)
+			deleted = true
+		}
+	}
+	return deleted
+}
+
+// mayTouchExistingTarget checks if the source may be replicated into an existing target in the given namespace
+// according to its replication strategy. With the "ignore-existing" strategy, targets that were not created by the
+// replicator are left untouched. Legacy replicas count as created by the replicator; whether they may be adopted is
+// decided by mayReplaceExistingTarget.
+func (r *GenericReplicator) mayTouchExistingTarget(source interface{}, namespace string) bool {
+	objMeta := MustGetObject(source)
+	if GetReplicationStrategy(objMeta) != ReplicationStrategyIgnoreExisting {
+		return true
+	}
+
+	targetLocation := fmt.Sprintf("%s/%s", namespace, r.ResolveTargetName(objMeta, namespace))
+	target, exists, err := r.cachedTarget(targetLocation)
+	if err != nil || !exists {
+		return true
+	}
+
+	annotations := MustGetObject(target).GetAnnotations()
+	if _, replicated := annotations[ReplicatedAtAnnotation]; replicated {
+		return true
+	}
+	if _, legacy := annotations[LegacyReplicatedByAnnotation]; legacy {
+		return true
+	}
+
+	log.WithField("kind", r.Kind).WithField("source", MustGetKey(source)).WithField("target", targetLocation).
+		Infof("%s already exists and was not created by the replicator; leaving it untouched", targetLocation)
+	return false
+}
+
+// isReplicatedOnce checks if the target has already been replicated from a source with the ReplicateOnce
+// annotation, and must therefore not be updated again unless replication is triggered
+func isReplicatedOnce(source interface{}, target interface{}) bool {
+	if MustGetObject(source).GetAnnotations()[ReplicateOnce] != "true" {
+		return false
+	}
+
+	_, replicated := MustGetObject(target).GetAnnotations()[ReplicatedAtAnnotation]
+	return replicated && !isTriggered(source, target)
+}
+
+// isTriggered checks if the ReplicateTrigger annotation of the source has changed since the target was replicated
+func isTriggered(source interface{}, target interface{}) bool {
+	trigger, ok := MustGetObject(source).GetAnnotations()[ReplicateTrigger]
+	return ok && MustGetObject(target).GetAnnotations()[ReplicatedTriggerAnnotation] != trigger
+}
+
+// mayUpdateExistingTarget checks if an existing target in the given namespace may be updated from the source
+// with regard to the ReplicateOnce annotation
+func (r *GenericReplicator) mayUpdateExistingTarget(source interface{}, namespace string) bool {
+	targetLocation := fmt.Sprintf("%s/%s", namespace, r.ResolveTargetName(MustGetObject(source), namespace))
+	target, exists, err := r.cachedTarget(targetLocation)
+	if err != nil || !exists {
+		return true
+	}
+
+	if isReplicatedOnce(source, target) {
+		log.WithField("kind", r.Kind).WithField("source", MustGetKey(source)).WithField("target", targetLocation).
+			Debugf("%s has already been replicated once; not updating it", targetLocation)
+		return false
+	}
+
+	return true
+}
+
+// isUpToDate checks if the cached target in the given namespace (or, before the informers have synced, the
+// Checkpoint) shows that it has already been replicated from the current version of the source, so that replicating
+// it again (e.g. after a restart of the replicator) can be skipped without loading the target. Sources that patch service accounts are never considered up-to-date, since the
+// service accounts are not cached.
+func (r *GenericReplicator) isUpToDate(source interface{}, namespace string) bool {
+	objMeta := MustGetObject(source)
+	if _, ok := objMeta.GetAnnotations()[PatchServiceAccounts]; ok {
+		return false
+	}
+
+	// until the informers have synced, replicas may be missing from the cache
+	if !r.hasSynced() && r.Checkpoint[r.Kind].covers(MustGetKey(source), objMeta.GetResourceVersion(), namespace) {
+		return true
+	}
+
+	targetLocation := fmt.Sprintf("%s/%s", namespace, r.ResolveTargetName(objMeta, namespace))
+	target, exists, err := r.cachedTarget(targetLocation)
+	if err != nil || !exists {
+		return false
+	}
+
+	annotations := MustGetObject(target).GetAnnotations()
+	return objMeta.GetResourceVersion() != "" && annotations[ReplicatedSourceAnnotation] == MustGetKey(source) &&
+		annotations[ReplicatedFromVersionAnnotation] == objMeta.GetResourceVersion()
+}
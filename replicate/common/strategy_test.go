@@ -0,0 +1,147 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestDeleteKeysExcept(t *testing.T) {
+	data := map[string]string{"a": "1", "b": "2", "c": "3"}
+
+	assert.True(t, DeleteKeysExcept(data, []string{"a", "c"}))
+	assert.Equal(t, map[string]string{"a": "1", "c": "3"}, data)
+
+	assert.False(t, DeleteKeysExcept(data, []string{"a", "c"}))
+}
+
+func TestMayTouchExistingTarget(t *testing.T) {
+	unmanaged := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "target"}}
+	managed := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "managed",
+		Namespace:   "target",
+		Annotations: map[string]string{ReplicatedAtAnnotation: "2024-01-01T00:00:00Z"},
+	}}
+	legacy := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "legacy",
+		Namespace:   "target",
+		Annotations: map[string]string{LegacyReplicatedByAnnotation: "source/legacy"},
+	}}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(unmanaged))
+	assert.NoError(t, store.Add(managed))
+	assert.NoError(t, store.Add(legacy))
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, TargetStore: store}
+
+	source := func(name string, strategy string) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "source",
+			Annotations: map[string]string{ReplicationStrategy: strategy},
+		}}
+	}
+
+	assert.True(t, r.mayTouchExistingTarget(source("unmanaged", ReplicationStrategyMerge), "target"))
+	assert.False(t, r.mayTouchExistingTarget(source("unmanaged", ReplicationStrategyIgnoreExisting), "target"))
+	assert.True(t, r.mayTouchExistingTarget(source("managed", ReplicationStrategyIgnoreExisting), "target"))
+	assert.True(t, r.mayTouchExistingTarget(source("missing", ReplicationStrategyIgnoreExisting), "target"))
+	assert.True(t, r.mayTouchExistingTarget(source("legacy", ReplicationStrategyIgnoreExisting), "target"))
+}
+
+func TestMayReplaceExistingTarget(t *testing.T) {
+	target := func(name string, annotations map[string]string) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "target", Annotations: annotations}}
+	}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	assert.NoError(t, store.Add(target("foreign", nil)))
+	assert.NoError(t, store.Add(target("legacy", map[string]string{LegacyReplicatedByAnnotation: "source/legacy"})))
+	assert.NoError(t, store.Add(target("other", map[string]string{LegacyReplicatedByAnnotation: "elsewhere/other"})))
+	assert.NoError(t, store.Add(target("adopted", map[string]string{
+		LegacyReplicatedByAnnotation:    "elsewhere/adopted",
+		ReplicatedFromVersionAnnotation: "1",
+	})))
+
+	source := func(name string) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "source"}}
+	}
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, TargetStore: store}
+	assert.True(t, r.mayReplaceExistingTarget(source("missing"), "target"))
+	assert.True(t, r.mayReplaceExistingTarget(source("foreign"), "target"))
+	assert.False(t, r.mayReplaceExistingTarget(source("legacy"), "target"))
+
+	r.AdoptLegacyReplicas = true
+	assert.True(t, r.mayReplaceExistingTarget(source("legacy"), "target"))
+	assert.False(t, r.mayReplaceExistingTarget(source("other"), "target"))
+	assert.True(t, r.mayReplaceExistingTarget(source("adopted"), "target"))
+}
+
+func TestIsReplicatedOnce(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "source"}}
+	fresh := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "target", Namespace: "target"}}
+	replicated := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "target",
+		Namespace:   "target",
+		Annotations: map[string]string{ReplicatedAtAnnotation: "2024-01-01T00:00:00Z"},
+	}}
+
+	assert.False(t, isReplicatedOnce(source, replicated))
+
+	source.Annotations = map[string]string{ReplicateOnce: "true"}
+	assert.False(t, isReplicatedOnce(source, fresh))
+	assert.True(t, isReplicatedOnce(source, replicated))
+}
+
+func TestIsReplicatedOnceTriggered(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "source",
+		Namespace:   "source",
+		Annotations: map[string]string{ReplicateOnce: "true", ReplicateTrigger: "1"},
+	}}
+	replicated := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "target",
+		Namespace:   "target",
+		Annotations: map[string]string{ReplicatedAtAnnotation: "2024-01-01T00:00:00Z"},
+	}}
+
+	assert.False(t, isReplicatedOnce(source, replicated))
+
+	repl := GenericReplicator{}
+	assert.True(t, repl.PropagateAnnotations(source.Annotations, replicated.Annotations))
+	assert.Equal(t, "1", replicated.Annotations[ReplicatedTriggerAnnotation])
+	assert.True(t, isReplicatedOnce(source, replicated))
+
+	source.Annotations[ReplicateTrigger] = "2"
+	assert.False(t, isReplicatedOnce(source, replicated))
+}
+
+func TestIsUpToDate(t *testing.T) {
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "source", ResourceVersion: "2"}}
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for namespace, version := range map[string]string{"current": "2", "outdated": "1"} {
+		assert.NoError(t, store.Add(&v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: namespace,
+			Annotations: map[string]string{
+				ReplicatedSourceAnnotation:      "source/secret",
+				ReplicatedFromVersionAnnotation: version,
+			},
+		}}))
+	}
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret"}, TargetStore: store}
+
+	assert.True(t, r.isUpToDate(source, "current"))
+	assert.False(t, r.isUpToDate(source, "outdated"))
+	assert.False(t, r.isUpToDate(source, "missing"))
+
+	source.Annotations = map[string]string{PatchServiceAccounts: "default"}
+	assert.False(t, r.isUpToDate(source, "current"))
+}
@@ -33,13 +33,17 @@ func GetKeysFromStringMap(data map[string]string) []string {
 	return strings
 }
 
-// MustGetKey creates a key from Kubernetes resource in the format <namespace>/<name>
+// MustGetKey creates a key from Kubernetes resource in the format <namespace>/<name>, or <name> for
+// cluster-scoped resources
 func MustGetKey(obj interface{}) string {
 	if obj == nil {
 		return ""
 	}
 
 	o := MustGetObject(obj)
+	if o.GetNamespace() == "" {
+		return o.GetName()
+	}
 	return fmt.Sprintf("%s/%s", o.GetNamespace(), o.GetName())
 
 }
@@ -75,3 +79,66 @@ func StringToPatternList(list string) (result []*regexp.Regexp) {
 
 	return
 }
+
+// MatchAllowedNamespacePatterns checks if the namespace matches the comma-separated list of patterns of the
+// ReplicationAllowedNamespaces annotation, and whether it is excluded by a pattern prefixed with "!". Unlike
+// MatchNamespacePatterns, a list consisting only of excluded patterns matches all other namespaces.
+func MatchAllowedNamespacePatterns(patterns string, namespace string) (matched bool, excluded bool) {
+	var include, exclude []string
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if excludedPattern, ok := strings.CutPrefix(pattern, "!"); ok {
+			exclude = append(exclude, excludedPattern)
+		} else {
+			include = append(include, pattern)
+		}
+	}
+
+	if len(exclude) > 0 {
+		for _, pattern := range StringToPatternList(strings.Join(exclude, ",")) {
+			if pattern.MatchString(namespace) {
+				return false, true
+			}
+		}
+
+		if len(include) == 0 {
+			return true, false
+		}
+	}
+
+	for _, pattern := range StringToPatternList(strings.Join(include, ",")) {
+		if pattern.MatchString(namespace) {
+			return true, false
+		}
+	}
+
+	return false, false
+}
+
+// MatchNamespacePatterns checks if the namespace matches the comma-separated list of patterns. Patterns prefixed
+// with "!" exclude namespaces that would otherwise be matched by the other patterns.
+func MatchNamespacePatterns(patterns string, namespace string) bool {
+	var include, exclude []string
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if excluded, ok := strings.CutPrefix(pattern, "!"); ok {
+			exclude = append(exclude, excluded)
+		} else {
+			include = append(include, pattern)
+		}
+	}
+
+	for _, pattern := range StringToPatternList(strings.Join(exclude, ",")) {
+		if pattern.MatchString(namespace) {
+			return false
+		}
+	}
+
+	for _, pattern := range StringToPatternList(strings.Join(include, ",")) {
+		if pattern.MatchString(namespace) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchNamespacePatterns(t *testing.T) {
+	assert.True(t, MatchNamespacePatterns("my-ns,team-.*", "my-ns"))
+	assert.True(t, MatchNamespacePatterns("my-ns,team-.*", "team-a"))
+	assert.False(t, MatchNamespacePatterns("my-ns,team-.*", "my-ns-2"))
+
+	patterns := ".*, !kube-.*, !cattle-.*"
+	assert.True(t, MatchNamespacePatterns(patterns, "default"))
+	assert.False(t, MatchNamespacePatterns(patterns, "kube-system"))
+	assert.False(t, MatchNamespacePatterns(patterns, "cattle-system"))
+
+	assert.False(t, MatchNamespacePatterns("!kube-.*", "default"))
+}
+
+func TestMatchAllowedNamespacePatterns(t *testing.T) {
+	matched, excluded := MatchAllowedNamespacePatterns("my-ns,team-.*", "team-a")
+	assert.True(t, matched)
+	assert.False(t, excluded)
+
+	matched, excluded = MatchAllowedNamespacePatterns("team-.*,!team-admin", "team-admin")
+	assert.False(t, matched)
+	assert.True(t, excluded)
+
+	matched, excluded = MatchAllowedNamespacePatterns("team-.*,!team-admin", "default")
+	assert.False(t, matched)
+	assert.False(t, excluded)
+
+	matched, _ = MatchAllowedNamespacePatterns("!kube-.*, !cattle-.*", "default")
+	assert.True(t, matched)
+
+	// exclusions are anchored just like inclusions
+	matched, excluded = MatchAllowedNamespacePatterns("!kube", "kube-system")
+	assert.True(t, matched)
+	assert.False(t, excluded)
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TemplateData is passed to the templates of sources with the TemplateValues annotation
+type TemplateData struct {
+	// Namespace is the name of the target namespace
+	Namespace string
+
+	// Labels are the labels of the target namespace
+	Labels map[string]string
+}
+
+// ValueRenderer renders the replicated values of a source for one target namespace
+type ValueRenderer struct {
+	enabled bool
+	data    TemplateData
+}
+
+// NewValueRenderer creates a renderer for the values of the source in the given target namespace. Values are
+// only rendered as Go templates if the source has the TemplateValues annotation; otherwise, they are copied as-is.
+func (r *GenericReplicator) NewValueRenderer(source metav1.Object, namespace string) (*ValueRenderer, error) {
+	if source.GetAnnotations()[TemplateValues] != "true" {
+		return &ValueRenderer{}, nil
+	}
+
+	ns, exists, err := r.getNamespace(namespace)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, errors.Errorf("could not get namespace %s: does not exist", namespace)
+	}
+
+	return &ValueRenderer{
+		enabled: true,
+		data: TemplateData{
+			Namespace: ns.Name,
+			Labels:    ns.Labels,
+		},
+	}, nil
+}
+
+// Render renders a single value
+func (v *ValueRenderer) Render(value string) (string, error) {
+	if !v.enabled {
+		return value, nil
+	}
+
+	tmpl, err := template.New("value").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", errors.Wrap(err, "could not parse template")
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, v.data); err != nil {
+		return "", errors.Wrap(err, "could not execute template")
+	}
+
+	return out.String(), nil
+}
+
+// RenderBytes renders a single binary value. The result never shares memory with the given value.
+func (v *ValueRenderer) RenderBytes(value []byte) ([]byte, error) {
+	if !v.enabled {
+		newValue := make([]byte, len(value))
+		copy(newValue, value)
+		return newValue, nil
+	}
+
+	rendered, err := v.Render(string(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(rendered), nil
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueRenderer(t *testing.T) {
+	plain := &ValueRenderer{}
+	out, err := plain.Render("db.{{ .Namespace }}.svc")
+	assert.NoError(t, err)
+	assert.Equal(t, "db.{{ .Namespace }}.svc", out)
+
+	renderer := &ValueRenderer{enabled: true, data: TemplateData{
+		Namespace: "team-a",
+		Labels:    map[string]string{"stage": "prod"},
+	}}
+	out, err = renderer.Render("db.{{ .Namespace }}.svc")
+	assert.NoError(t, err)
+	assert.Equal(t, "db.team-a.svc", out)
+
+	outBytes, err := renderer.RenderBytes([]byte(`{{ index .Labels "stage" }}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("prod"), outBytes)
+
+	_, err = renderer.Render("{{ .Unknown }}")
+	assert.Error(t, err)
+}
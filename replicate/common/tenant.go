@@ -0,0 +1,28 @@
+package common
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// sameTenantSelector returns a label selector that matches all namespaces belonging to the same tenant as the
+// given namespace. The tenant of a namespace is read from the namespace label configured as TenantLabel.
+func (r *GenericReplicator) sameTenantSelector(namespace string) (labels.Selector, error) {
+	if r.TenantLabel == "" {
+		return nil, errors.Errorf("%s annotation is set, but no tenant label is configured", ReplicateToSameTenant)
+	}
+
+	ns, exists, err := r.getNamespace(namespace)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, errors.Errorf("could not get namespace %s: does not exist", namespace)
+	}
+
+	tenant, ok := ns.Labels[r.TenantLabel]
+	if !ok || tenant == "" {
+		return nil, errors.Errorf("namespace %s does not belong to a tenant (label %s missing)", namespace, r.TenantLabel)
+	}
+
+	return labels.SelectorFromSet(labels.Set{r.TenantLabel: tenant}), nil
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestSameTenantSelector(t *testing.T) {
+	teamA := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tenant": "a"}}}
+	teamB := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"tenant": "b"}}}
+	shared := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shared"}}
+
+	namespaceWatcher.NamespaceStore = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for _, ns := range []*v1.Namespace{teamA, teamB, shared} {
+		assert.NoError(t, namespaceWatcher.NamespaceStore.Add(ns))
+	}
+
+	r := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{Kind: "Secret", Client: fake.NewSimpleClientset()}}
+
+	_, err := r.sameTenantSelector("team-a")
+	assert.Error(t, err, "the tenant label has to be configured")
+
+	r.TenantLabel = "tenant"
+	selector, err := r.sameTenantSelector("team-a")
+	assert.NoError(t, err)
+	assert.True(t, selector.Matches(labels.Set(teamA.Labels)))
+	assert.False(t, selector.Matches(labels.Set(teamB.Labels)))
+	assert.False(t, selector.Matches(labels.Set(shared.Labels)))
+
+	_, err = r.sameTenantSelector("shared")
+	assert.Error(t, err, "sources in namespaces without a tenant are not replicated")
+}
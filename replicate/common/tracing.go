@@ -0,0 +1,34 @@
+package common
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of all replicators. Spans are discarded unless a tracer provider is registered with
+// otel.SetTracerProvider.
+var tracer = otel.Tracer("github.com/mittwald/kubernetes-replicator/replicate/common")
+
+// startSpan starts a span of the given operation of the replicator as a child of the span in ctx, if any. Spans
+// without a parent start a new reconciliation, see withReconciliationID.
+func (r *GenericReplicator) startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes, attribute.String("replicator.kind", r.Kind))
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+	if ReconciliationID(ctx) == "" {
+		ctx = withReconciliationID(ctx, span)
+	}
+	return ctx, span
+}
+
+// endSpan ends the span, marking it as failed if err is not nil
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// stripBulkyMetadata removes metadata that the replicator never reads from an object before it is cached, in
+// order to reduce the memory footprint of the caches. The managed fields are always removed, since the API server
+// retains them when an object is updated without them. The annotation written by "kubectl apply" is only removed
+// if requested, since it would be lost when the object is later updated as a whole.
+func stripBulkyMetadata(obj interface{}, lastApplied bool) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+
+	object.SetManagedFields(nil)
+
+	if annotations := object.GetAnnotations(); lastApplied && annotations != nil {
+		if _, ok := annotations[v1.LastAppliedConfigAnnotation]; ok {
+			delete(annotations, v1.LastAppliedConfigAnnotation)
+			object.SetAnnotations(annotations)
+		}
+	}
+}
+
+// StripBulkyMetadata is a cache transform for informers whose objects are only read, never updated
+func StripBulkyMetadata(obj interface{}) (interface{}, error) {
+	stripBulkyMetadata(obj, true)
+	return obj, nil
+}
+
+// StripManagedFields is a cache transform for informers whose objects may be updated as a whole
+func StripManagedFields(obj interface{}) (interface{}, error) {
+	stripBulkyMetadata(obj, false)
+	return obj, nil
+}
+
+// newTransform creates the cache transform of the informer of a replicator
+func newTransform(config ReplicatorConfig) cache.TransformFunc {
+	return func(obj interface{}) (interface{}, error) {
+		stripBulkyMetadata(obj, config.StripLastAppliedConfiguration)
+		if object, err := meta.Accessor(obj); err == nil && len(config.CompatibilityModes) > 0 {
+			TranslateForeignAnnotations(object, config.CompatibilityModes)
+		}
+		return obj, nil
+	}
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStripBulkyMetadata(t *testing.T) {
+	newSecret := func() *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:          "source",
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+			Annotations: map[string]string{
+				v1.LastAppliedConfigAnnotation: `{"apiVersion":"v1"}`,
+				ReplicateTo:                    "target",
+			},
+		}}
+	}
+
+	obj, err := StripManagedFields(newSecret())
+	assert.NoError(t, err)
+	assert.Nil(t, obj.(*v1.Secret).ManagedFields)
+	assert.Contains(t, obj.(*v1.Secret).Annotations, v1.LastAppliedConfigAnnotation)
+
+	obj, err = StripBulkyMetadata(newSecret())
+	assert.NoError(t, err)
+	assert.Nil(t, obj.(*v1.Secret).ManagedFields)
+	assert.Equal(t, map[string]string{ReplicateTo: "target"}, obj.(*v1.Secret).Annotations)
+
+	obj, err = newTransform(ReplicatorConfig{CompatibilityModes: []string{CompatibilityKubed}})(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"kubed.appscode.com/sync": "app=foo"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "app=foo", obj.(*v1.Secret).Annotations[ReplicateToMatching])
+}
@@ -0,0 +1,281 @@
+package common
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// booleanAnnotations lists all annotations that are expected to contain either "true" or "false"
+var booleanAnnotations = []string{
+	ReplicationAllowed,
+	ReplicateToSameTenant,
+	ReplicateToIntersect,
+	OwnByNamespace,
+	StripLabels,
+	TemplateValues,
+	RequiresApproval,
+	ReplicateOnce,
+	Retain,
+	KeepOnNamespaceLabelChange,
+	CompareCertificates,
+	DecompressValues,
+}
+
+// configurationAnnotations lists all annotations that configure replication of an object (as opposed to the
+// bookkeeping annotations set on replicas)
+var configurationAnnotations = []string{
+	ReplicateFromAnnotation,
+	MergeFrom,
+	ReplicationAllowed,
+	ReplicationAllowedNamespaces,
+	ReplicationAllowedNamespaceLabels,
+	ReplicateTo,
+	ReplicateToMatching,
+	ReplicateToFromConfigMap,
+	ReplicateToSameTenant,
+	ReplicateToIntersect,
+	ReplicateToNamespacesWith,
+	ReplicateToName,
+	ReplicateToPrefix,
+	ReplicateToSuffix,
+	ReplicateKeys,
+	ReplicateKeysExclude,
+	ReplicateKeyMap,
+	TemplateValues,
+	ReplicationStrategy,
+	ReplicationPriority,
+	CompressValuesLargerThan,
+	DecompressValues,
+	RequiresApproval,
+	ReplicateOnce,
+	ServiceAccountTokenMode,
+	KeepAnnotations,
+	StripLabelPrefixes,
+	ReplicateAddLabels,
+	ReplicateAddAnnotations,
+	ReplicateAfter,
+	ReplicateTrigger,
+	DeletionPolicy,
+	KeepOnNamespaceLabelChange,
+	ResyncPeriod,
+	CompareCertificates,
+	MaxReplicas,
+	FilterValues,
+}
+
+// hasReplicatorAnnotations checks if the object carries any annotation that configures the replicator
+func hasReplicatorAnnotations(object metav1.Object) bool {
+	annotations := object.GetAnnotations()
+	for _, annotation := range configurationAnnotations {
+		if _, ok := annotations[annotation]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAnnotations checks the replicator annotations of an object and returns all configuration errors that
+// would cause replication to silently not happen (or not happen as intended)
+func (r *GenericReplicator) ValidateAnnotations(object metav1.Object) error {
+	annotations := object.GetAnnotations()
+
+	var result error
+
+	for _, annotation := range booleanAnnotations {
+		if value, ok := annotations[annotation]; ok {
+			if _, err := strconv.ParseBool(value); err != nil {
+				result = multierror.Append(result, errors.Errorf("%s: expected \"true\" or \"false\", got %q", annotation, value))
+			}
+		}
+	}
+
+	if sourceLocation, ok := annotations[ReplicateFromAnnotation]; ok {
+		if v := strings.SplitN(sourceLocation, "/", 2); len(v) < 2 || v[0] == "" || v[1] == "" {
+			result = multierror.Append(result, errors.Errorf("%s: expected '<namespace>/<name>', got %q", ReplicateFromAnnotation, sourceLocation))
+		}
+
+		for _, annotation := range []string{ReplicateTo, ReplicateToMatching, ReplicateToFromConfigMap, ReplicateToSameTenant, ReplicateToNamespacesWith, ReplicateAfter} {
+			if _, ok := annotations[annotation]; ok {
+				result = multierror.Append(result, errors.Errorf("%s is ignored on objects with a %s annotation", annotation, ReplicateFromAnnotation))
+			}
+		}
+	}
+
+	if sources, ok := annotations[MergeFrom]; ok {
+		if _, err := ParseMergeSources(sources); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", MergeFrom))
+		}
+		if _, ok := annotations[ReplicateFromAnnotation]; ok {
+			result = multierror.Append(result, errors.Errorf("%s is ignored on objects with a %s annotation", MergeFrom, ReplicateFromAnnotation))
+		}
+	}
+
+	if after, ok := annotations[ReplicateAfter]; ok {
+		if v := strings.SplitN(after, "/", 2); len(v) < 2 || v[0] == "" || v[1] == "" {
+			result = multierror.Append(result, errors.Errorf("%s: expected '<namespace>/<name>', got %q", ReplicateAfter, after))
+		}
+	}
+
+	for _, annotation := range []string{ReplicateTo, ReplicationAllowedNamespaces} {
+		if patterns, ok := annotations[annotation]; ok {
+			for _, pattern := range strings.Split(patterns, ",") {
+				pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "!")
+				if _, err := regexp.Compile(BuildStrictRegex(pattern)); err != nil {
+					result = multierror.Append(result, errors.Wrapf(err, "%s: invalid pattern %q", annotation, pattern))
+				}
+			}
+		}
+	}
+
+	if patterns, ok := annotations[ReplicateKeysExclude]; ok {
+		for _, pattern := range strings.Split(patterns, ",") {
+			if _, err := path.Match(strings.TrimSpace(pattern), ""); err != nil {
+				result = multierror.Append(result, errors.Wrapf(err, "%s: invalid pattern %q", ReplicateKeysExclude, pattern))
+			}
+		}
+	}
+
+	if keyMap, ok := annotations[ReplicateKeyMap]; ok {
+		if mapping, err := ParseKeyMap(keyMap); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", ReplicateKeyMap))
+		} else {
+			targets := make(map[string]string)
+			for from, to := range mapping {
+				for _, msg := range validation.IsConfigMapKey(to) {
+					result = multierror.Append(result, errors.Errorf("%s: invalid target key %q: %s", ReplicateKeyMap, to, msg))
+				}
+				if other, ok := targets[to]; ok {
+					result = multierror.Append(result, errors.Errorf("%s: keys %q and %q are both mapped to %q", ReplicateKeyMap, other, from, to))
+				}
+				targets[to] = from
+			}
+		}
+	}
+
+	if reference, ok := annotations[ReplicateToFromConfigMap]; ok {
+		if _, _, _, err := ParseConfigMapReference(reference, object.GetNamespace()); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", ReplicateToFromConfigMap))
+		}
+	}
+
+	if annotations[ReplicateToIntersect] == "true" {
+		_, hasPatterns := annotations[ReplicateTo]
+		_, hasConfigMap := annotations[ReplicateToFromConfigMap]
+		if _, hasSelector := annotations[ReplicateToMatching]; !hasSelector || (!hasPatterns && !hasConfigMap) {
+			result = multierror.Append(result, errors.Errorf("%s: requires both %s (or %s) and %s", ReplicateToIntersect, ReplicateTo, ReplicateToFromConfigMap, ReplicateToMatching))
+		}
+	}
+
+	if value, ok := annotations[ReplicateToNamespacesWith]; ok {
+		if _, err := ParseRequiredResource(value); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", ReplicateToNamespacesWith))
+		}
+	}
+
+	if value, ok := annotations[StripLabelPrefixes]; ok {
+		for _, prefix := range strings.Split(value, ",") {
+			if strings.TrimSpace(prefix) == "" {
+				result = multierror.Append(result, errors.Errorf("%s: empty prefix in %q", StripLabelPrefixes, value))
+			}
+		}
+	}
+
+	if value, ok := annotations[ReplicateAddLabels]; ok {
+		if _, err := ParseAddedLabels(value); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", ReplicateAddLabels))
+		}
+	}
+
+	if value, ok := annotations[ReplicateAddAnnotations]; ok {
+		if _, err := ParseAddedAnnotations(value); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", ReplicateAddAnnotations))
+		}
+	}
+
+	if value, ok := annotations[KeepOwnerReferences]; ok {
+		if !slices.Contains([]string{"true", "false", OwnerReferencesRewrite}, value) {
+			result = multierror.Append(result, errors.Errorf("%s: expected \"true\", \"false\" or %q, got %q", KeepOwnerReferences, OwnerReferencesRewrite, value))
+		}
+	}
+
+	if mode, ok := annotations[ServiceAccountTokenMode]; ok {
+		if !slices.Contains([]string{ServiceAccountTokenModeCopy, ServiceAccountTokenModeSkip, ServiceAccountTokenModeRegenerate}, mode) {
+			result = multierror.Append(result, errors.Errorf("%s: expected \"copy\", \"skip\" or \"regenerate\", got %q", ServiceAccountTokenMode, mode))
+		}
+	}
+
+	if strategy, ok := annotations[ReplicationStrategy]; ok && !slices.Contains(ReplicationStrategies, strategy) {
+		result = multierror.Append(result, errors.Errorf("%s: expected one of %v, got %q", ReplicationStrategy, ReplicationStrategies, strategy))
+	}
+
+	if threshold, ok := annotations[CompressValuesLargerThan]; ok {
+		if _, err := ParseCompressionThreshold(threshold); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", CompressValuesLargerThan))
+		}
+	}
+
+	if limit, ok := annotations[MaxReplicas]; ok {
+		if _, err := ParseMaxReplicas(limit); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", MaxReplicas))
+		}
+	}
+
+	if filters, ok := annotations[FilterValues]; ok {
+		if _, err := ParseValueFilters(filters); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", FilterValues))
+		}
+	}
+
+	if period, ok := annotations[ResyncPeriod]; ok {
+		if _, err := ParseResyncPeriod(period); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "%s", ResyncPeriod))
+		}
+	}
+
+	if priority, ok := annotations[ReplicationPriority]; ok {
+		if _, err := strconv.Atoi(priority); err != nil {
+			result = multierror.Append(result, errors.Errorf("%s: expected an integer, got %q", ReplicationPriority, priority))
+		}
+	}
+
+	if policy, ok := annotations[DeletionPolicy]; ok && !slices.Contains(DeletionPolicies, policy) {
+		result = multierror.Append(result, errors.Errorf("%s: expected one of %v, got %q", DeletionPolicy, DeletionPolicies, policy))
+	}
+
+	for _, annotation := range []string{ReplicateToMatching, ReplicationAllowedNamespaceLabels} {
+		if selector, ok := annotations[annotation]; ok {
+			if _, err := labels.Parse(selector); err != nil {
+				result = multierror.Append(result, errors.Wrapf(err, "%s: invalid label selector", annotation))
+			}
+		}
+	}
+
+	if annotations[ReplicateToSameTenant] == "true" && r.TenantLabel == "" {
+		result = multierror.Append(result, errors.Errorf("%s is set, but no tenant label is configured", ReplicateToSameTenant))
+	}
+
+	_, hasName := annotations[ReplicateToName]
+	_, hasPrefix := annotations[ReplicateToPrefix]
+	_, hasSuffix := annotations[ReplicateToSuffix]
+	if hasName || hasPrefix || hasSuffix {
+		// templated names are checked with an exemplary namespace name
+		if name, err := renderTargetName(object, "namespace"); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "invalid target name %q", name))
+		} else {
+			for _, msg := range validation.IsDNS1123Subdomain(name) {
+				result = multierror.Append(result, errors.Errorf("invalid target name %q: %s", name, msg))
+			}
+		}
+	}
+
+	return result
+}
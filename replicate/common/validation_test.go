@@ -0,0 +1,49 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateAnnotations(t *testing.T) {
+	r := &GenericReplicator{}
+
+	valid := &metav1.ObjectMeta{Name: "source", Annotations: map[string]string{
+		ReplicateTo:         "ns-1,ns-[0-9]+",
+		ReplicateToMatching: "team=a",
+		ReplicateToPrefix:   "copy-",
+		StripLabels:         "true",
+	}}
+	assert.NoError(t, r.ValidateAnnotations(valid))
+
+	invalid := &metav1.ObjectMeta{Name: "source", Annotations: map[string]string{
+		ReplicateTo:           "ns-(",
+		ReplicateToMatching:   "team in (a",
+		ReplicateToSameTenant: "true",
+		ReplicateToName:       "Not_A_Name",
+		StripLabels:           "yes",
+		ReplicateKeysExclude:  "[",
+	}}
+	assert.Error(t, r.ValidateAnnotations(invalid))
+
+	templated := &metav1.ObjectMeta{Name: "source", Annotations: map[string]string{
+		ReplicateToName: "{{ .Namespace }}-credentials",
+	}}
+	assert.NoError(t, r.ValidateAnnotations(templated))
+
+	templated.Annotations[ReplicateToName] = "{{ .Namespace }"
+	assert.Error(t, r.ValidateAnnotations(templated))
+
+	pull := &metav1.ObjectMeta{Name: "target", Annotations: map[string]string{
+		ReplicateFromAnnotation: "source",
+	}}
+	assert.Error(t, r.ValidateAnnotations(pull))
+
+	pull.Annotations[ReplicateFromAnnotation] = "ns/source"
+	assert.NoError(t, r.ValidateAnnotations(pull))
+
+	pull.Annotations[ReplicateTo] = "ns-1"
+	assert.Error(t, r.ValidateAnnotations(pull))
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"sync/atomic"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newListWatch creates the (instrumented) ListWatch of the informer of a replicator. If a WatchSelector is
+// configured, only objects matching it are listed and watched, so that objects that are not involved in
+// replication are not cached.
+func newListWatch(config ReplicatorConfig) *cache.ListWatch {
+	listFunc := PagedListFunc(config.ListFunc, config.ListPageSize)
+	if config.WatchSelector == nil || config.WatchSelector.Empty() {
+		return InstrumentListWatch(config.Kind, &cache.ListWatch{
+			ListFunc:  listFunc,
+			WatchFunc: config.WatchFunc,
+		})
+	}
+
+	return InstrumentListWatch(config.Kind, &cache.ListWatch{
+		ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+			return listFunc(withSelector(lo, config.WatchSelector))
+		},
+		WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+			return config.WatchFunc(withSelector(lo, config.WatchSelector))
+		},
+	})
+}
+
+// withSelector adds the selector to the label selector of the list options
+func withSelector(lo metav1.ListOptions, selector labels.Selector) metav1.ListOptions {
+	if lo.LabelSelector == "" {
+		lo.LabelSelector = selector.String()
+	} else {
+		lo.LabelSelector = lo.LabelSelector + "," + selector.String()
+	}
+	return lo
+}
+
+// PagedListFunc makes an informer list objects in chunks of at most pageSize objects, using the limit and continue
+// parameters of the API, instead of receiving all objects in a single response. The initial list of an informer
+// (with resource version "0") is usually served from the watch cache of the API server, which ignores the limit;
+// it is read with the most recent resource version instead. The list function is returned unchanged if pageSize
+// is zero.
+func PagedListFunc(list cache.ListFunc, pageSize int64) cache.ListFunc {
+	if pageSize <= 0 {
+		return list
+	}
+
+	return func(lo metav1.ListOptions) (runtime.Object, error) {
+		lo.Limit = pageSize
+		if lo.ResourceVersion == "0" && lo.Continue == "" {
+			lo.ResourceVersion = ""
+			lo.ResourceVersionMatch = ""
+		}
+		return list(lo)
+	}
+}
+
+// InstrumentListWatch counts the lists and watches of an informer, as well as the watches that ended because their
+// resource version was too old, which makes the informer list all objects again. Watches started after the first one
+// are counted as restarts, and the duration of the last complete (possibly paged) list is recorded. It also makes
+// sure that watch bookmarks are requested, which keep the resource version of quiet watches current so that they can
+// be resumed without listing all objects again.
+func InstrumentListWatch(informer string, lw *cache.ListWatch) *cache.ListWatch {
+	// the reflector of an informer lists and watches sequentially, so the start of the current list needs no locking
+	var listStarted time.Time
+	var watched atomic.Bool
+
+	return &cache.ListWatch{
+		ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+			if lo.Continue == "" {
+				metricInformerLists.WithLabelValues(informer).Inc()
+				listStarted = time.Now()
+			}
+
+			list, err := lw.ListFunc(lo)
+			if err != nil {
+				return nil, err
+			}
+			if listMeta, err := meta.ListAccessor(list); err == nil && listMeta.GetContinue() == "" {
+				metricInformerListDuration.WithLabelValues(informer).Set(time.Since(listStarted).Seconds())
+			}
+			return list, nil
+		},
+		WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+			lo.AllowWatchBookmarks = true
+			metricInformerWatches.WithLabelValues(informer).Inc()
+			if watched.Swap(true) {
+				metricInformerWatchRestarts.WithLabelValues(informer).Inc()
+			}
+
+			w, err := lw.WatchFunc(lo)
+			if err != nil {
+				if isExpired(err) {
+					metricInformerExpiredWatches.WithLabelValues(informer).Inc()
+				}
+				return nil, err
+			}
+
+			return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+				if event.Type == watch.Error && isExpired(apierrors.FromObject(event.Object)) {
+					metricInformerExpiredWatches.WithLabelValues(informer).Inc()
+				}
+				return event, true
+			}), nil
+		},
+	}
+}
+
+// isExpired checks if a watch failed because its resource version is too old
+func isExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
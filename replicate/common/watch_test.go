@@ -0,0 +1,123 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestNewListWatch(t *testing.T) {
+	var selectors []string
+	config := ReplicatorConfig{
+		ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+			selectors = append(selectors, lo.LabelSelector)
+			return &v1.SecretList{}, nil
+		},
+	}
+
+	_, err := newListWatch(config).List(metav1.ListOptions{})
+	require.NoError(t, err)
+
+	config.WatchSelector, err = labels.Parse("replicator.v1.mittwald.de/enabled=true")
+	require.NoError(t, err)
+
+	_, err = newListWatch(config).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	_, err = newListWatch(config).List(metav1.ListOptions{LabelSelector: "app=foo"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "replicator.v1.mittwald.de/enabled=true", "app=foo,replicator.v1.mittwald.de/enabled=true"}, selectors)
+}
+
+func TestPagedListFunc(t *testing.T) {
+	var options []metav1.ListOptions
+	list := PagedListFunc(func(lo metav1.ListOptions) (runtime.Object, error) {
+		options = append(options, lo)
+		return &v1.SecretList{}, nil
+	}, 100)
+
+	_, err := list(metav1.ListOptions{ResourceVersion: "0"})
+	require.NoError(t, err)
+	_, err = list(metav1.ListOptions{Continue: "token"})
+	require.NoError(t, err)
+	_, err = list(metav1.ListOptions{ResourceVersion: "42"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []metav1.ListOptions{
+		{Limit: 100},
+		{Limit: 100, Continue: "token"},
+		{Limit: 100, ResourceVersion: "42"},
+	}, options)
+}
+
+func TestInstrumentListWatch(t *testing.T) {
+	fakeWatch := watch.NewFake()
+	var watchOptions metav1.ListOptions
+	lw := InstrumentListWatch("Test", &cache.ListWatch{
+		ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+			return &v1.SecretList{}, nil
+		},
+		WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+			watchOptions = lo
+			return fakeWatch, nil
+		},
+	})
+
+	_, err := lw.List(metav1.ListOptions{})
+	require.NoError(t, err)
+	_, err = lw.List(metav1.ListOptions{Continue: "next-page"})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metricInformerLists.WithLabelValues("Test")))
+
+	w, err := lw.Watch(metav1.ListOptions{})
+	require.NoError(t, err)
+	defer w.Stop()
+	assert.True(t, watchOptions.AllowWatchBookmarks)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metricInformerWatches.WithLabelValues("Test")))
+
+	go fakeWatch.Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+	event := <-w.ResultChan()
+	assert.Equal(t, watch.Error, event.Type)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metricInformerExpiredWatches.WithLabelValues("Test")))
+}
+
+func TestInstrumentListWatchHealth(t *testing.T) {
+	var pages []string
+	lw := InstrumentListWatch("Health", &cache.ListWatch{
+		ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+			pages = append(pages, lo.Continue)
+			if lo.Continue == "" {
+				return &v1.SecretList{ListMeta: metav1.ListMeta{Continue: "next-page"}}, nil
+			}
+			return &v1.SecretList{}, nil
+		},
+		WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	})
+
+	_, err := lw.List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.False(t, metricInformerListDuration.DeleteLabelValues("Health"), "duration recorded before the last page")
+	_, err = lw.List(metav1.ListOptions{Continue: "next-page"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "next-page"}, pages)
+	assert.True(t, metricInformerListDuration.DeleteLabelValues("Health"), "duration not recorded after the last page")
+
+	for i := 0; i < 3; i++ {
+		w, err := lw.Watch(metav1.ListOptions{})
+		require.NoError(t, err)
+		w.Stop()
+	}
+	assert.Equal(t, 3.0, testutil.ToFloat64(metricInformerWatches.WithLabelValues("Health")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metricInformerWatchRestarts.WithLabelValues("Health")))
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ParseWorkersPerKind parses per-kind numbers of workers of the form "Secret=8,ConfigMap=2". Kinds are matched
+// case-insensitively.
+func ParseWorkersPerKind(workers string) (map[string]int, error) {
+	result := make(map[string]int)
+
+	for _, entry := range strings.Split(workers, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		kind, count, ok := strings.Cut(entry, "=")
+		kind, count = strings.TrimSpace(kind), strings.TrimSpace(count)
+		if !ok || kind == "" {
+			return nil, errors.Errorf("invalid number of workers %q: expected '<kind>=<number>'", entry)
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 {
+			return nil, errors.Errorf("invalid number of workers %q: expected a positive number", entry)
+		}
+
+		result[strings.ToLower(kind)] = n
+	}
+
+	return result, nil
+}
+
+// kindWorkers returns the number of workers of the configured kind: the number configured for the kind in
+// WorkersPerKind, or else the global Workers
+func kindWorkers(config ReplicatorConfig) int {
+	if workers, ok := config.WorkersPerKind[strings.ToLower(config.Kind)]; ok {
+		return workers
+	}
+	return config.Workers
+}
+
+// parallelize calls work for every index from 0 to n-1, using at most the given number of concurrent workers.
+// It returns once all calls have returned. With less than two workers, the calls are made one after another.
+func parallelize(workers int, n int, work func(i int)) {
+	if workers < 2 || n < 2 {
+		for i := 0; i < n; i++ {
+			work(i)
+		}
+		return
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				work(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestParallelize(t *testing.T) {
+	var running, maxRunning int32
+	done := make([]bool, 20)
+
+	parallelize(4, len(done), func(i int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			previous := atomic.LoadInt32(&maxRunning)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxRunning, previous, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		done[i] = true
+		atomic.AddInt32(&running, -1)
+	})
+
+	assert.NotContains(t, done, false)
+	assert.LessOrEqual(t, maxRunning, int32(4))
+	assert.Greater(t, maxRunning, int32(1))
+
+	var order []int
+	parallelize(1, 3, func(i int) { order = append(order, i) })
+	assert.Equal(t, []int{0, 1, 2}, order)
+}
+
+func TestParseWorkersPerKind(t *testing.T) {
+	workers, err := ParseWorkersPerKind("Secret=8, configmap=2,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"secret": 8, "configmap": 2}, workers)
+
+	_, err = ParseWorkersPerKind("Secret")
+	assert.Error(t, err)
+
+	_, err = ParseWorkersPerKind("Secret=0")
+	assert.Error(t, err)
+}
+
+func TestKindWorkers(t *testing.T) {
+	config := ReplicatorConfig{Kind: "Secret", Workers: 4, WorkersPerKind: map[string]int{"secret": 8}}
+	assert.Equal(t, 8, kindWorkers(config))
+
+	config.Kind = "ConfigMap"
+	assert.Equal(t, 4, kindWorkers(config))
+}
+
+func TestReplicateResourceToNamespacesAggregatesErrors(t *testing.T) {
+	r := &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Secret", Workers: 4},
+		Store:            cache.NewStore(cache.MetaNamespaceKeyFunc),
+		TargetStore:      cache.NewStore(cache.MetaNamespaceKeyFunc),
+		UpdateFuncs: UpdateFuncs{
+			ReplicateObjectTo: func(source interface{}, target *v1.Namespace) error {
+				if target.Name == "a" || target.Name == "d" || target.Name == "g" {
+					return errors.New("forbidden")
+				}
+				return nil
+			},
+		},
+	}
+
+	var namespaces []v1.Namespace
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		namespaces = append(namespaces, v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"}}
+	replicated, err := r.replicateResourceToNamespaces(context.Background(), source, namespaces)
+
+	var merr *multierror.Error
+	if assert.ErrorAs(t, err, &merr) {
+		assert.Len(t, merr.Errors, 3, "the errors of all failed namespaces are aggregated")
+	}
+
+	names := make([]string, len(replicated))
+	for i, ns := range replicated {
+		names[i] = ns.Name
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"b", "c", "e", "f", "h"}, names)
+}
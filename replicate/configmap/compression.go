@@ -0,0 +1,64 @@
+package configmap
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// storeValue stores a value of the source under the given key of the config map. If the source requests
+// compression (and the target, if given, does not decompress values), the value is compressed into the binary
+// data instead. It returns the key under which the value was stored, and whether the config map changed.
+func storeValue(configMap *v1.ConfigMap, source metav1.Object, target metav1.Object, key string, value string) (string, bool, error) {
+	if common.ShouldCompress(source, target, value) {
+		compressed, err := common.CompressValue(value)
+		if err != nil {
+			return "", false, err
+		}
+		key += common.CompressedKeySuffix
+		return key, storeBinary(configMap, key, compressed), nil
+	}
+
+	return key, storeString(configMap, key, value), nil
+}
+
+// storeBinaryValue stores a binary value of the source under the given key of the config map. If the target is
+// given and decompresses values, compressed values are stored decompressed in the data instead. It returns the
+// key under which the value was stored, and whether the config map changed.
+func storeBinaryValue(configMap *v1.ConfigMap, target metav1.Object, key string, value []byte) (string, bool, error) {
+	if target != nil && common.ShouldDecompress(target, key) {
+		decompressed, err := common.DecompressValue(value)
+		if err != nil {
+			return "", false, err
+		}
+		key = strings.TrimSuffix(key, common.CompressedKeySuffix)
+		return key, storeString(configMap, key, decompressed), nil
+	}
+
+	newValue := make([]byte, len(value))
+	copy(newValue, value)
+	return key, storeBinary(configMap, key, newValue), nil
+}
+
+// storeString stores a value in the data of the config map and returns whether it changed
+func storeString(configMap *v1.ConfigMap, key string, value string) bool {
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	oldValue, ok := configMap.Data[key]
+	configMap.Data[key] = value
+	return !ok || oldValue != value
+}
+
+// storeBinary stores a binary value in the config map and returns whether it changed
+func storeBinary(configMap *v1.ConfigMap, key string, value []byte) bool {
+	if configMap.BinaryData == nil {
+		configMap.BinaryData = make(map[string][]byte)
+	}
+	oldValue, ok := configMap.BinaryData[key]
+	configMap.BinaryData[key] = value
+	return !ok || !bytes.Equal(oldValue, value)
+}
@@ -1,7 +1,6 @@
 package configmap
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -18,7 +17,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/kubernetes"
 )
 
 type Replicator struct {
@@ -26,22 +24,26 @@ type Replicator struct {
 }
 
 // NewReplicator creates a new config map replicator
-func NewReplicator(client kubernetes.Interface, resyncPeriod time.Duration, allowAll, syncByContent bool) common.Replicator {
+func NewReplicator(config common.ReplicatorConfig) common.Replicator {
+	client := config.Client
+	config.Kind = "ConfigMap"
+	config.ObjType = &v1.ConfigMap{}
+	config.ListFunc = func(lo metav1.ListOptions) (runtime.Object, error) {
+		return client.CoreV1().ConfigMaps("").List(context.TODO(), lo)
+	}
+	config.WatchFunc = func(lo metav1.ListOptions) (watch.Interface, error) {
+		return client.CoreV1().ConfigMaps("").Watch(context.TODO(), lo)
+	}
+	config.GetFunc = func(namespace string, name string) (runtime.Object, error) {
+		return client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
+	config.TargetResource = v1.SchemeGroupVersion.WithResource("configmaps")
+
+	// replicas are updated with patches, which keep the annotation written by "kubectl apply"
+	config.StripLastAppliedConfiguration = true
+
 	repl := Replicator{
-		GenericReplicator: common.NewGenericReplicator(common.ReplicatorConfig{
-			Kind:          "ConfigMap",
-			ObjType:       &v1.ConfigMap{},
-			AllowAll:      allowAll,
-			SyncByContent: syncByContent,
-			ResyncPeriod:  resyncPeriod,
-			Client:        client,
-			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
-				return client.CoreV1().ConfigMaps("").List(context.TODO(), lo)
-			},
-			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
-				return client.CoreV1().ConfigMaps("").Watch(context.TODO(), lo)
-			},
-		}),
+		GenericReplicator: common.NewGenericReplicator(config),
 	}
 	repl.UpdateFuncs = common.UpdateFuncs{
 		ReplicateDataFrom:        repl.ReplicateDataFrom,
@@ -80,42 +82,52 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 	prevKeys, hasPrevKeys := common.PreviouslyPresentKeys(&targetCopy.ObjectMeta)
 	replicatedKeys := make([]string, 0)
 
+	render, err := r.NewValueRenderer(source, target.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "could not render values for %s", common.MustGetKey(target))
+	}
+
 	dataChanged := false
-	for key, value := range source.Data {
-		oldValue, ok := targetCopy.Data[key]
-		if ok {
-			if strings.Compare(value, oldValue) != 0 {
-				dataChanged = true
-			}
-		} else {
+	for k, value := range source.Data {
+		key, ok := common.PulledKey(source, target, k)
+		if !ok {
+			continue
+		}
+		value, err := render.Render(value)
+		if err != nil {
+			return errors.Wrapf(err, "could not render key %s of %s", k, common.MustGetKey(source))
+		}
+		value, err = common.FilterValue(source, k, value)
+		if err != nil {
+			return errors.Wrapf(err, "could not filter key %s of %s", k, common.MustGetKey(source))
+		}
+		key, changed, err := storeValue(targetCopy, source, target, key, value)
+		if err != nil {
+			return errors.Wrapf(err, "could not store key %s of %s", k, common.MustGetKey(source))
+		}
+		if changed {
 			dataChanged = true
 		}
-		targetCopy.Data[key] = value
 
 		replicatedKeys = append(replicatedKeys, key)
 		delete(prevKeys, key)
 	}
 
-	if source.BinaryData != nil {
-		if targetCopy.BinaryData == nil {
-			targetCopy.BinaryData = make(map[string][]byte)
+	for k, value := range source.BinaryData {
+		key, ok := common.PulledKey(source, target, k)
+		if !ok {
+			continue
 		}
-		for key, value := range source.BinaryData {
-			newValue := make([]byte, len(value))
-			copy(newValue, value)
-			oldValue, ok := targetCopy.BinaryData[key]
-			if ok {
-				if bytes.Compare(newValue, oldValue) != 0 {
-					dataChanged = true
-				}
-			} else {
-				dataChanged = true
-			}
-			targetCopy.BinaryData[key] = newValue
-
-			replicatedKeys = append(replicatedKeys, key)
-			delete(prevKeys, key)
+		key, changed, err := storeBinaryValue(targetCopy, target, key, value)
+		if err != nil {
+			return errors.Wrapf(err, "could not store key %s of %s", k, common.MustGetKey(source))
 		}
+		if changed {
+			dataChanged = true
+		}
+
+		replicatedKeys = append(replicatedKeys, key)
+		delete(prevKeys, key)
 	}
 
 	if hasPrevKeys {
@@ -127,24 +139,58 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 		}
 	}
 
+	if common.GetReplicationStrategy(source) == common.ReplicationStrategyReplace {
+		if common.DeleteKeysExcept(targetCopy.Data, replicatedKeys) {
+			dataChanged = true
+		}
+		if common.DeleteKeysExcept(targetCopy.BinaryData, replicatedKeys) {
+			dataChanged = true
+		}
+	}
+
+	if r.PropagateAnnotations(source.Annotations, targetCopy.Annotations) {
+		dataChanged = true
+	}
+
+	if r.SetDataHash(targetCopy.Annotations, targetCopy.Data, targetCopy.BinaryData) {
+		dataChanged = true
+	}
+
 	if !dataChanged {
 		logger.Debugf("target values of %s are already up-to-date", common.MustGetKey(target))
 		return nil
 	}
 
+	contentHash := r.ContentHash(source, targetCopy.Data, targetCopy.BinaryData)
+	if r.IsUnchangedSinceWrite(target, contentHash, target.Data, target.BinaryData) {
+		logger.Debugf("%s has not changed since it was last written from the same data; not writing it again", common.MustGetKey(target))
+		return nil
+	}
+
 	sort.Strings(replicatedKeys)
 
 	logger.Infof("updating config map %s/%s", target.Namespace, target.Name)
+	common.LogKeyDiff(logger, target.Data, targetCopy.Data, false)
+	common.LogKeyDiff(logger, target.BinaryData, targetCopy.BinaryData, true)
 
+	targetCopy.Annotations[common.ReplicatedContentHashAnnotation] = contentHash
 	targetCopy.Annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
 	targetCopy.Annotations[common.ReplicatedFromVersionAnnotation] = source.ResourceVersion
 	targetCopy.Annotations[common.ReplicatedKeysAnnotation] = strings.Join(replicatedKeys, ",")
 
-	s, err := r.Client.CoreV1().ConfigMaps(target.Namespace).Update(context.TODO(), targetCopy, metav1.UpdateOptions{})
+	patch, err := common.StrategicMergePatch(target, targetCopy)
+	if err != nil {
+		return errors.Wrapf(err, "Failed updating target %s/%s", target.Namespace, targetCopy.Name)
+	}
+
+	s, err := r.Client.CoreV1().ConfigMaps(target.Namespace).Patch(context.TODO(), targetCopy.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
 	if err != nil {
 		err = errors.Wrapf(err, "Failed updating target %s/%s", target.Namespace, targetCopy.Name)
-	} else if err = r.Store.Update(s); err != nil {
-		err = errors.Wrapf(err, "Failed to update cache for %s/%s: %v", target.Namespace, targetCopy, err)
+	} else {
+		r.RememberWrite(s, s.Data, s.BinaryData)
+		if err = r.Store.Update(s); err != nil {
+			err = errors.Wrapf(err, "Failed to update cache for %s/%s: %v", target.Namespace, targetCopy, err)
+		}
 	}
 
 	return err
@@ -153,14 +199,14 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 // ReplicateObjectTo copies the whole object to target namespace
 func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespace) error {
 	source := sourceObj.(*v1.ConfigMap)
-	targetLocation := fmt.Sprintf("%s/%s", target.Name, source.Name)
+	targetLocation := fmt.Sprintf("%s/%s", target.Name, r.ResolveTargetName(source, target.Name))
 
 	logger := log.
 		WithField("kind", r.Kind).
 		WithField("source", common.MustGetKey(source)).
 		WithField("target", targetLocation)
 
-	targetResource, exists, err := r.Store.GetByKey(targetLocation)
+	targetResource, exists, err := r.LoadTarget(targetLocation)
 	if err != nil {
 		return errors.Wrapf(err, "Could not get %s from cache!", targetLocation)
 	}
@@ -182,9 +228,8 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		resourceCopy = new(v1.ConfigMap)
 	}
 
-	keepOwnerReferences, ok := source.Annotations[common.KeepOwnerReferences]
-	if ok && keepOwnerReferences == "true" {
-		resourceCopy.OwnerReferences = source.OwnerReferences
+	if ownerReferences, ok := r.TargetOwnerReferences(source, target.Name); ok {
+		resourceCopy.OwnerReferences = ownerReferences
 	}
 
 	if resourceCopy.Data == nil {
@@ -200,16 +245,40 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 	prevKeys, hasPrevKeys := common.PreviouslyPresentKeys(&resourceCopy.ObjectMeta)
 	replicatedKeys := make([]string, 0)
 
-	for key, value := range source.Data {
-		resourceCopy.Data[key] = value
+	render, err := r.NewValueRenderer(source, target.Name)
+	if err != nil {
+		return errors.Wrapf(err, "could not render values for %s", targetLocation)
+	}
+
+	for k, value := range source.Data {
+		if !common.IsKeyReplicated(source, k) {
+			continue
+		}
+		key := common.TargetKey(source, k)
+		value, err := render.Render(value)
+		if err != nil {
+			return errors.Wrapf(err, "could not render key %s of %s", k, common.MustGetKey(source))
+		}
+		value, err = common.FilterValue(source, k, value)
+		if err != nil {
+			return errors.Wrapf(err, "could not filter key %s of %s", k, common.MustGetKey(source))
+		}
+		key, _, err = storeValue(resourceCopy, source, nil, key, value)
+		if err != nil {
+			return errors.Wrapf(err, "could not store key %s of %s", k, common.MustGetKey(source))
+		}
 
 		replicatedKeys = append(replicatedKeys, key)
 		delete(prevKeys, key)
 	}
-	for key, value := range source.BinaryData {
-		newValue := make([]byte, len(value))
-		copy(newValue, value)
-		resourceCopy.BinaryData[key] = newValue
+	for k, value := range source.BinaryData {
+		if !common.IsKeyReplicated(source, k) {
+			continue
+		}
+		key, _, err := storeBinaryValue(resourceCopy, nil, common.TargetKey(source, k), value)
+		if err != nil {
+			return errors.Wrapf(err, "could not store key %s of %s", k, common.MustGetKey(source))
+		}
 
 		replicatedKeys = append(replicatedKeys, key)
 		delete(prevKeys, key)
@@ -219,6 +288,7 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		for k := range prevKeys {
 			logger.Debugf("removing previously present key %s: not present in source secret any more", k)
 			delete(resourceCopy.Data, k)
+			delete(resourceCopy.BinaryData, k)
 		}
 	}
 
@@ -233,17 +303,40 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		}
 	}
 
+	if common.GetReplicationStrategy(source) == common.ReplicationStrategyReplace {
+		common.DeleteKeysExcept(resourceCopy.Data, replicatedKeys)
+		common.DeleteKeysExcept(resourceCopy.BinaryData, replicatedKeys)
+	}
+
 	sort.Strings(replicatedKeys)
-	resourceCopy.Name = source.Name
+	resourceCopy.Name = r.ResolveTargetName(source, target.Name)
+	common.StripLabelsWithPrefixes(source, labelsCopy)
+	common.AddLabels(source, labelsCopy)
+	common.SetTrackingLabels(source, labelsCopy)
 	resourceCopy.Labels = labelsCopy
+	r.PropagateAnnotations(source.Annotations, resourceCopy.Annotations)
+	hashChanged := r.SetDataHash(resourceCopy.Annotations, resourceCopy.Data, resourceCopy.BinaryData)
+	contentHash := r.ContentHash(source, resourceCopy.Data, resourceCopy.BinaryData)
+	if exists && !hashChanged && common.HasContentHash(resourceCopy, contentHash) {
+		logger.Debugf("content of %s is unchanged; not updating it", targetLocation)
+		return nil
+	}
+	resourceCopy.Annotations[common.ReplicatedContentHashAnnotation] = contentHash
 	resourceCopy.Annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	r.SetProvenanceAnnotations(source, resourceCopy.Annotations)
 	resourceCopy.Annotations[common.ReplicatedFromVersionAnnotation] = source.ResourceVersion
 	resourceCopy.Annotations[common.ReplicatedKeysAnnotation] = strings.Join(replicatedKeys, ",")
 
 	var obj interface{}
 	if exists {
 		logger.Debugf("Updating existing secret %s/%s", target.Name, resourceCopy.Name)
-		obj, err = r.Client.CoreV1().ConfigMaps(target.Name).Update(context.TODO(), resourceCopy, metav1.UpdateOptions{})
+		existing := targetResource.(*v1.ConfigMap)
+		common.LogKeyDiff(logger, existing.Data, resourceCopy.Data, false)
+		common.LogKeyDiff(logger, existing.BinaryData, resourceCopy.BinaryData, true)
+		var patch []byte
+		if patch, err = common.StrategicMergePatch(targetResource, resourceCopy); err == nil {
+			obj, err = r.Client.CoreV1().ConfigMaps(target.Name).Patch(context.TODO(), resourceCopy.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+		}
 	} else {
 		logger.Debugf("Creating a new secret secret %s/%s", target.Name, resourceCopy.Name)
 		obj, err = r.Client.CoreV1().ConfigMaps(target.Name).Create(context.TODO(), resourceCopy, metav1.CreateOptions{})
@@ -252,7 +345,7 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		return errors.Wrapf(err, "Failed to update secret %s/%s", target.Name, resourceCopy.Name)
 	}
 
-	if err := r.Store.Update(obj); err != nil {
+	if err := r.CacheTarget(obj); err != nil {
 		return errors.Wrapf(err, "Failed to update cache for %s/%s", target.Name, resourceCopy)
 	}
 
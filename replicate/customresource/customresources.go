@@ -0,0 +1,278 @@
+package customresource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+type Replicator struct {
+	*common.GenericReplicator
+	DynamicClient dynamic.Interface
+	Resource      schema.GroupVersionResource
+}
+
+// NewReplicator creates a new replicator for a namespaced custom resource. The spec of the source object
+// is copied to its replicas.
+func NewReplicator(dynamicClient dynamic.Interface, resource schema.GroupVersionResource, kind string, config common.ReplicatorConfig) *Replicator {
+	client := dynamicClient.Resource(resource)
+
+	objType := &unstructured.Unstructured{}
+	objType.SetGroupVersionKind(resource.GroupVersion().WithKind(kind))
+
+	config.Kind = kind
+	config.ObjType = objType
+	config.DynamicClient = dynamicClient
+	config.ListFunc = func(lo metav1.ListOptions) (runtime.Object, error) {
+		return client.Namespace("").List(context.TODO(), lo)
+	}
+	config.WatchFunc = func(lo metav1.ListOptions) (watch.Interface, error) {
+		return client.Namespace("").Watch(context.TODO(), lo)
+	}
+
+	repl := Replicator{
+		GenericReplicator: common.NewGenericReplicator(config),
+		DynamicClient:     dynamicClient,
+		Resource:          resource,
+	}
+	repl.UpdateFuncs = common.UpdateFuncs{
+		ReplicateDataFrom:        repl.ReplicateDataFrom,
+		ReplicateObjectTo:        repl.ReplicateObjectTo,
+		PatchDeleteDependent:     repl.PatchDeleteDependent,
+		DeleteReplicatedResource: repl.DeleteReplicatedResource,
+	}
+
+	return &repl
+}
+
+func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interface{}) error {
+	source := sourceObj.(*unstructured.Unstructured)
+	target := targetObj.(*unstructured.Unstructured)
+
+	logger := log.
+		WithField("kind", r.Kind).
+		WithField("source", common.MustGetKey(source)).
+		WithField("target", common.MustGetKey(target))
+
+	// make sure replication is allowed
+	if ok, err := r.IsReplicationPermitted(objectMeta(target), objectMeta(source)); !ok {
+		return errors.Wrapf(err, "replication of target %s is not permitted", common.MustGetKey(source))
+	}
+
+	targetVersion, ok := target.GetAnnotations()[common.ReplicatedFromVersionAnnotation]
+	sourceVersion := source.GetResourceVersion()
+
+	if ok && targetVersion == sourceVersion {
+		logger.Debugf("target %s is already up-to-date", common.MustGetKey(target))
+		return nil
+	}
+
+	targetCopy := target.DeepCopy()
+	common.StripTranslatedAnnotations(targetCopy)
+	if err := copySpec(source, targetCopy); err != nil {
+		return errors.Wrapf(err, "Failed copying spec of %s", common.MustGetKey(source))
+	}
+
+	logger.Infof("updating target %s/%s", target.GetNamespace(), target.GetName())
+
+	annotations := targetCopy.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	r.PropagateAnnotations(source.GetAnnotations(), annotations)
+	annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	annotations[common.ReplicatedFromVersionAnnotation] = source.GetResourceVersion()
+	targetCopy.SetAnnotations(annotations)
+
+	s, err := r.DynamicClient.Resource(r.Resource).Namespace(target.GetNamespace()).Update(context.TODO(), targetCopy, metav1.UpdateOptions{})
+	if err != nil {
+		err = errors.Wrapf(err, "Failed updating target %s/%s", target.GetNamespace(), targetCopy.GetName())
+	} else if err = r.Store.Update(s); err != nil {
+		err = errors.Wrapf(err, "Failed to update cache for %s/%s: %v", target.GetNamespace(), targetCopy, err)
+	}
+
+	return err
+}
+
+// ReplicateObjectTo copies the whole object to target namespace
+func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespace) error {
+	source := sourceObj.(*unstructured.Unstructured)
+	targetLocation := fmt.Sprintf("%s/%s", target.Name, r.ResolveTargetName(source, target.Name))
+
+	logger := log.
+		WithField("kind", r.Kind).
+		WithField("source", common.MustGetKey(source)).
+		WithField("target", targetLocation)
+
+	targetResource, exists, err := r.Store.GetByKey(targetLocation)
+	if err != nil {
+		return errors.Wrapf(err, "Could not get %s from cache!", targetLocation)
+	}
+	logger.Infof("Checking if %s exists? %v", targetLocation, exists)
+
+	var targetCopy *unstructured.Unstructured
+	if exists {
+		targetObject := targetResource.(*unstructured.Unstructured)
+		targetVersion, ok := targetObject.GetAnnotations()[common.ReplicatedFromVersionAnnotation]
+		sourceVersion := source.GetResourceVersion()
+
+		if ok && targetVersion == sourceVersion {
+			logger.Debugf("%s %s is already up-to-date", r.Kind, common.MustGetKey(targetObject))
+			return nil
+		}
+
+		targetCopy = targetObject.DeepCopy()
+		common.StripTranslatedAnnotations(targetCopy)
+	} else {
+		targetCopy = new(unstructured.Unstructured)
+		targetCopy.SetGroupVersionKind(source.GroupVersionKind())
+	}
+
+	if ownerReferences, ok := r.TargetOwnerReferences(source, target.Name); ok {
+		targetCopy.SetOwnerReferences(ownerReferences)
+	}
+
+	labelsCopy := make(map[string]string)
+
+	stripLabels, ok := source.GetAnnotations()[common.StripLabels]
+	if !ok && stripLabels != "true" {
+		for key, value := range source.GetLabels() {
+			labelsCopy[key] = value
+		}
+	}
+
+	if err := copySpec(source, targetCopy); err != nil {
+		return errors.Wrapf(err, "Failed copying spec of %s", common.MustGetKey(source))
+	}
+
+	annotations := targetCopy.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	r.PropagateAnnotations(source.GetAnnotations(), annotations)
+	contentHash := r.ContentHash(source, targetCopy.Object["spec"])
+	if exists && common.HasContentHash(targetCopy, contentHash) {
+		logger.Debugf("content of %s is unchanged; not updating it", targetLocation)
+		return nil
+	}
+	annotations[common.ReplicatedContentHashAnnotation] = contentHash
+	annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	r.SetProvenanceAnnotations(source, annotations)
+	annotations[common.ReplicatedFromVersionAnnotation] = source.GetResourceVersion()
+
+	targetCopy.SetName(r.ResolveTargetName(source, target.Name))
+	targetCopy.SetNamespace(target.Name)
+	common.StripLabelsWithPrefixes(source, labelsCopy)
+	common.AddLabels(source, labelsCopy)
+	common.SetTrackingLabels(source, labelsCopy)
+	targetCopy.SetLabels(labelsCopy)
+	targetCopy.SetAnnotations(annotations)
+
+	resource := r.DynamicClient.Resource(r.Resource).Namespace(target.Name)
+
+	var obj interface{}
+	if exists {
+		logger.Debugf("Updating existing %s %s/%s", r.Kind, target.Name, targetCopy.GetName())
+		obj, err = resource.Update(context.TODO(), targetCopy, metav1.UpdateOptions{})
+	} else {
+		logger.Debugf("Creating a new %s %s/%s", r.Kind, target.Name, targetCopy.GetName())
+		obj, err = resource.Create(context.TODO(), targetCopy, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Failed to update %s %s/%s", r.Kind, target.Name, targetCopy.GetName())
+	}
+
+	if err := r.Store.Update(obj); err != nil {
+		return errors.Wrapf(err, "Failed to update cache for %s/%s", target.Name, targetCopy)
+	}
+
+	return nil
+}
+
+func (r *Replicator) PatchDeleteDependent(sourceKey string, target interface{}) (interface{}, error) {
+	dependentKey := common.MustGetKey(target)
+	logger := log.WithFields(log.Fields{
+		"kind":   r.Kind,
+		"source": sourceKey,
+		"target": dependentKey,
+	})
+
+	targetObject, ok := target.(*unstructured.Unstructured)
+	if !ok {
+		err := errors.Errorf("bad type returned from Store: %T", target)
+		return nil, err
+	}
+
+	if _, found := targetObject.Object["spec"]; !found {
+		return targetObject, nil
+	}
+
+	patch := []common.JSONPatchOperation{{Operation: "remove", Path: "/spec"}}
+	patchBody, err := json.Marshal(&patch)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while building patch body for %s %s: %v", r.Kind, dependentKey, err)
+	}
+
+	logger.Debugf("clearing dependent %s %s", r.Kind, dependentKey)
+	logger.Tracef("patch body: %s", string(patchBody))
+
+	s, err := r.DynamicClient.Resource(r.Resource).Namespace(targetObject.GetNamespace()).Patch(context.TODO(), targetObject.GetName(), types.JSONPatchType, patchBody, metav1.PatchOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while patching %s %s: %v", r.Kind, dependentKey, err)
+	}
+	return s, nil
+}
+
+// DeleteReplicatedResource deletes a resource replicated by ReplicateTo annotation
+func (r *Replicator) DeleteReplicatedResource(targetResource interface{}) error {
+	targetLocation := common.MustGetKey(targetResource)
+	logger := log.WithFields(log.Fields{
+		"kind":   r.Kind,
+		"target": targetLocation,
+	})
+
+	object := targetResource.(*unstructured.Unstructured)
+	logger.Debugf("Deleting %s", targetLocation)
+	if err := r.DynamicClient.Resource(r.Resource).Namespace(object.GetNamespace()).Delete(context.TODO(), object.GetName(), metav1.DeleteOptions{}); err != nil {
+		return errors.Wrapf(err, "Failed deleting %s: %v", targetLocation, err)
+	}
+	return nil
+}
+
+// copySpec replaces the spec of the target with a deep copy of the source's spec
+func copySpec(source, target *unstructured.Unstructured) error {
+	spec, found, err := unstructured.NestedMap(source.Object, "spec")
+	if err != nil {
+		return err
+	}
+	if !found {
+		unstructured.RemoveNestedField(target.Object, "spec")
+		return nil
+	}
+
+	return unstructured.SetNestedMap(target.Object, spec, "spec")
+}
+
+// objectMeta extracts the metadata needed for permission checks from an unstructured object
+func objectMeta(obj *unstructured.Unstructured) *metav1.ObjectMeta {
+	return &metav1.ObjectMeta{
+		Name:        obj.GetName(),
+		Namespace:   obj.GetNamespace(),
+		Annotations: obj.GetAnnotations(),
+	}
+}
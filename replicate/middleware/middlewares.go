@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/mittwald/kubernetes-replicator/replicate/customresource"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// GroupVersionResources lists the API groups in which Traefik serves middlewares, in order of preference. Traefik v3
+// only serves traefik.io, while Traefik v2 serves traefik.containo.us and, since v2.10, traefik.io as well.
+var GroupVersionResources = []schema.GroupVersionResource{
+	{Group: "traefik.io", Version: "v1alpha1", Resource: "middlewares"},
+	{Group: "traefik.containo.us", Version: "v1alpha1", Resource: "middlewares"},
+}
+
+// ServedResource returns the first of GroupVersionResources that is served by the API server
+func ServedResource(client discovery.DiscoveryInterface) (schema.GroupVersionResource, error) {
+	for _, resource := range GroupVersionResources {
+		resources, err := client.ServerResourcesForGroupVersion(resource.GroupVersion().String())
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return schema.GroupVersionResource{}, errors.Wrapf(err, "could not discover resources of %s", resource.GroupVersion())
+		}
+
+		for _, served := range resources.APIResources {
+			if served.Name == resource.Resource {
+				return resource, nil
+			}
+		}
+	}
+
+	return schema.GroupVersionResource{}, errors.Errorf("none of %v is served; is Traefik installed?", GroupVersionResources)
+}
+
+// NewReplicator creates a new Traefik middleware replicator for the given resource, see ServedResource
+func NewReplicator(dynamicClient dynamic.Interface, resource schema.GroupVersionResource, config common.ReplicatorConfig) common.Replicator {
+	return customresource.NewReplicator(dynamicClient, resource, "Middleware", config)
+}
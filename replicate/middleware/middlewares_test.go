@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/mittwald/kubernetes-replicator/replicate/customresource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func discoveryServing(groupVersions ...string) *fakediscovery.FakeDiscovery {
+	client := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}
+	for _, groupVersion := range groupVersions {
+		client.Resources = append(client.Resources, &metav1.APIResourceList{
+			GroupVersion: groupVersion,
+			APIResources: []metav1.APIResource{{Name: "middlewares", Kind: "Middleware", Namespaced: true}},
+		})
+	}
+	return client
+}
+
+func TestServedResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		served   []string
+		expected string
+	}{
+		{name: "Traefik v3", served: []string{"traefik.io/v1alpha1"}, expected: "traefik.io"},
+		{name: "Traefik v2.10", served: []string{"traefik.containo.us/v1alpha1", "traefik.io/v1alpha1"}, expected: "traefik.io"},
+		{name: "Traefik v2", served: []string{"traefik.containo.us/v1alpha1"}, expected: "traefik.containo.us"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource, err := ServedResource(discoveryServing(test.served...))
+			assert.NoError(t, err)
+			assert.Equal(t, schema.GroupVersionResource{Group: test.expected, Version: "v1alpha1", Resource: "middlewares"}, resource)
+		})
+	}
+}
+
+func TestServedResourceWithoutTraefik(t *testing.T) {
+	_, err := ServedResource(discoveryServing())
+	assert.Error(t, err)
+}
+
+func TestReplicatesSpec(t *testing.T) {
+	resource := GroupVersionResources[1]
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": resource.GroupVersion().String(),
+		"kind":       "Middleware",
+		"metadata": map[string]interface{}{
+			"name":            "rate-limit",
+			"namespace":       "shared",
+			"resourceVersion": "1",
+		},
+		"spec": map[string]interface{}{
+			"rateLimit": map[string]interface{}{"average": int64(100), "burst": int64(50)},
+		},
+	}}
+
+	scheme := runtime.NewScheme()
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{
+		resource: "MiddlewareList",
+	})
+	repl := NewReplicator(dynamicClient, resource, common.ReplicatorConfig{Client: fake.NewSimpleClientset()}).(*customresource.Replicator)
+
+	require.NoError(t, repl.ReplicateObjectTo(source, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}}))
+
+	replica, err := dynamicClient.Resource(resource).Namespace("app").Get(context.TODO(), "rate-limit", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, source.Object["spec"], replica.Object["spec"])
+	assert.Equal(t, "1", replica.GetAnnotations()[common.ReplicatedFromVersionAnnotation])
+}
@@ -0,0 +1,20 @@
+package networkattachmentdefinition
+
+import (
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/mittwald/kubernetes-replicator/replicate/customresource"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// GroupVersionResource identifies the Multus NetworkAttachmentDefinition resource
+var GroupVersionResource = schema.GroupVersionResource{
+	Group:    "k8s.cni.cncf.io",
+	Version:  "v1",
+	Resource: "network-attachment-definitions",
+}
+
+// NewReplicator creates a new network attachment definition replicator
+func NewReplicator(dynamicClient dynamic.Interface, config common.ReplicatorConfig) common.Replicator {
+	return customresource.NewReplicator(dynamicClient, GroupVersionResource, "NetworkAttachmentDefinition", config)
+}
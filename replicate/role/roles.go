@@ -16,7 +16,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/kubernetes"
 )
 
 type Replicator struct {
@@ -24,21 +23,19 @@ type Replicator struct {
 }
 
 // NewReplicator creates a new role replicator
-func NewReplicator(client kubernetes.Interface, resyncPeriod time.Duration, allowAll bool) common.Replicator {
+func NewReplicator(config common.ReplicatorConfig) common.Replicator {
+	client := config.Client
+	config.Kind = "Role"
+	config.ObjType = &rbacv1.Role{}
+	config.ListFunc = func(lo metav1.ListOptions) (runtime.Object, error) {
+		return client.RbacV1().Roles("").List(context.TODO(), lo)
+	}
+	config.WatchFunc = func(lo metav1.ListOptions) (watch.Interface, error) {
+		return client.RbacV1().Roles("").Watch(context.TODO(), lo)
+	}
+
 	repl := Replicator{
-		GenericReplicator: common.NewGenericReplicator(common.ReplicatorConfig{
-			Kind:         "Role",
-			ObjType:      &rbacv1.Role{},
-			AllowAll:     allowAll,
-			ResyncPeriod: resyncPeriod,
-			Client:       client,
-			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
-				return client.RbacV1().Roles("").List(context.TODO(), lo)
-			},
-			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
-				return client.RbacV1().Roles("").Watch(context.TODO(), lo)
-			},
-		}),
+		GenericReplicator: common.NewGenericReplicator(config),
 	}
 	repl.UpdateFuncs = common.UpdateFuncs{
 		ReplicateDataFrom:        repl.ReplicateDataFrom,
@@ -73,7 +70,9 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 	}
 
 	targetCopy := target.DeepCopy()
+	common.StripTranslatedAnnotations(targetCopy)
 	targetCopy.Rules = source.Rules
+	r.PropagateAnnotations(source.Annotations, targetCopy.Annotations)
 
 	logger.Infof("updating target %s/%s", target.Namespace, target.Name)
 
@@ -93,7 +92,7 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 // ReplicateObjectTo copies the whole object to target namespace
 func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespace) error {
 	source := sourceObj.(*rbacv1.Role)
-	targetLocation := fmt.Sprintf("%s/%s", target.Name, source.Name)
+	targetLocation := fmt.Sprintf("%s/%s", target.Name, r.ResolveTargetName(source, target.Name))
 
 	logger := log.
 		WithField("kind", r.Kind).
@@ -118,13 +117,13 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		}
 
 		targetCopy = targetObject.DeepCopy()
+		common.StripTranslatedAnnotations(targetCopy)
 	} else {
 		targetCopy = new(rbacv1.Role)
 	}
 
-	keepOwnerReferences, ok := source.Annotations[common.KeepOwnerReferences]
-	if ok && keepOwnerReferences == "true" {
-		targetCopy.OwnerReferences = source.OwnerReferences
+	if ownerReferences, ok := r.TargetOwnerReferences(source, target.Name); ok {
+		targetCopy.OwnerReferences = ownerReferences
 	}
 
 	if targetCopy.Rules == nil {
@@ -145,10 +144,21 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		}
 	}
 
-	targetCopy.Name = source.Name
+	targetCopy.Name = r.ResolveTargetName(source, target.Name)
+	common.StripLabelsWithPrefixes(source, labelsCopy)
+	common.AddLabels(source, labelsCopy)
+	common.SetTrackingLabels(source, labelsCopy)
 	targetCopy.Labels = labelsCopy
+	r.PropagateAnnotations(source.Annotations, targetCopy.Annotations)
 	targetCopy.Rules = source.Rules
+	contentHash := r.ContentHash(source, targetCopy.Rules)
+	if exists && common.HasContentHash(targetCopy, contentHash) {
+		logger.Debugf("content of %s is unchanged; not updating it", targetLocation)
+		return nil
+	}
+	targetCopy.Annotations[common.ReplicatedContentHashAnnotation] = contentHash
 	targetCopy.Annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	r.SetProvenanceAnnotations(source, targetCopy.Annotations)
 	targetCopy.Annotations[common.ReplicatedFromVersionAnnotation] = source.ResourceVersion
 
 	var obj interface{}
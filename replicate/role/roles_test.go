@@ -7,13 +7,13 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/mittwald/kubernetes-replicator/test/harness"
 	pkgerrors "github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
@@ -22,7 +22,6 @@ import (
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 func namespacePrefix() string {
@@ -60,26 +59,37 @@ func (pf *PlainFormatter) Format(entry *log.Entry) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
-func TestRoleReplicator(t *testing.T) {
+// apiServer runs the integration tests; they are skipped if it could not be started (see harness.StartAPIServer)
+var apiServer *harness.APIServer
 
-	log.SetLevel(log.TraceLevel)
-	log.SetFormatter(&PlainFormatter{})
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
 
-	kubeconfig := os.Getenv("KUBECONFIG")
-	//is KUBECONFIG is not specified try to use the local KUBECONFIG or the in cluster config
-	if len(kubeconfig) == 0 {
-		if home := homeDir(); home != "" && home != "/root" {
-			kubeconfig = filepath.Join(home, ".kube", "config")
-		}
+func run(m *testing.M) int {
+	var err error
+	if apiServer, err = harness.StartAPIServer(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	defer func() {
+		if err := apiServer.Stop(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	require.NoError(t, err)
+	return m.Run()
+}
+
+func TestRoleReplicator(t *testing.T) {
+
+	log.SetLevel(log.TraceLevel)
+	log.SetFormatter(&PlainFormatter{})
 
 	prefix := namespacePrefix()
-	client := kubernetes.NewForConfigOrDie(config)
+	client := apiServer.Client(t)
 
-	repl := NewReplicator(client, 60*time.Second, false)
+	repl := NewReplicator(common.ReplicatorConfig{Client: client, ResyncPeriod: 60 * time.Second})
 	go repl.Run()
 
 	time.Sleep(200 * time.Millisecond)
@@ -89,7 +99,7 @@ func TestRoleReplicator(t *testing.T) {
 			Name: prefix + "test",
 		},
 	}
-	_, err = client.CoreV1().Namespaces().Create(context.TODO(), &ns, metav1.CreateOptions{})
+	_, err := client.CoreV1().Namespaces().Create(context.TODO(), &ns, metav1.CreateOptions{})
 	require.NoError(t, err)
 
 	ns2 := corev1.Namespace{
@@ -329,7 +339,7 @@ func TestRoleReplicator(t *testing.T) {
 
 }
 
-func waitForNamespaces(client *kubernetes.Clientset, count int, eventHandlers EventHandlerFuncs) (wg *sync.WaitGroup, stop chan struct{}) {
+func waitForNamespaces(client kubernetes.Interface, count int, eventHandlers EventHandlerFuncs) (wg *sync.WaitGroup, stop chan struct{}) {
 	wg = &sync.WaitGroup{}
 	wg.Add(count)
 	informerFactory := informers.NewSharedInformerFactory(client, 60*time.Second)
@@ -359,7 +369,7 @@ func waitForNamespaces(client *kubernetes.Clientset, count int, eventHandlers Ev
 
 }
 
-func waitForRoles(client *kubernetes.Clientset, count int, eventHandlers EventHandlerFuncs) (wg *sync.WaitGroup, stop chan struct{}) {
+func waitForRoles(client kubernetes.Interface, count int, eventHandlers EventHandlerFuncs) (wg *sync.WaitGroup, stop chan struct{}) {
 	wg = &sync.WaitGroup{}
 	wg.Add(count)
 	informerFactory := informers.NewSharedInformerFactory(client, 60*time.Second)
@@ -404,10 +414,3 @@ func waitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) {
 		log.WithError(err).Debugf("Wait timed out")
 	}
 }
-
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
-	}
-	return os.Getenv("USERPROFILE") // windows
-}
@@ -0,0 +1,134 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// MergeDataFrom merges the registry credentials of several image pull secrets into the ".dockerconfigjson" key
+// of the target. If more than one source has credentials for the same registry, the source listed first wins.
+func (r *Replicator) MergeDataFrom(sourceObjs []interface{}, targetObj interface{}) error {
+	target := targetObj.(*v1.Secret)
+
+	logger := log.
+		WithField("kind", r.Kind).
+		WithField("target", common.MustGetKey(target))
+
+	if target.Type != v1.SecretTypeDockerConfigJson {
+		return errors.Errorf("target %s is of type %s, expected %s", common.MustGetKey(target), target.Type, v1.SecretTypeDockerConfigJson)
+	}
+
+	auths := make(map[string]json.RawMessage)
+	merged := make([]string, 0, len(sourceObjs))
+	for _, sourceObj := range sourceObjs {
+		source := sourceObj.(*v1.Secret)
+		sourceKey := common.MustGetKey(source)
+
+		if ok, err := r.IsReplicationPermitted(&target.ObjectMeta, &source.ObjectMeta); !ok {
+			logger.WithError(err).Warnf("not merging %s", sourceKey)
+			continue
+		}
+
+		sourceAuths, err := registryAuths(source)
+		if err != nil {
+			logger.WithError(err).Warnf("not merging %s", sourceKey)
+			continue
+		}
+
+		for registry, auth := range sourceAuths {
+			if _, ok := auths[registry]; !ok {
+				auths[registry] = auth
+			}
+		}
+		merged = append(merged, sourceKey)
+	}
+
+	dockerConfig, err := json.Marshal(map[string]interface{}{"auths": auths})
+	if err != nil {
+		return errors.Wrapf(err, "could not encode merged credentials for %s", common.MustGetKey(target))
+	}
+
+	targetCopy := target.DeepCopy()
+	if targetCopy.Data == nil {
+		targetCopy.Data = make(map[string][]byte)
+	}
+	if targetCopy.Annotations == nil {
+		targetCopy.Annotations = make(map[string]string)
+	}
+
+	mergedSources := strings.Join(merged, ",")
+	dataChanged := !bytes.Equal(targetCopy.Data[v1.DockerConfigJsonKey], dockerConfig) ||
+		targetCopy.Annotations[common.MergedSourcesAnnotation] != mergedSources
+	targetCopy.Data[v1.DockerConfigJsonKey] = dockerConfig
+
+	if r.SetDataHash(targetCopy.Annotations, targetCopy.Data) {
+		dataChanged = true
+	}
+
+	if !dataChanged {
+		logger.Debugf("target %s is already up-to-date", common.MustGetKey(target))
+		return nil
+	}
+
+	contentHash := common.DataHash(mergedSources, targetCopy.Data)
+	if r.IsUnchangedSinceWrite(target, contentHash, target.Data) {
+		logger.Debugf("%s has not changed since it was last written from the same data; not writing it again", common.MustGetKey(target))
+		return nil
+	}
+
+	logger.Infof("updating target %s with credentials of %s", common.MustGetKey(target), mergedSources)
+
+	targetCopy.Annotations[common.ReplicatedContentHashAnnotation] = contentHash
+	targetCopy.Annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	targetCopy.Annotations[common.MergedSourcesAnnotation] = mergedSources
+
+	patch, err := common.StrategicMergePatch(target, targetCopy)
+	if err != nil {
+		return errors.Wrapf(err, "Failed updating target %s/%s", target.Namespace, targetCopy.Name)
+	}
+
+	s, err := r.Client.CoreV1().Secrets(target.Namespace).Patch(context.TODO(), targetCopy.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		err = errors.Wrapf(err, "Failed updating target %s/%s", target.Namespace, targetCopy.Name)
+	} else {
+		r.RememberWrite(s, s.Data)
+		r.Audit(common.AuditOperationUpdate, mergedSources, common.MustGetKey(s), common.AuditReasonMerge)
+		if err = r.Store.Update(s); err != nil {
+			err = errors.Wrapf(err, "Failed to update cache for %s/%s: %v", target.Namespace, targetCopy.Name, err)
+		}
+	}
+	return err
+}
+
+// registryAuths returns the credentials per registry of an image pull secret of type
+// "kubernetes.io/dockerconfigjson" or "kubernetes.io/dockercfg"
+func registryAuths(source *v1.Secret) (map[string]json.RawMessage, error) {
+	switch source.Type {
+	case v1.SecretTypeDockerConfigJson:
+		var config struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if err := json.Unmarshal(source.Data[v1.DockerConfigJsonKey], &config); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", v1.DockerConfigJsonKey)
+		}
+		return config.Auths, nil
+	case v1.SecretTypeDockercfg:
+		var auths map[string]json.RawMessage
+		if err := json.Unmarshal(source.Data[v1.DockerConfigKey], &auths); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", v1.DockerConfigKey)
+		}
+		return auths, nil
+	default:
+		return nil, errors.Errorf("secret is of type %s, expected %s or %s", source.Type, v1.SecretTypeDockerConfigJson, v1.SecretTypeDockercfg)
+	}
+}
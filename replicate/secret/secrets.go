@@ -9,45 +9,57 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/mittwald/kubernetes-replicator/replicate/common"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/types"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/kubernetes"
 )
 
 type Replicator struct {
 	*common.GenericReplicator
 }
 
+const serviceAccountRetryInterval = 200 * time.Millisecond
+
+// serviceAccountPatchAttempts is the number of times a service account is read and patched again when it was
+// changed concurrently
+const serviceAccountPatchAttempts = 3
+
 // NewReplicator creates a new secret replicator
-func NewReplicator(client kubernetes.Interface, resyncPeriod time.Duration, allowAll, syncByContent bool) common.Replicator {
+func NewReplicator(config common.ReplicatorConfig) common.Replicator {
+	client := config.Client
+	config.Kind = "Secret"
+	config.ObjType = &v1.Secret{}
+	config.ListFunc = func(lo metav1.ListOptions) (runtime.Object, error) {
+		return client.CoreV1().Secrets("").List(context.TODO(), lo)
+	}
+	config.WatchFunc = func(lo metav1.ListOptions) (watch.Interface, error) {
+		return client.CoreV1().Secrets("").Watch(context.TODO(), lo)
+	}
+	config.GetFunc = func(namespace string, name string) (runtime.Object, error) {
+		return client.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
+	config.TargetResource = v1.SchemeGroupVersion.WithResource("secrets")
+
+	// replicas are updated with patches, which keep the annotation written by "kubectl apply"
+	config.StripLastAppliedConfiguration = true
+
 	repl := Replicator{
-		GenericReplicator: common.NewGenericReplicator(common.ReplicatorConfig{
-			Kind:          "Secret",
-			ObjType:       &v1.Secret{},
-			AllowAll:      allowAll,
-			SyncByContent: syncByContent,
-			ResyncPeriod:  resyncPeriod,
-			Client:        client,
-			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
-				return client.CoreV1().Secrets("").List(context.TODO(), lo)
-			},
-			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
-				return client.CoreV1().Secrets("").Watch(context.TODO(), lo)
-			},
-		}),
+		GenericReplicator: common.NewGenericReplicator(config),
 	}
 	repl.UpdateFuncs = common.UpdateFuncs{
 		ReplicateDataFrom:        repl.ReplicateDataFrom,
 		ReplicateObjectTo:        repl.ReplicateObjectTo,
 		PatchDeleteDependent:     repl.PatchDeleteDependent,
 		DeleteReplicatedResource: repl.DeleteReplicatedResource,
+		MergeDataFrom:            repl.MergeDataFrom,
 	}
 
 	return &repl
@@ -68,6 +80,11 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 		return errors.Wrapf(err, "replication of target %s is not permitted", common.MustGetKey(source))
 	}
 
+	if serviceAccountTokenMode(source) != common.ServiceAccountTokenModeCopy {
+		logger.Infof("not copying service account token %s: tokens are bound to their service account", common.MustGetKey(source))
+		return nil
+	}
+
 	targetVersion, ok := target.Annotations[common.ReplicatedFromVersionAnnotation]
 	sourceVersion := source.ResourceVersion
 
@@ -84,10 +101,21 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 	prevKeys, hasPrevKeys := common.PreviouslyPresentKeys(&targetCopy.ObjectMeta)
 	replicatedKeys := make([]string, 0)
 
+	render, err := r.NewValueRenderer(source, target.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "could not render values for %s", common.MustGetKey(target))
+	}
+
 	dataChanged := false
-	for key, value := range source.Data {
-		newValue := make([]byte, len(value))
-		copy(newValue, value)
+	for k, value := range source.Data {
+		key, ok := common.PulledKey(source, target, k)
+		if !ok {
+			continue
+		}
+		newValue, err := render.RenderBytes(value)
+		if err != nil {
+			return errors.Wrapf(err, "could not render key %s of %s", k, common.MustGetKey(source))
+		}
 		oldValue, ok := targetCopy.Data[key]
 		if ok {
 			if bytes.Compare(newValue, oldValue) != 0 {
@@ -110,24 +138,60 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 		}
 	}
 
+	if common.GetReplicationStrategy(source) == common.ReplicationStrategyReplace {
+		if common.DeleteKeysExcept(targetCopy.Data, replicatedKeys) {
+			dataChanged = true
+		}
+	}
+
+	if dataChanged && common.ComparesCertificates(source, target.Type) && common.SameCertificateData(target.Data, targetCopy.Data) {
+		logger.Debugf("certificate of %s is unchanged; not updating its data", common.MustGetKey(target))
+		targetCopy.Data = target.DeepCopy().Data
+		dataChanged = false
+	}
+
+	if r.PropagateAnnotations(source.Annotations, targetCopy.Annotations) {
+		dataChanged = true
+	}
+
+	if r.SetDataHash(targetCopy.Annotations, targetCopy.Data) {
+		dataChanged = true
+	}
+
 	if !dataChanged {
 		logger.Debugf("target values of %s are already up-to-date", common.MustGetKey(target))
 		return nil
 	}
 
+	contentHash := r.ContentHash(source, targetCopy.Data)
+	if r.IsUnchangedSinceWrite(target, contentHash, target.Data) {
+		logger.Debugf("%s has not changed since it was last written from the same data; not writing it again", common.MustGetKey(target))
+		return nil
+	}
+
 	sort.Strings(replicatedKeys)
 
 	logger.Infof("updating target %s", common.MustGetKey(target))
+	common.LogKeyDiff(logger, target.Data, targetCopy.Data, true)
 
+	targetCopy.Annotations[common.ReplicatedContentHashAnnotation] = contentHash
 	targetCopy.Annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
 	targetCopy.Annotations[common.ReplicatedFromVersionAnnotation] = source.ResourceVersion
 	targetCopy.Annotations[common.ReplicatedKeysAnnotation] = strings.Join(replicatedKeys, ",")
 
-	s, err := r.Client.CoreV1().Secrets(target.Namespace).Update(context.TODO(), targetCopy, metav1.UpdateOptions{})
+	patch, err := common.StrategicMergePatch(target, targetCopy)
+	if err != nil {
+		return errors.Wrapf(err, "Failed updating target %s/%s", target.Namespace, targetCopy.Name)
+	}
+
+	s, err := r.Client.CoreV1().Secrets(target.Namespace).Patch(context.TODO(), targetCopy.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
 	if err != nil {
 		err = errors.Wrapf(err, "Failed updating target %s/%s", target.Namespace, targetCopy.Name)
-	} else if err = r.Store.Update(s); err != nil {
-		err = errors.Wrapf(err, "Failed to update cache for %s/%s: %v", target.Namespace, targetCopy, err)
+	} else {
+		r.RememberWrite(s, s.Data)
+		if err = r.Store.Update(s); err != nil {
+			err = errors.Wrapf(err, "Failed to update cache for %s/%s: %v", target.Namespace, targetCopy, err)
+		}
 	}
 	return err
 }
@@ -135,15 +199,23 @@ func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interfac
 // ReplicateObjectTo copies the whole object to target namespace
 func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespace) error {
 	source := sourceObj.(*v1.Secret)
-	targetLocation := fmt.Sprintf("%s/%s", target.Name, source.Name)
+	targetLocation := fmt.Sprintf("%s/%s", target.Name, r.ResolveTargetName(source, target.Name))
 
 	logger := log.
 		WithField("kind", r.Kind).
 		WithField("source", common.MustGetKey(source)).
 		WithField("target", targetLocation)
 
+	switch serviceAccountTokenMode(source) {
+	case common.ServiceAccountTokenModeSkip:
+		logger.Infof("not replicating service account token %s", common.MustGetKey(source))
+		return nil
+	case common.ServiceAccountTokenModeRegenerate:
+		return r.replicateServiceAccountToken(source, target)
+	}
+
 	targetResourceType := source.Type
-	targetResource, exists, err := r.Store.GetByKey(targetLocation)
+	targetResource, exists, err := r.LoadTarget(targetLocation)
 	if err != nil {
 		return errors.Wrapf(err, "Could not get %s from cache!", targetLocation)
 	}
@@ -157,7 +229,7 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 
 		if ok && targetVersion == sourceVersion {
 			logger.Debugf("Secret %s is already up-to-date", common.MustGetKey(targetObject))
-			return nil
+			return r.patchServiceAccounts(source, target.Name)
 		}
 
 		targetResourceType = targetObject.Type
@@ -166,9 +238,13 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		resourceCopy = new(v1.Secret)
 	}
 
-	keepOwnerReferences, ok := source.Annotations[common.KeepOwnerReferences]
-	if ok && keepOwnerReferences == "true" {
-		resourceCopy.OwnerReferences = source.OwnerReferences
+	if ownerReferences, ok := r.TargetOwnerReferences(source, target.Name); ok {
+		resourceCopy.OwnerReferences = ownerReferences
+	}
+
+	ownByNamespace, ok := source.Annotations[common.OwnByNamespace]
+	if ok && ownByNamespace == "true" {
+		resourceCopy.OwnerReferences = common.WithNamespaceOwnerReference(resourceCopy.OwnerReferences, target)
 	}
 
 	if resourceCopy.Data == nil {
@@ -178,7 +254,26 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		resourceCopy.Annotations = make(map[string]string)
 	}
 
-	replicatedKeys := r.extractReplicatedKeys(source, targetLocation, resourceCopy)
+	render, err := r.NewValueRenderer(source, target.Name)
+	if err != nil {
+		return errors.Wrapf(err, "could not render values for %s", targetLocation)
+	}
+
+	replicatedKeys, err := r.extractReplicatedKeys(source, targetLocation, resourceCopy, render)
+	if err != nil {
+		return err
+	}
+
+	if common.GetReplicationStrategy(source) == common.ReplicationStrategyReplace {
+		common.DeleteKeysExcept(resourceCopy.Data, replicatedKeys)
+	}
+
+	if exists && common.ComparesCertificates(source, targetResourceType) {
+		if existing := targetResource.(*v1.Secret); common.SameCertificateData(existing.Data, resourceCopy.Data) {
+			logger.Debugf("certificate of %s is unchanged; not updating its data", targetLocation)
+			resourceCopy.Data = existing.DeepCopy().Data
+		}
+	}
 
 	sort.Strings(replicatedKeys)
 
@@ -193,31 +288,152 @@ func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, target *v1.Namespa
 		}
 	}
 
-	resourceCopy.Name = source.Name
+	common.StripLabelsWithPrefixes(source, labelsCopy)
+	common.AddLabels(source, labelsCopy)
+	common.SetTrackingLabels(source, labelsCopy)
+	resourceCopy.Name = r.ResolveTargetName(source, target.Name)
 	resourceCopy.Labels = labelsCopy
 	resourceCopy.Type = targetResourceType
+	r.PropagateAnnotations(source.Annotations, resourceCopy.Annotations)
+	hashChanged := r.SetDataHash(resourceCopy.Annotations, resourceCopy.Data)
+	contentHash := r.ContentHash(source, resourceCopy.Type, resourceCopy.Data)
+	if exists && !hashChanged && common.HasContentHash(resourceCopy, contentHash) {
+		logger.Debugf("content of %s is unchanged; not updating it", targetLocation)
+		return r.patchServiceAccounts(source, target.Name)
+	}
+	resourceCopy.Annotations[common.ReplicatedContentHashAnnotation] = contentHash
 	resourceCopy.Annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	r.SetProvenanceAnnotations(source, resourceCopy.Annotations)
 	resourceCopy.Annotations[common.ReplicatedFromVersionAnnotation] = source.ResourceVersion
 	resourceCopy.Annotations[common.ReplicatedKeysAnnotation] = strings.Join(replicatedKeys, ",")
 
 	var obj interface{}
 	if exists {
 		logger.Debugf("Updating existing secret %s/%s", target.Name, resourceCopy.Name)
-		obj, err = r.Client.CoreV1().Secrets(target.Name).Update(context.TODO(), resourceCopy, metav1.UpdateOptions{})
+		common.LogKeyDiff(logger, targetResource.(*v1.Secret).Data, resourceCopy.Data, true)
+		var patch []byte
+		if patch, err = common.StrategicMergePatch(targetResource, resourceCopy); err == nil {
+			obj, err = r.Client.CoreV1().Secrets(target.Name).Patch(context.TODO(), resourceCopy.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+		}
 	} else {
 		logger.Debugf("Creating a new secret secret %s/%s", target.Name, resourceCopy.Name)
 		obj, err = r.Client.CoreV1().Secrets(target.Name).Create(context.TODO(), resourceCopy, metav1.CreateOptions{})
 	}
 	if err != nil {
 		err = errors.Wrapf(err, "Failed to update secret %s/%s", target.Name, resourceCopy.Name)
-	} else if err = r.Store.Update(obj); err != nil {
+	} else if err = r.CacheTarget(obj); err != nil {
 		err = errors.Wrapf(err, "Failed to update cache for %s/%s", target.Name, resourceCopy)
+	} else {
+		err = r.patchServiceAccounts(source, target.Name)
 	}
 
 	return err
 }
 
-func (r *Replicator) extractReplicatedKeys(source *v1.Secret, targetLocation string, resourceCopy *v1.Secret) []string {
+// patchServiceAccounts adds a replicated image pull secret to the imagePullSecrets of the service accounts
+// listed in the PatchServiceAccounts annotation of the source. Service accounts are retried for a short time,
+// since the "default" service account of a new namespace is created asynchronously.
+func (r *Replicator) patchServiceAccounts(source *v1.Secret, namespace string) error {
+	serviceAccountList, ok := source.Annotations[common.PatchServiceAccounts]
+	if !ok {
+		return nil
+	}
+
+	logger := log.
+		WithField("kind", r.Kind).
+		WithField("source", common.MustGetKey(source))
+
+	if source.Type != v1.SecretTypeDockerConfigJson && source.Type != v1.SecretTypeDockercfg {
+		logger.Warnf("ignoring %s annotation: secret is of type %s", common.PatchServiceAccounts, source.Type)
+		return nil
+	}
+
+	if strings.TrimSpace(serviceAccountList) == "" {
+		serviceAccountList = "default"
+	}
+
+	secretName := r.ResolveTargetName(source, namespace)
+
+	var result error
+	for _, name := range strings.Split(serviceAccountList, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if err := r.addImagePullSecret(logger, namespace, name, secretName); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result
+}
+
+// addImagePullSecret appends the image pull secret to the imagePullSecrets of a service account, unless it is
+// already listed. The list is atomic, so a strategic merge patch would replace it as a whole; instead, the
+// secret is appended with a JSON patch that first tests that the list has not changed since it was read, and
+// the service account is read again if it has.
+func (r *Replicator) addImagePullSecret(logger *log.Entry, namespace string, name string, secretName string) error {
+	var err error
+	for attempt := 0; attempt < serviceAccountPatchAttempts; attempt++ {
+		var serviceAccount *v1.ServiceAccount
+		serviceAccount, err = r.getServiceAccount(namespace, name)
+		if err != nil {
+			return errors.Wrapf(err, "could not get service account %s/%s", namespace, name)
+		}
+
+		if hasImagePullSecret(serviceAccount, secretName) {
+			return nil
+		}
+
+		reference := v1.LocalObjectReference{Name: secretName}
+		patch := []common.JSONPatchOperation{{Operation: "add", Path: "/imagePullSecrets", Value: []v1.LocalObjectReference{reference}}}
+		if len(serviceAccount.ImagePullSecrets) > 0 {
+			patch = []common.JSONPatchOperation{
+				{Operation: "test", Path: "/imagePullSecrets", Value: serviceAccount.ImagePullSecrets},
+				{Operation: "add", Path: "/imagePullSecrets/-", Value: reference},
+			}
+		}
+
+		var patchBody []byte
+		if patchBody, err = json.Marshal(&patch); err != nil {
+			return errors.Wrapf(err, "error while building patch body for service account %s/%s", namespace, name)
+		}
+
+		logger.Infof("adding image pull secret %s to service account %s/%s", secretName, namespace, name)
+		logger.Tracef("patch body: %s", string(patchBody))
+
+		_, err = r.Client.CoreV1().ServiceAccounts(namespace).Patch(context.TODO(), name, types.JSONPatchType, patchBody, metav1.PatchOptions{})
+		if err == nil {
+			return nil
+		}
+		logger.WithError(err).Debugf("could not patch service account %s/%s; reading it again", namespace, name)
+	}
+
+	return errors.Wrapf(err, "error while patching service account %s/%s", namespace, name)
+}
+
+func (r *Replicator) getServiceAccount(namespace string, name string) (serviceAccount *v1.ServiceAccount, err error) {
+	for i := 0; i < 10; i++ {
+		serviceAccount, err = r.Client.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err == nil || !apierrors.IsNotFound(err) {
+			return
+		}
+		time.Sleep(serviceAccountRetryInterval)
+	}
+	return
+}
+
+func hasImagePullSecret(serviceAccount *v1.ServiceAccount, secretName string) bool {
+	for _, ref := range serviceAccount.ImagePullSecrets {
+		if ref.Name == secretName {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Replicator) extractReplicatedKeys(source *v1.Secret, targetLocation string, resourceCopy *v1.Secret, render *common.ValueRenderer) ([]string, error) {
 	logger := log.
 		WithField("kind", r.Kind).
 		WithField("source", common.MustGetKey(source)).
@@ -226,9 +442,15 @@ func (r *Replicator) extractReplicatedKeys(source *v1.Secret, targetLocation str
 	prevKeys, hasPrevKeys := common.PreviouslyPresentKeys(&resourceCopy.ObjectMeta)
 	replicatedKeys := make([]string, 0)
 
-	for key, value := range source.Data {
-		newValue := make([]byte, len(value))
-		copy(newValue, value)
+	for k, value := range source.Data {
+		if !common.IsKeyReplicated(source, k) {
+			continue
+		}
+		key := common.TargetKey(source, k)
+		newValue, err := render.RenderBytes(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not render key %s of %s", k, common.MustGetKey(source))
+		}
 		resourceCopy.Data[key] = newValue
 
 		replicatedKeys = append(replicatedKeys, key)
@@ -241,7 +463,7 @@ func (r *Replicator) extractReplicatedKeys(source *v1.Secret, targetLocation str
 			delete(resourceCopy.Data, k)
 		}
 	}
-	return replicatedKeys
+	return replicatedKeys, nil
 }
 
 func (r *Replicator) PatchDeleteDependent(sourceKey string, target interface{}) (interface{}, error) {
@@ -285,7 +507,7 @@ func (r *Replicator) DeleteReplicatedResource(targetResource interface{}) error
 
 	object := targetResource.(*v1.Secret)
 	resourceKeys := strings.Join(common.GetKeysFromBinaryMap(object.Data), ",")
-	if resourceKeys == object.Annotations[common.ReplicatedKeysAnnotation] {
+	if resourceKeys == object.Annotations[common.ReplicatedKeysAnnotation] || object.Type == v1.SecretTypeServiceAccountToken {
 		logger.Debugf("Deleting %s", targetLocation)
 		if err := r.Client.CoreV1().Secrets(object.Namespace).Delete(context.TODO(), object.Name, metav1.DeleteOptions{}); err != nil {
 			return errors.Wrapf(err, "Failed deleting %s: %v", targetLocation, err)
@@ -4,9 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"k8s.io/client-go/tools/clientcmd"
 	"os"
-	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
@@ -14,6 +12,7 @@ import (
 	"time"
 
 	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/mittwald/kubernetes-replicator/test/harness"
 	pkgerrors "github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
@@ -23,6 +22,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -61,19 +61,26 @@ func (pf *PlainFormatter) Format(entry *log.Entry) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
-func setupRealClientSet(t *testing.T) *kubernetes.Clientset {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	//is KUBECONFIG is not specified try to use the local KUBECONFIG or the in cluster config
-	if len(kubeconfig) == 0 {
-		if home := homeDir(); home != "" && home != "/root" {
-			kubeconfig = filepath.Join(home, ".kube", "config")
-		}
-	}
+// apiServer runs the integration tests; they are skipped if it could not be started (see harness.StartAPIServer)
+var apiServer *harness.APIServer
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	require.NoError(t, err)
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	var err error
+	if apiServer, err = harness.StartAPIServer(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() {
+		if err := apiServer.Stop(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
 
-	return kubernetes.NewForConfigOrDie(config)
+	return m.Run()
 }
 
 func TestSecretReplicator(t *testing.T) {
@@ -81,9 +88,9 @@ func TestSecretReplicator(t *testing.T) {
 	log.SetLevel(log.TraceLevel)
 	log.SetFormatter(&PlainFormatter{})
 
-	client := setupRealClientSet(t)
+	client := apiServer.Client(t)
 
-	repl := NewReplicator(client, 60*time.Second, false, false)
+	repl := NewReplicator(common.ReplicatorConfig{Client: client, ResyncPeriod: 60 * time.Second})
 	go repl.Run()
 
 	time.Sleep(200 * time.Millisecond)
@@ -1290,10 +1297,10 @@ func TestSecretReplicatorSyncByContent(t *testing.T) {
 	log.SetFormatter(&PlainFormatter{})
 
 	prefix := namespacePrefix()
-	client := setupRealClientSet(t)
+	client := apiServer.Client(t)
 	ctx := context.TODO()
 
-	repl := NewReplicator(client, 60*time.Second, false, true)
+	repl := NewReplicator(common.ReplicatorConfig{Client: client, ResyncPeriod: 60 * time.Second, SyncByContent: true})
 	go repl.Run()
 
 	time.Sleep(200 * time.Millisecond)
@@ -1460,9 +1467,37 @@ func waitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) {
 	}
 }
 
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
+func TestPatchServiceAccountsPreservesImagePullSecrets(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Namespace: "team-a", Name: "default"},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "existing"}},
+	}, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "builder"},
+	})
+	repl := &Replicator{GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{
+		Kind:   "Secret",
+		Client: client,
+	}}}
+
+	registry := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Annotations: map[string]string{
+				common.PatchServiceAccounts: "default,builder",
+			}},
+			Type: corev1.SecretTypeDockerConfigJson,
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, repl.patchServiceAccounts(registry("registry-a"), "team-a"))
+		require.NoError(t, repl.patchServiceAccounts(registry("registry-b"), "team-a"))
 	}
-	return os.Getenv("USERPROFILE") // windows
+
+	sa, err := client.CoreV1().ServiceAccounts("team-a").Get(context.TODO(), "default", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []corev1.LocalObjectReference{{Name: "existing"}, {Name: "registry-a"}, {Name: "registry-b"}}, sa.ImagePullSecrets)
+
+	sa, err = client.CoreV1().ServiceAccounts("team-a").Get(context.TODO(), "builder", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []corev1.LocalObjectReference{{Name: "registry-a"}, {Name: "registry-b"}}, sa.ImagePullSecrets)
 }
@@ -0,0 +1,97 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// serviceAccountTokenMode returns how the source is replicated if it is a service account token
+func serviceAccountTokenMode(source *v1.Secret) string {
+	if source.Type != v1.SecretTypeServiceAccountToken {
+		return common.ServiceAccountTokenModeCopy
+	}
+
+	switch mode := source.Annotations[common.ServiceAccountTokenMode]; mode {
+	case common.ServiceAccountTokenModeSkip, common.ServiceAccountTokenModeRegenerate:
+		return mode
+	default:
+		return common.ServiceAccountTokenModeCopy
+	}
+}
+
+// replicateServiceAccountToken creates a new service account token secret in the target namespace for the
+// service account with the same name as the one of the source. The token itself is not copied, since it is
+// bound to the source's service account; instead, Kubernetes issues a new token for the target namespace.
+func (r *Replicator) replicateServiceAccountToken(source *v1.Secret, target *v1.Namespace) error {
+	name := r.ResolveTargetName(source, target.Name)
+	targetLocation := fmt.Sprintf("%s/%s", target.Name, name)
+	serviceAccountName := source.Annotations[v1.ServiceAccountNameKey]
+
+	logger := log.
+		WithField("kind", r.Kind).
+		WithField("source", common.MustGetKey(source)).
+		WithField("target", targetLocation)
+
+	if serviceAccountName == "" {
+		return errors.Errorf("service account token %s does not reference a service account", common.MustGetKey(source))
+	}
+
+	targetResource, exists, err := r.LoadTarget(targetLocation)
+	if err != nil {
+		return errors.Wrapf(err, "Could not get %s from cache!", targetLocation)
+	}
+
+	if exists {
+		targetObject := targetResource.(*v1.Secret)
+		if targetObject.Type == v1.SecretTypeServiceAccountToken && targetObject.Annotations[v1.ServiceAccountNameKey] == serviceAccountName {
+			logger.Debugf("service account token %s has already been issued", targetLocation)
+			return nil
+		}
+		return errors.Errorf("cannot issue service account token %s: a different secret with this name exists", targetLocation)
+	}
+
+	labelsCopy := make(map[string]string)
+	if source.Annotations[common.StripLabels] != "true" {
+		for key, value := range source.Labels {
+			labelsCopy[key] = value
+		}
+	}
+
+	common.StripLabelsWithPrefixes(source, labelsCopy)
+	common.AddLabels(source, labelsCopy)
+	common.SetTrackingLabels(source, labelsCopy)
+
+	resource := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labelsCopy,
+			Annotations: map[string]string{
+				v1.ServiceAccountNameKey:               serviceAccountName,
+				common.ReplicatedAtAnnotation:          time.Now().Format(time.RFC3339),
+				common.ReplicatedFromVersionAnnotation: source.ResourceVersion,
+				common.ReplicatedKeysAnnotation:        "",
+			},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+	}
+	r.SetProvenanceAnnotations(source, resource.Annotations)
+
+	logger.Infof("issuing a new token for service account %s/%s", target.Name, serviceAccountName)
+	obj, err := r.Client.CoreV1().Secrets(target.Name).Create(context.TODO(), resource, metav1.CreateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create service account token %s", targetLocation)
+	}
+
+	if err := r.CacheTarget(obj); err != nil {
+		return errors.Wrapf(err, "Failed to update cache for %s", targetLocation)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package replications
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+)
+
+type response struct {
+	Sources []common.ReplicationSource `json:"sources"`
+}
+
+// Handler implements a HTTP response handler that lists the sources of all replicators together with the targets
+// they are replicated into. The list can be restricted to a kind with the "kind" query parameter.
+type Handler struct {
+	Replicators []common.Replicator
+}
+
+func (h *Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	kind := req.URL.Query().Get("kind")
+	r := response{
+		Sources: []common.ReplicationSource{},
+	}
+
+	for _, replicator := range h.Replicators {
+		reporter, ok := replicator.(common.GraphReporter)
+		if !ok {
+			continue
+		}
+		for _, source := range reporter.Replications() {
+			if kind == "" || strings.EqualFold(source.Kind, kind) {
+				r.Sources = append(r.Sources, source)
+			}
+		}
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	_ = enc.Encode(&r)
+}
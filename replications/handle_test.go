@@ -0,0 +1,70 @@
+package replications
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+type MockReplicator struct {
+	sources []common.ReplicationSource
+}
+
+func (r *MockReplicator) Run() {
+}
+
+func (r *MockReplicator) Synced() bool {
+	return true
+}
+
+// noinspection GoUnusedParameter
+func (r *MockReplicator) NamespaceAdded(ns *v1.Namespace) {
+	// Do nothing
+}
+
+func (r *MockReplicator) Replications() []common.ReplicationSource {
+	return r.sources
+}
+
+func TestListsReplications(t *testing.T) {
+	secret := common.ReplicationSource{
+		Kind:    "Secret",
+		Source:  "default/credentials",
+		Version: "42",
+		Targets: []common.ReplicationTarget{{Target: "team-a/credentials", Mode: common.ReplicationModePush, ReplicatedVersion: "42"}},
+	}
+	configMap := common.ReplicationSource{Kind: "ConfigMap", Source: "default/settings", Missing: true, Targets: []common.ReplicationTarget{}}
+
+	handler := Handler{
+		Replicators: []common.Replicator{
+			&MockReplicator{sources: []common.ReplicationSource{secret}},
+			&MockReplicator{sources: []common.ReplicationSource{configMap}},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/replications", nil)
+	assert.Nil(t, err)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+	var body response
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, []common.ReplicationSource{secret, configMap}, body.Sources)
+
+	req, err = http.NewRequest("GET", "/api/v1/replications?kind=configmap", nil)
+	assert.Nil(t, err)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	body = response{}
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, []common.ReplicationSource{configMap}, body.Sources)
+}
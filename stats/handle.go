@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+)
+
+type memoryStats struct {
+	HeapAllocBytes  uint64 `json:"heapAllocBytes"`
+	HeapInuseBytes  uint64 `json:"heapInuseBytes"`
+	HeapObjects     uint64 `json:"heapObjects"`
+	SysBytes        uint64 `json:"sysBytes"`
+	NumGC           uint32 `json:"numGC"`
+	LastGCPauseNs   uint64 `json:"lastGCPauseNs"`
+	TotalGCPauseNs  uint64 `json:"totalGCPauseNs"`
+	NumGoroutine    int    `json:"numGoroutine"`
+	NextGCThreshold uint64 `json:"nextGCThreshold"`
+}
+
+type response struct {
+	Replicators  []common.ReplicatorStats `json:"replicators"`
+	SharedCaches map[string]int           `json:"sharedCaches"`
+	Memory       memoryStats              `json:"memory"`
+}
+
+// Handler implements a HTTP response handler that reports the sizes of the
+// controller's internal caches and Go runtime memory statistics
+type Handler struct {
+	Replicators []common.Replicator
+}
+
+func readMemoryStats() memoryStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return memoryStats{
+		HeapAllocBytes:  m.HeapAlloc,
+		HeapInuseBytes:  m.HeapInuse,
+		HeapObjects:     m.HeapObjects,
+		SysBytes:        m.Sys,
+		NumGC:           m.NumGC,
+		LastGCPauseNs:   m.PauseNs[(m.NumGC+255)%256],
+		TotalGCPauseNs:  m.PauseTotalNs,
+		NumGoroutine:    runtime.NumGoroutine(),
+		NextGCThreshold: m.NextGC,
+	}
+}
+
+//noinspection GoUnusedParameter
+func (h *Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	r := response{
+		Replicators:  make([]common.ReplicatorStats, 0, len(h.Replicators)),
+		SharedCaches: common.SharedCacheStats(),
+		Memory:       readMemoryStats(),
+	}
+
+	for _, replicator := range h.Replicators {
+		if reporter, ok := replicator.(common.StatsReporter); ok {
+			r.Replicators = append(r.Replicators, reporter.Stats())
+		}
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	_ = enc.Encode(&r)
+}
@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+type MockReplicator struct {
+	stats common.ReplicatorStats
+}
+
+func (r *MockReplicator) Run() {
+}
+
+func (r *MockReplicator) Synced() bool {
+	return true
+}
+
+//noinspection GoUnusedParameter
+func (r *MockReplicator) NamespaceAdded(ns *v1.Namespace) {
+	// Do nothing
+}
+
+func (r *MockReplicator) Stats() common.ReplicatorStats {
+	return r.stats
+}
+
+func TestReportsReplicatorStats(t *testing.T) {
+	req, err := http.NewRequest("GET", "/debug/stats", nil)
+	assert.Nil(t, err)
+	res := httptest.NewRecorder()
+
+	handler := Handler{
+		Replicators: []common.Replicator{
+			&MockReplicator{stats: common.ReplicatorStats{Kind: "Secret", CachedObjects: 42}},
+		},
+	}
+
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	var body response
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, []common.ReplicatorStats{{Kind: "Secret", CachedObjects: 42}}, body.Replicators)
+	assert.NotZero(t, body.Memory.SysBytes)
+}
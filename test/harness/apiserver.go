@@ -0,0 +1,56 @@
+package harness
+
+import (
+	"os"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// AssetsVariable is the environment variable that names the directory with the kube-apiserver and etcd binaries
+const AssetsVariable = "KUBEBUILDER_ASSETS"
+
+// APIServer is a local API server and etcd, started by envtest. There are no controllers running besides the
+// replicators, so e.g. namespaces are never removed once deleted, and no service account tokens are issued.
+type APIServer struct {
+	Config *rest.Config
+
+	env *envtest.Environment
+}
+
+// StartAPIServer starts a local API server with the binaries in the directory named by AssetsVariable. It returns
+// nil if the variable is not set; tests that need the API server are skipped then (see APIServer.Client).
+func StartAPIServer() (*APIServer, error) {
+	if os.Getenv(AssetsVariable) == "" {
+		return nil, nil
+	}
+
+	env := &envtest.Environment{}
+	config, err := env.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIServer{Config: config, env: env}, nil
+}
+
+// Stop stops the API server, if it was started
+func (s *APIServer) Stop() error {
+	if s == nil {
+		return nil
+	}
+	return s.env.Stop()
+}
+
+// Client returns a client for the API server, or skips the test if it was not started
+func (s *APIServer) Client(t testing.TB) kubernetes.Interface {
+	t.Helper()
+
+	if s == nil {
+		t.Skipf("%s is not set; skipping test against a local API server", AssetsVariable)
+	}
+
+	return kubernetes.NewForConfigOrDie(s.Config)
+}
@@ -0,0 +1,313 @@
+// Package harness runs replicators against a Kubernetes API, so that replication behaviour can be tested without a
+// live cluster.
+//
+// By default, the API is an in-memory one backed by the fake clientset of client-go (see New). It does not validate
+// objects and does not implement admission, defaulting or the resource version semantics of watches; the harness
+// only assigns a new resource version on every create, update and patch. When the KUBEBUILDER_ASSETS environment
+// variable names a directory with the kube-apiserver and etcd binaries (e.g. as installed by
+// "setup-envtest use -p path"), a local API server can be started with envtest instead (see StartAPIServer and
+// NewWithAPIServer).
+//
+// Since the replicators share a process-wide namespace watcher, only one Environment can be created per test
+// binary; create it in TestMain and isolate tests by giving each of them its own namespaces (see
+// Environment.Namespace).
+package harness
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	// Timeout is the time the assertions wait for the replicators to reach the expected state
+	Timeout = 5 * time.Second
+
+	// Interval is the time between two checks of the expected state
+	Interval = 50 * time.Millisecond
+
+	// QuietPeriod is the time AssertNotReplicated waits to make sure that no replica shows up
+	QuietPeriod = 500 * time.Millisecond
+
+	created atomic.Bool
+)
+
+// Constructor creates a replicator from a configuration, e.g. secret.NewReplicator
+type Constructor func(config common.ReplicatorConfig) common.Replicator
+
+// Lookup fetches an object by namespace and name
+type Lookup func(namespace string, name string) (metav1.Object, error)
+
+// Environment is a Kubernetes API with a set of running replicators
+type Environment struct {
+	Client      kubernetes.Interface
+	Replicators []common.Replicator
+
+	namespaces atomic.Int64
+
+	watchLock sync.Mutex
+	watches   int
+}
+
+// New starts the given replicators against a new in-memory API that initially contains the given objects. It
+// returns once all replicators have synced their caches and are watching for changes.
+func New(config common.ReplicatorConfig, constructors []Constructor, objects ...runtime.Object) (*Environment, error) {
+	if !created.CompareAndSwap(false, true) {
+		return nil, fmt.Errorf("only one environment can be created per process")
+	}
+
+	client := fake.NewSimpleClientset(objects...)
+	env := Environment{
+		Client: client,
+	}
+
+	// The fake clientset does not maintain resource versions, which the replicators use to detect outdated
+	// replicas. Handle all actions with a tracker that does, in front of the default reactor.
+	client.PrependReactor("*", "*", k8stesting.ObjectReaction(&versionedTracker{ObjectTracker: client.Tracker()}))
+
+	// The informers of the replicators miss all changes made between their initial list and the start of their
+	// watch. Count the established watches, so that New does not return before all of them are in place.
+	client.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		w, err := client.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+
+		env.watchLock.Lock()
+		env.watches++
+		env.watchLock.Unlock()
+
+		return true, w, nil
+	})
+
+	// one watch per replicator, plus the shared namespace watcher
+	if err := env.start(config, constructors, len(constructors)+1); err != nil {
+		return nil, err
+	}
+
+	return &env, nil
+}
+
+// NewWithAPIServer starts the given replicators against the API server. It returns once all replicators have
+// synced their caches.
+func NewWithAPIServer(server *APIServer, config common.ReplicatorConfig, constructors []Constructor) (*Environment, error) {
+	if !created.CompareAndSwap(false, true) {
+		return nil, fmt.Errorf("only one environment can be created per process")
+	}
+
+	client, err := kubernetes.NewForConfig(server.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	// informers of a real API server watch from the resource version of their initial list, so they don't miss
+	// any changes and there is no need to wait for their watches
+	env := Environment{
+		Client: client,
+	}
+	if err := env.start(config, constructors, 0); err != nil {
+		return nil, err
+	}
+
+	return &env, nil
+}
+
+// start runs the replicators created by the given constructors and waits until they have synced and the expected
+// number of watches is established
+func (e *Environment) start(config common.ReplicatorConfig, constructors []Constructor, expectedWatches int) error {
+	config.Client = e.Client
+	for _, constructor := range constructors {
+		repl := constructor(config)
+		go repl.Run()
+		e.Replicators = append(e.Replicators, repl)
+	}
+
+	deadline := time.Now().Add(Timeout)
+	for !e.started(expectedWatches) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("replicators did not start within %s", Timeout)
+		}
+		time.Sleep(Interval)
+	}
+
+	return nil
+}
+
+// versionedTracker assigns a new resource version to every object that is created, updated or patched
+type versionedTracker struct {
+	k8stesting.ObjectTracker
+
+	resourceVersion atomic.Int64
+}
+
+func (t *versionedTracker) Create(gvr schema.GroupVersionResource, obj runtime.Object, ns string, opts ...metav1.CreateOptions) error {
+	t.setResourceVersion(obj)
+	return t.ObjectTracker.Create(gvr, obj, ns, opts...)
+}
+
+func (t *versionedTracker) Update(gvr schema.GroupVersionResource, obj runtime.Object, ns string, opts ...metav1.UpdateOptions) error {
+	t.setResourceVersion(obj)
+	return t.ObjectTracker.Update(gvr, obj, ns, opts...)
+}
+
+func (t *versionedTracker) Patch(gvr schema.GroupVersionResource, obj runtime.Object, ns string, opts ...metav1.PatchOptions) error {
+	t.setResourceVersion(obj)
+	return t.ObjectTracker.Patch(gvr, obj, ns, opts...)
+}
+
+func (t *versionedTracker) setResourceVersion(obj runtime.Object) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetResourceVersion(strconv.FormatInt(t.resourceVersion.Add(1), 10))
+	}
+}
+
+// started checks if all replicators have synced and the expected number of watches is established
+func (e *Environment) started(expectedWatches int) bool {
+	for _, repl := range e.Replicators {
+		if !repl.Synced() {
+			return false
+		}
+	}
+
+	e.watchLock.Lock()
+	defer e.watchLock.Unlock()
+
+	return e.watches >= expectedWatches
+}
+
+// Namespace creates a namespace with a name that is unique within the environment and derived from the test name
+func (e *Environment) Namespace(t testing.TB, labels map[string]string) string {
+	t.Helper()
+
+	name := fmt.Sprintf("%s-%d", strings.ToLower(strings.NewReplacer("/", "-", "_", "-").Replace(t.Name())), e.namespaces.Add(1))
+	if len(name) > 63 {
+		name = name[len(name)-63:]
+	}
+	name = strings.TrimLeft(name, "-")
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+
+	_, err := e.Client.CoreV1().Namespaces().Create(context.TODO(), &ns, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	return name
+}
+
+// Secrets looks up secrets in the environment
+func (e *Environment) Secrets() Lookup {
+	return func(namespace string, name string) (metav1.Object, error) {
+		return e.Client.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
+}
+
+// ConfigMaps looks up config maps in the environment
+func (e *Environment) ConfigMaps() Lookup {
+	return func(namespace string, name string) (metav1.Object, error) {
+		return e.Client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
+}
+
+// Roles looks up roles in the environment
+func (e *Environment) Roles() Lookup {
+	return func(namespace string, name string) (metav1.Object, error) {
+		return e.Client.RbacV1().Roles(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
+}
+
+// AssertReplicated waits until an object with the given name exists in all given namespaces and returns the
+// replicas by namespace. If check is not nil, it is called for each replica and the assertion keeps waiting
+// until it returns true for all of them.
+func (e *Environment) AssertReplicated(t testing.TB, lookup Lookup, name string, namespaces []string, check func(replica metav1.Object) bool) map[string]metav1.Object {
+	t.Helper()
+
+	replicas := make(map[string]metav1.Object)
+	missing := namespaces
+
+	ok := assert.Eventually(t, func() bool {
+		missing = nil
+		for _, namespace := range namespaces {
+			obj, err := lookup(namespace, name)
+			if err != nil || (check != nil && !check(obj)) {
+				missing = append(missing, namespace)
+				continue
+			}
+			replicas[namespace] = obj
+		}
+		return len(missing) == 0
+	}, Timeout, Interval)
+
+	if !ok {
+		t.Errorf("%s was not replicated to %v", name, missing)
+	}
+
+	return replicas
+}
+
+// AssertNotReplicated makes sure that no object with the given name shows up in any of the given namespaces
+// for QuietPeriod
+func (e *Environment) AssertNotReplicated(t testing.TB, lookup Lookup, name string, namespaces []string) {
+	t.Helper()
+
+	assert.Never(t, func() bool {
+		for _, namespace := range namespaces {
+			if _, err := lookup(namespace, name); !apierrors.IsNotFound(err) {
+				t.Logf("%s/%s exists", namespace, name)
+				return true
+			}
+		}
+		return false
+	}, QuietPeriod, Interval)
+}
+
+// AssertDeleted waits until no object with the given name exists in any of the given namespaces
+func (e *Environment) AssertDeleted(t testing.TB, lookup Lookup, name string, namespaces []string) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool {
+		for _, namespace := range namespaces {
+			if _, err := lookup(namespace, name); !apierrors.IsNotFound(err) {
+				return false
+			}
+		}
+		return true
+	}, Timeout, Interval, "%s was not deleted from %v", name, namespaces)
+}
+
+// AssertNotDeleted makes sure that objects with the given name exist in all given namespaces for QuietPeriod
+func (e *Environment) AssertNotDeleted(t testing.TB, lookup Lookup, name string, namespaces []string) {
+	t.Helper()
+
+	assert.Never(t, func() bool {
+		for _, namespace := range namespaces {
+			if _, err := lookup(namespace, name); err != nil {
+				t.Logf("%s/%s: %v", namespace, name, err)
+				return true
+			}
+		}
+		return false
+	}, QuietPeriod, Interval)
+}
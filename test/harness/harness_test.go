@@ -0,0 +1,615 @@
+package harness_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mittwald/kubernetes-replicator/replicate/clusterrole"
+	"github.com/mittwald/kubernetes-replicator/replicate/common"
+	"github.com/mittwald/kubernetes-replicator/replicate/configmap"
+	"github.com/mittwald/kubernetes-replicator/replicate/secret"
+	"github.com/mittwald/kubernetes-replicator/test/harness"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var env *harness.Environment
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+// run runs the tests against a local API server if one can be started, and against the in-memory API otherwise
+func run(m *testing.M) int {
+	server, err := harness.StartAPIServer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() {
+		if err := server.Stop(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+
+	config := common.ReplicatorConfig{Workers: 4, AdoptLegacyReplicas: true, TenantLabel: "tenant"}
+	constructors := []harness.Constructor{secret.NewReplicator, configmap.NewReplicator, clusterrole.NewReplicator}
+	if server != nil {
+		env, err = harness.NewWithAPIServer(server, config, constructors)
+	} else {
+		env, err = harness.New(config, constructors)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return m.Run()
+}
+
+func TestPushReplication(t *testing.T) {
+	source := env.Namespace(t, nil)
+	teamA := env.Namespace(t, map[string]string{"team": "a"})
+	teamB := env.Namespace(t, map[string]string{"team": "a"})
+	other := env.Namespace(t, map[string]string{"team": "b"})
+
+	_, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "shared",
+			Annotations: map[string]string{
+				common.ReplicateToMatching: "team=a",
+			},
+		},
+		Data: map[string][]byte{"password": []byte("secret")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	replicas := env.AssertReplicated(t, env.Secrets(), "shared", []string{teamA, teamB}, nil)
+	for _, replica := range replicas {
+		assert.Equal(t, []byte("secret"), replica.(*corev1.Secret).Data["password"])
+	}
+	env.AssertNotReplicated(t, env.Secrets(), "shared", []string{other})
+
+	late := env.Namespace(t, map[string]string{"team": "a"})
+	env.AssertReplicated(t, env.Secrets(), "shared", []string{late}, nil)
+
+	require.NoError(t, env.Client.CoreV1().Secrets(source).Delete(context.TODO(), "shared", metav1.DeleteOptions{}))
+	env.AssertDeleted(t, env.Secrets(), "shared", []string{teamA, teamB, late})
+}
+
+func TestPullReplication(t *testing.T) {
+	source := env.Namespace(t, nil)
+	target := env.Namespace(t, nil)
+
+	_, err := env.Client.CoreV1().ConfigMaps(source).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "settings",
+			Annotations: map[string]string{
+				common.ReplicationAllowed:           "true",
+				common.ReplicationAllowedNamespaces: target,
+			},
+		},
+		Data: map[string]string{"level": "debug"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = env.Client.CoreV1().ConfigMaps(target).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "settings",
+			Annotations: map[string]string{
+				common.ReplicateFromAnnotation: source + "/settings",
+			},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.ConfigMaps(), "settings", []string{target}, func(replica metav1.Object) bool {
+		return replica.(*corev1.ConfigMap).Data["level"] == "debug"
+	})
+}
+
+func TestReplicateTrigger(t *testing.T) {
+	source := env.Namespace(t, nil)
+	target := env.Namespace(t, nil)
+
+	secret, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "credentials",
+			Annotations: map[string]string{
+				common.ReplicateTo: target,
+			},
+		},
+		Data: map[string][]byte{"password": []byte("secret")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	replica := env.AssertReplicated(t, env.Secrets(), "credentials", []string{target}, nil)[target].(*corev1.Secret)
+
+	// replicas that were changed by hand are only repaired when the source changes
+	replica.Data["password"] = []byte("changed")
+	_, err = env.Client.CoreV1().Secrets(target).Update(context.TODO(), replica, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	secret.Annotations[common.ReplicateTrigger] = "1"
+	_, err = env.Client.CoreV1().Secrets(source).Update(context.TODO(), secret, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.Secrets(), "credentials", []string{target}, func(replica metav1.Object) bool {
+		return string(replica.(*corev1.Secret).Data["password"]) == "secret"
+	})
+}
+
+func TestDeletionPolicyRetain(t *testing.T) {
+	source := env.Namespace(t, nil)
+	target := env.Namespace(t, nil)
+
+	_, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "mounted",
+			Annotations: map[string]string{
+				common.ReplicateTo:    target,
+				common.DeletionPolicy: common.DeletionPolicyRetain,
+			},
+		},
+		Data: map[string][]byte{"password": []byte("secret")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.Secrets(), "mounted", []string{target}, nil)
+
+	require.NoError(t, env.Client.CoreV1().Secrets(source).Delete(context.TODO(), "mounted", metav1.DeleteOptions{}))
+	env.AssertNotDeleted(t, env.Secrets(), "mounted", []string{target})
+}
+
+func TestMetadataOnlyChange(t *testing.T) {
+	source := env.Namespace(t, nil)
+	target := env.Namespace(t, nil)
+
+	secret, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "managed",
+			Annotations: map[string]string{
+				common.ReplicateTo: target,
+			},
+		},
+		Data: map[string][]byte{"password": []byte("secret")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	replica := env.AssertReplicated(t, env.Secrets(), "managed", []string{target}, nil)[target]
+
+	secret.Labels = map[string]string{"app.kubernetes.io/instance": "managed"}
+	secret, err = env.Client.CoreV1().Secrets(source).Update(context.TODO(), secret, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	assert.Never(t, func() bool {
+		current, err := env.Secrets()(target, "managed")
+		return err != nil || current.GetResourceVersion() != replica.GetResourceVersion()
+	}, harness.QuietPeriod, harness.Interval)
+
+	secret.Data["password"] = []byte("changed")
+	_, err = env.Client.CoreV1().Secrets(source).Update(context.TODO(), secret, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.Secrets(), "managed", []string{target}, func(replica metav1.Object) bool {
+		return string(replica.(*corev1.Secret).Data["password"]) == "changed"
+	})
+}
+
+func TestReplicateToIntersect(t *testing.T) {
+	source := env.Namespace(t, nil)
+	matching := env.Namespace(t, map[string]string{"team": "a"})
+	unlabeled := env.Namespace(t, nil)
+	other := env.Namespace(t, map[string]string{"team": "a"})
+
+	_, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "intersected",
+			Annotations: map[string]string{
+				common.ReplicateTo:          matching + "," + unlabeled,
+				common.ReplicateToMatching:  "team=a",
+				common.ReplicateToIntersect: "true",
+			},
+		},
+		Data: map[string][]byte{"password": []byte("secret")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.Secrets(), "intersected", []string{matching}, nil)
+	env.AssertNotReplicated(t, env.Secrets(), "intersected", []string{unlabeled, other})
+
+	ns, err := env.Client.CoreV1().Namespaces().Get(context.TODO(), unlabeled, metav1.GetOptions{})
+	require.NoError(t, err)
+	ns.Labels = map[string]string{"team": "a"}
+	_, err = env.Client.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.Secrets(), "intersected", []string{unlabeled}, nil)
+}
+
+func TestKeepOnNamespaceLabelChange(t *testing.T) {
+	source := env.Namespace(t, nil)
+	kept := env.Namespace(t, map[string]string{"team": "a"})
+	removed := env.Namespace(t, map[string]string{"team": "a"})
+
+	for name, keep := range map[string]string{"kept": "true", "removed": "false"} {
+		_, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Annotations: map[string]string{
+					common.ReplicateToMatching:        "team=a",
+					common.KeepOnNamespaceLabelChange: keep,
+				},
+			},
+			Data: map[string][]byte{"password": []byte("secret")},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	env.AssertReplicated(t, env.Secrets(), "kept", []string{kept, removed}, nil)
+	env.AssertReplicated(t, env.Secrets(), "removed", []string{kept, removed}, nil)
+
+	for _, name := range []string{kept, removed} {
+		ns, err := env.Client.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		ns.Labels = map[string]string{"team": "b"}
+		_, err = env.Client.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+		require.NoError(t, err)
+	}
+
+	env.AssertDeleted(t, env.Secrets(), "removed", []string{kept, removed})
+	env.AssertNotDeleted(t, env.Secrets(), "kept", []string{kept, removed})
+}
+
+func TestAcceptFrom(t *testing.T) {
+	source := env.Namespace(t, nil)
+	accepting := env.Namespace(t, nil)
+	other := env.Namespace(t, nil)
+
+	allowed := map[string]string{
+		common.ReplicationAllowed:           "true",
+		common.ReplicationAllowedNamespaces: accepting,
+	}
+
+	_, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard-tls", Annotations: allowed},
+		Data:       map[string][]byte{"tls.crt": []byte("cert")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "token"},
+		Data:       map[string][]byte{"token": []byte("secret")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	ns, err := env.Client.CoreV1().Namespaces().Get(context.TODO(), accepting, metav1.GetOptions{})
+	require.NoError(t, err)
+	ns.Annotations = map[string]string{common.AcceptFrom: source + "/wildcard-tls, " + source + "/token, " + source + "/missing"}
+	_, err = env.Client.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.Secrets(), "wildcard-tls", []string{accepting}, nil)
+	env.AssertNotReplicated(t, env.Secrets(), "wildcard-tls", []string{other})
+	env.AssertNotReplicated(t, env.Secrets(), "token", []string{accepting})
+
+	_, err = env.Client.CoreV1().ConfigMaps(source).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "missing", Annotations: allowed},
+		Data:       map[string]string{"key": "value"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.ConfigMaps(), "missing", []string{accepting}, nil)
+}
+
+func TestMergeFrom(t *testing.T) {
+	infra := env.Namespace(t, nil)
+	team := env.Namespace(t, nil)
+	target := env.Namespace(t, nil)
+
+	registryCredentials := func(namespace, name, registry, auth string) {
+		_, err := env.Client.CoreV1().Secrets(namespace).Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Annotations: map[string]string{
+					common.ReplicationAllowed:           "true",
+					common.ReplicationAllowedNamespaces: target,
+				},
+			},
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, registry, auth)),
+			},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	registryCredentials(infra, "registry", "registry.example.com", "aW5mcmE=")
+
+	_, err := env.Client.CoreV1().Secrets(target).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pull-secret",
+			Annotations: map[string]string{
+				common.MergeFrom: infra + "/registry," + team + "/registry",
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.Secrets(), "pull-secret", []string{target}, func(replica metav1.Object) bool {
+		return string(replica.(*corev1.Secret).Data[corev1.DockerConfigJsonKey]) == `{"auths":{"registry.example.com":{"auth":"aW5mcmE="}}}`
+	})
+
+	registryCredentials(team, "registry", "ghcr.io", "dGVhbQ==")
+
+	env.AssertReplicated(t, env.Secrets(), "pull-secret", []string{target}, func(replica metav1.Object) bool {
+		return string(replica.(*corev1.Secret).Data[corev1.DockerConfigJsonKey]) == `{"auths":{"ghcr.io":{"auth":"dGVhbQ=="},"registry.example.com":{"auth":"aW5mcmE="}}}`
+	})
+
+	require.NoError(t, env.Client.CoreV1().Secrets(infra).Delete(context.TODO(), "registry", metav1.DeleteOptions{}))
+
+	env.AssertReplicated(t, env.Secrets(), "pull-secret", []string{target}, func(replica metav1.Object) bool {
+		return string(replica.(*corev1.Secret).Data[corev1.DockerConfigJsonKey]) == `{"auths":{"ghcr.io":{"auth":"dGVhbQ=="}}}`
+	})
+}
+
+func TestCompressValues(t *testing.T) {
+	source := env.Namespace(t, nil)
+	target := env.Namespace(t, nil)
+	bundle := strings.Repeat("-----BEGIN CERTIFICATE-----\n", 100)
+
+	_, err := env.Client.CoreV1().ConfigMaps(source).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "bundle",
+			Annotations: map[string]string{
+				common.ReplicateTo:              target,
+				common.CompressValuesLargerThan: "1Ki",
+			},
+		},
+		Data: map[string]string{"bundle.pem": bundle, "version": "1"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	replica := env.AssertReplicated(t, env.ConfigMaps(), "bundle", []string{target}, nil)[target].(*corev1.ConfigMap)
+	assert.Equal(t, map[string]string{"version": "1"}, replica.Data)
+	assert.Contains(t, replica.BinaryData, "bundle.pem.gz")
+
+	_, err = env.Client.CoreV1().ConfigMaps(source).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "packed",
+			Annotations: map[string]string{
+				common.ReplicationAllowed:           "true",
+				common.ReplicationAllowedNamespaces: target,
+			},
+		},
+		BinaryData: map[string][]byte{"bundle.pem.gz": replica.BinaryData["bundle.pem.gz"]},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = env.Client.CoreV1().ConfigMaps(target).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "unpacked",
+			Annotations: map[string]string{
+				common.ReplicateFromAnnotation: source + "/packed",
+				common.DecompressValues:        "true",
+			},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.ConfigMaps(), "unpacked", []string{target}, func(replica metav1.Object) bool {
+		return replica.(*corev1.ConfigMap).Data["bundle.pem"] == bundle
+	})
+}
+
+func TestResourceVersions(t *testing.T) {
+	namespace := env.Namespace(t, nil)
+
+	created, err := env.Client.CoreV1().ConfigMaps(namespace).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "versioned"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	patched, err := env.Client.CoreV1().ConfigMaps(namespace).Patch(context.TODO(), "versioned", types.StrategicMergePatchType,
+		[]byte(`{"data":{"key":"value"}}`), metav1.PatchOptions{})
+	require.NoError(t, err)
+	assert.NotEqual(t, created.ResourceVersion, patched.ResourceVersion)
+
+	stored, err := env.Client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), "versioned", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, patched.ResourceVersion, stored.ResourceVersion)
+}
+
+func TestRepeatedSourceUpdates(t *testing.T) {
+	source := env.Namespace(t, nil)
+	target := env.Namespace(t, nil)
+
+	secret, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "rotated",
+			Annotations: map[string]string{common.ReplicateTo: target},
+		},
+		Data: map[string][]byte{"password": []byte("v1")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// replicas are patched on every update, so each update has to be detected by its resource version
+	for _, password := range []string{"v1", "v2", "v3"} {
+		if password != "v1" {
+			secret.Data["password"] = []byte(password)
+			secret, err = env.Client.CoreV1().Secrets(source).Update(context.TODO(), secret, metav1.UpdateOptions{})
+			require.NoError(t, err)
+		}
+
+		env.AssertReplicated(t, env.Secrets(), "rotated", []string{target}, func(replica metav1.Object) bool {
+			return string(replica.(*corev1.Secret).Data["password"]) == password
+		})
+	}
+}
+
+func TestLegacyReplicaAdoption(t *testing.T) {
+	source := env.Namespace(t, nil)
+	adopted := env.Namespace(t, nil)
+	foreign := env.Namespace(t, nil)
+	otherSource := env.Namespace(t, nil)
+
+	existing := map[string]*corev1.Secret{
+		adopted: {ObjectMeta: metav1.ObjectMeta{
+			Name:        "legacy",
+			Annotations: map[string]string{common.LegacyReplicatedByAnnotation: source + "/legacy"},
+		}},
+		foreign: {ObjectMeta: metav1.ObjectMeta{
+			Name: "legacy",
+		}},
+		otherSource: {ObjectMeta: metav1.ObjectMeta{
+			Name:        "legacy",
+			Annotations: map[string]string{common.LegacyReplicatedByAnnotation: "elsewhere/legacy"},
+		}},
+	}
+	for namespace, secret := range existing {
+		secret.Data = map[string][]byte{"password": []byte("old")}
+		// the fake API does not assign UIDs
+		secret.UID = types.UID("uid-" + namespace)
+		created, err := env.Client.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+		require.NoError(t, err)
+		existing[namespace] = created
+	}
+
+	_, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "legacy",
+			Annotations: map[string]string{
+				common.ReplicateTo:         strings.Join([]string{adopted, foreign, otherSource}, ","),
+				common.ReplicationStrategy: common.ReplicationStrategyIgnoreExisting,
+			},
+		},
+		Data: map[string][]byte{"password": []byte("new")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// the legacy replica of this source is updated in place instead of being recreated
+	replicas := env.AssertReplicated(t, env.Secrets(), "legacy", []string{adopted}, func(replica metav1.Object) bool {
+		_, bookkept := replica.GetAnnotations()[common.ReplicatedFromVersionAnnotation]
+		return bookkept && string(replica.(*corev1.Secret).Data["password"]) == "new"
+	})
+	if replica, ok := replicas[adopted]; ok {
+		assert.Equal(t, existing[adopted].UID, replica.GetUID())
+	}
+
+	// neither a foreign object nor a legacy replica of another source is adopted
+	assert.Never(t, func() bool {
+		for _, namespace := range []string{foreign, otherSource} {
+			secret, err := env.Client.CoreV1().Secrets(namespace).Get(context.TODO(), "legacy", metav1.GetOptions{})
+			if err != nil || string(secret.Data["password"]) != "old" || secret.UID != existing[namespace].UID {
+				t.Logf("%s/legacy was changed", namespace)
+				return true
+			}
+			if _, bookkept := secret.Annotations[common.ReplicatedFromVersionAnnotation]; bookkept {
+				t.Logf("%s/legacy was adopted", namespace)
+				return true
+			}
+		}
+		return false
+	}, harness.QuietPeriod, harness.Interval)
+
+	// once adopted, the replica is deleted along with its source, while the others are left alone
+	require.NoError(t, env.Client.CoreV1().Secrets(source).Delete(context.TODO(), "legacy", metav1.DeleteOptions{}))
+	env.AssertDeleted(t, env.Secrets(), "legacy", []string{adopted})
+	env.AssertNotDeleted(t, env.Secrets(), "legacy", []string{foreign, otherSource})
+}
+
+func TestClusterRoleProjection(t *testing.T) {
+	target := env.Namespace(t, nil)
+	name := target + "-reader"
+
+	clusterRole, err := env.Client.RbacV1().ClusterRoles().Create(context.TODO(), &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{common.ReplicateTo: target},
+		},
+		Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// the role has the name and rules of the cluster role, and is updated along with it
+	for _, verbs := range [][]string{{"get"}, {"get", "list", "watch"}} {
+		if len(verbs) > 1 {
+			clusterRole.Rules[0].Verbs = verbs
+			clusterRole, err = env.Client.RbacV1().ClusterRoles().Update(context.TODO(), clusterRole, metav1.UpdateOptions{})
+			require.NoError(t, err)
+		}
+
+		env.AssertReplicated(t, env.Roles(), name, []string{target}, func(replica metav1.Object) bool {
+			return assert.ObjectsAreEqual(clusterRole.Rules, replica.(*rbacv1.Role).Rules)
+		})
+	}
+
+	// the role is removed once the cluster role is no longer replicated into the namespace
+	delete(clusterRole.Annotations, common.ReplicateTo)
+	_, err = env.Client.RbacV1().ClusterRoles().Update(context.TODO(), clusterRole, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	env.AssertDeleted(t, env.Roles(), name, []string{target})
+}
+
+func TestReplicateToSameTenant(t *testing.T) {
+	source := env.Namespace(t, map[string]string{"tenant": "a"})
+	sibling := env.Namespace(t, map[string]string{"tenant": "a"})
+	other := env.Namespace(t, map[string]string{"tenant": "b"})
+	untenanted := env.Namespace(t, nil)
+
+	_, err := env.Client.CoreV1().ConfigMaps(source).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "tenant-settings",
+			Annotations: map[string]string{common.ReplicateToSameTenant: "true"},
+		},
+		Data: map[string]string{"level": "debug"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	env.AssertReplicated(t, env.ConfigMaps(), "tenant-settings", []string{sibling}, nil)
+	env.AssertNotReplicated(t, env.ConfigMaps(), "tenant-settings", []string{other, untenanted})
+
+	late := env.Namespace(t, map[string]string{"tenant": "a"})
+	env.AssertReplicated(t, env.ConfigMaps(), "tenant-settings", []string{late}, nil)
+}
+
+func TestServiceAccountTokenModes(t *testing.T) {
+	source := env.Namespace(t, nil)
+	target := env.Namespace(t, nil)
+
+	for _, mode := range []string{common.ServiceAccountTokenModeSkip, common.ServiceAccountTokenModeRegenerate} {
+		_, err := env.Client.CoreV1().Secrets(source).Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "token-" + mode,
+				Annotations: map[string]string{
+					corev1.ServiceAccountNameKey:   "ci",
+					common.ReplicateTo:             target,
+					common.ServiceAccountTokenMode: mode,
+				},
+			},
+			Type: corev1.SecretTypeServiceAccountToken,
+			Data: map[string][]byte{corev1.ServiceAccountTokenKey: []byte("bound-to-" + source)},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	// a new token is issued for the service account of the same name instead of copying the bound one
+	replicas := env.AssertReplicated(t, env.Secrets(), "token-regenerate", []string{target}, nil)
+	if replica, ok := replicas[target]; ok {
+		secret := replica.(*corev1.Secret)
+		assert.Equal(t, corev1.SecretTypeServiceAccountToken, secret.Type)
+		assert.Equal(t, "ci", secret.Annotations[corev1.ServiceAccountNameKey])
+		assert.Empty(t, secret.Data[corev1.ServiceAccountTokenKey])
+	}
+
+	env.AssertNotReplicated(t, env.Secrets(), "token-skip", []string{target})
+}
@@ -0,0 +1,68 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Build information, which is set with -ldflags "-X github.com/mittwald/kubernetes-replicator/version.version=..."
+// (as done by goreleaser, see .goreleaser.yml)
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+var metricBuildInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "kubernetes_replicator",
+	Name:      "build_info",
+	Help:      "Build information of the replicator; always 1",
+}, []string{"version", "commit", "date", "goversion"})
+
+// Info describes the build of the replicator
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Current returns the build information set at build time. The commit and date fall back to the VCS information
+// embedded by the Go toolchain for builds without -ldflags.
+func Current() Info {
+	info := Info{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+
+	if embedded, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range embedded.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+
+	return info
+}
+
+// Register exports the build information as the build_info gauge, which is always 1
+func Register(info Info) {
+	metricBuildInfo.WithLabelValues(info.Version, info.Commit, info.Date, info.GoVersion).Set(1)
+}
+
+// Handler reports the build information as JSON
+type Handler struct {
+	Info Info
+}
+
+func (h *Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(res).Encode(&h.Info)
+}
@@ -0,0 +1,48 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrentDefaultsToDevelopmentBuild(t *testing.T) {
+	info := Current()
+
+	assert.Equal(t, "dev", info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
+
+func TestCurrentPrefersBuildFlags(t *testing.T) {
+	defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+	version, commit, date = "v2.11.0", "abc123", "2024-01-01T00:00:00Z"
+
+	assert.Equal(t, Info{Version: "v2.11.0", Commit: "abc123", Date: "2024-01-01T00:00:00Z", GoVersion: runtime.Version()}, Current())
+}
+
+func TestRegisterExportsBuildInfo(t *testing.T) {
+	Register(Info{Version: "v2.11.0", Commit: "abc123", Date: "2024-01-01T00:00:00Z", GoVersion: "go1.23.0"})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metricBuildInfo.WithLabelValues("v2.11.0", "abc123", "2024-01-01T00:00:00Z", "go1.23.0")))
+}
+
+func TestServesBuildInfo(t *testing.T) {
+	info := Info{Version: "v2.11.0", Commit: "abc123", Date: "2024-01-01T00:00:00Z", GoVersion: "go1.23.0"}
+
+	req, err := http.NewRequest("GET", "/version", nil)
+	assert.Nil(t, err)
+	res := httptest.NewRecorder()
+	(&Handler{Info: info}).ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+	var body Info
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, info, body)
+}